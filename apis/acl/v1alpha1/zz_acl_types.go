@@ -25,6 +25,25 @@ import (
 	v1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
 )
 
+type ACLFromInitParameters struct {
+
+	// The ConfigMap key holding the policy.
+	ConfigMapKeyRef *ConfigMapKeyRefInitParameters `json:"configMapKeyRef,omitempty" tf:"config_map_key_ref,omitempty"`
+}
+
+type ACLFromObservation struct {
+
+	// The ConfigMap key holding the policy.
+	ConfigMapKeyRef *ConfigMapKeyRefObservation `json:"configMapKeyRef,omitempty" tf:"config_map_key_ref,omitempty"`
+}
+
+type ACLFromParameters struct {
+
+	// The ConfigMap key holding the policy.
+	// +kubebuilder:validation:Optional
+	ConfigMapKeyRef *ConfigMapKeyRefParameters `json:"configMapKeyRef,omitempty" tf:"config_map_key_ref,omitempty"`
+}
+
 type ACLInitParameters struct {
 
 	// The policy that defines which devices and users are allowed to connect in your network. Can be either a JSON or a HuJSON string.
@@ -42,6 +61,12 @@ type ACLObservation struct {
 	// The policy that defines which devices and users are allowed to connect in your network. Can be either a JSON or a HuJSON string.
 	ACL *string `json:"acl,omitempty" tf:"acl,omitempty"`
 
+	// The object of aclFrom the policy was last copied from.
+	ACLSource *ACLSourceObservation `json:"aclSource,omitempty" tf:"-"`
+
+	// The console lock held by this ACL.
+	ConsoleLock *ConsoleLockObservation `json:"consoleLock,omitempty" tf:"-"`
+
 	ID *string `json:"id,omitempty" tf:"id,omitempty"`
 
 	// If true, will skip requirement to import acl before allowing changes. Be careful, can cause ACL to be overwritten
@@ -51,12 +76,30 @@ type ACLObservation struct {
 	ResetACLOnDestroy *bool `json:"resetAclOnDestroy,omitempty" tf:"reset_acl_on_destroy,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.externalLink) || (has(self.lockConsole) && self.lockConsole)",message="externalLink requires lockConsole to be true"
 type ACLParameters struct {
 
 	// The policy that defines which devices and users are allowed to connect in your network. Can be either a JSON or a HuJSON string.
 	// +kubebuilder:validation:Optional
 	ACL *string `json:"acl,omitempty" tf:"acl,omitempty"`
 
+	// Take the policy from a ConfigMap instead of setting acl inline. The policy is copied into acl whenever the ConfigMap changes.
+	// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef)",message="configMapKeyRef must be set"
+	// +upjet:crd:field:TFTag=-
+	// +kubebuilder:validation:Optional
+	ACLFrom *ACLFromParameters `json:"aclFrom,omitempty" tf:"-"`
+
+	// URL the locked console editor links to, typically the repository holding the policy. Requires lockConsole.
+	// +kubebuilder:validation:XValidation:rule="isURL(self) && url(self).getScheme() == 'https'",message="must be an https URL"
+	// +upjet:crd:field:TFTag=-
+	// +kubebuilder:validation:Optional
+	ExternalLink *string `json:"externalLink,omitempty" tf:"-"`
+
+	// Lock the ACL editor of the Tailscale admin console while this ACL exists.
+	// +upjet:crd:field:TFTag=-
+	// +kubebuilder:validation:Optional
+	LockConsole *bool `json:"lockConsole,omitempty" tf:"-"`
+
 	// If true, will skip requirement to import acl before allowing changes. Be careful, can cause ACL to be overwritten
 	// +kubebuilder:validation:Optional
 	OverwriteExistingContent *bool `json:"overwriteExistingContent,omitempty" tf:"overwrite_existing_content,omitempty"`
@@ -66,6 +109,75 @@ type ACLParameters struct {
 	ResetACLOnDestroy *bool `json:"resetAclOnDestroy,omitempty" tf:"reset_acl_on_destroy,omitempty"`
 }
 
+type ACLSourceInitParameters struct {
+}
+
+type ACLSourceObservation struct {
+
+	// resourceVersion of the ConfigMap.
+	ResourceVersion *string `json:"resourceVersion,omitempty" tf:"resource_version,omitempty"`
+
+	// Hex encoded SHA-256 of the policy.
+	Sha256 *string `json:"sha256,omitempty" tf:"sha256,omitempty"`
+}
+
+type ACLSourceParameters struct {
+}
+
+type ConfigMapKeyRefInitParameters struct {
+
+	// Key of the policy within the ConfigMap. Defaults to policy.hujson.
+	Key *string `json:"key,omitempty" tf:"key,omitempty"`
+
+	// Name of the ConfigMap.
+	Name *string `json:"name,omitempty" tf:"name,omitempty"`
+
+	// Namespace of the ConfigMap.
+	Namespace *string `json:"namespace,omitempty" tf:"namespace,omitempty"`
+}
+
+type ConfigMapKeyRefObservation struct {
+
+	// Key of the policy within the ConfigMap. Defaults to policy.hujson.
+	Key *string `json:"key,omitempty" tf:"key,omitempty"`
+
+	// Name of the ConfigMap.
+	Name *string `json:"name,omitempty" tf:"name,omitempty"`
+
+	// Namespace of the ConfigMap.
+	Namespace *string `json:"namespace,omitempty" tf:"namespace,omitempty"`
+}
+
+type ConfigMapKeyRefParameters struct {
+
+	// Key of the policy within the ConfigMap. Defaults to policy.hujson.
+	// +kubebuilder:validation:Optional
+	Key *string `json:"key,omitempty" tf:"key,omitempty"`
+
+	// Name of the ConfigMap.
+	// +kubebuilder:validation:Optional
+	Name *string `json:"name" tf:"name,omitempty"`
+
+	// Namespace of the ConfigMap.
+	// +kubebuilder:validation:Optional
+	Namespace *string `json:"namespace" tf:"namespace,omitempty"`
+}
+
+type ConsoleLockInitParameters struct {
+}
+
+type ConsoleLockObservation struct {
+
+	// URL the locked console editor links to.
+	ExternalLink *string `json:"externalLink,omitempty" tf:"external_link,omitempty"`
+
+	// Whether this ACL locked the ACL editor of the admin console.
+	Locked *bool `json:"locked,omitempty" tf:"locked,omitempty"`
+}
+
+type ConsoleLockParameters struct {
+}
+
 // ACLSpec defines the desired state of ACL
 type ACLSpec struct {
 	v1.ResourceSpec `json:",inline"`
@@ -102,9 +214,8 @@ type ACLStatus struct {
 type ACL struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
-	// +kubebuilder:validation:XValidation:rule="!('*' in self.managementPolicies || 'Create' in self.managementPolicies || 'Update' in self.managementPolicies) || has(self.forProvider.acl) || (has(self.initProvider) && has(self.initProvider.acl))",message="spec.forProvider.acl is a required parameter"
-	Spec   ACLSpec   `json:"spec"`
-	Status ACLStatus `json:"status,omitempty"`
+	Spec              ACLSpec   `json:"spec"`
+	Status            ACLStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
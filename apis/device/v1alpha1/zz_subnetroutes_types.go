@@ -28,6 +28,9 @@ import (
 type SubnetRoutesInitParameters struct {
 
 	// The subnet routes that are enabled to be routed by a device
+	// +kubebuilder:validation:MaxItems=1024
+	// +kubebuilder:validation:items:MaxLength=43
+	// +kubebuilder:validation:items:XValidation:rule="isCIDR(self) && cidr(self) == cidr(self).masked()",message="must be a CIDR prefix without host bits, such as 10.0.0.0/24"
 	// +listType=set
 	Routes []*string `json:"routes,omitempty" tf:"routes,omitempty"`
 }
@@ -43,6 +46,9 @@ type SubnetRoutesObservation struct {
 type SubnetRoutesParameters struct {
 
 	// The subnet routes that are enabled to be routed by a device
+	// +kubebuilder:validation:MaxItems=1024
+	// +kubebuilder:validation:items:MaxLength=43
+	// +kubebuilder:validation:items:XValidation:rule="isCIDR(self) && cidr(self) == cidr(self).masked()",message="must be a CIDR prefix without host bits, such as 10.0.0.0/24"
 	// +kubebuilder:validation:Optional
 	// +listType=set
 	Routes []*string `json:"routes,omitempty" tf:"routes,omitempty"`
@@ -33,7 +33,7 @@ func (mg *Integration) GetTerraformResourceType() string {
 
 // GetConnectionDetailsMapping for this Integration
 func (tr *Integration) GetConnectionDetailsMapping() map[string]string {
-	return nil
+	return map[string]string{"client_secret": "clientSecretSecretRef"}
 }
 
 // GetObservation of this Integration
@@ -25,13 +25,28 @@ import (
 	v1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
 )
 
+type ClientSecretSourceInitParameters struct {
+}
+
+type ClientSecretSourceObservation struct {
+
+	// When the client secret last changed, in RFC 3339 format.
+	ChangedAt *string `json:"changedAt,omitempty" tf:"changed_at,omitempty"`
+
+	// Hex encoded SHA-256 of the client secret.
+	Sha256 *string `json:"sha256,omitempty" tf:"sha256,omitempty"`
+}
+
+type ClientSecretSourceParameters struct {
+}
+
 type IntegrationInitParameters struct {
 
 	// Unique identifier for your client.
 	ClientID *string `json:"clientId,omitempty" tf:"client_id,omitempty"`
 
 	// The secret (auth key, token, etc.) used to authenticate with the provider.
-	ClientSecret *string `json:"clientSecret,omitempty" tf:"client_secret,omitempty"`
+	ClientSecretSecretRef v1.SecretKeySelector `json:"clientSecretSecretRef" tf:"-"`
 
 	// Identifies which of the provider's clouds to integrate with.
 	CloudID *string `json:"cloudId,omitempty" tf:"cloud_id,omitempty"`
@@ -48,8 +63,8 @@ type IntegrationObservation struct {
 	// Unique identifier for your client.
 	ClientID *string `json:"clientId,omitempty" tf:"client_id,omitempty"`
 
-	// The secret (auth key, token, etc.) used to authenticate with the provider.
-	ClientSecret *string `json:"clientSecret,omitempty" tf:"client_secret,omitempty"`
+	// The client secret last read from clientSecretSecretRef.
+	ClientSecretSource *ClientSecretSourceObservation `json:"clientSecretSource,omitempty" tf:"-"`
 
 	// Identifies which of the provider's clouds to integrate with.
 	CloudID *string `json:"cloudId,omitempty" tf:"cloud_id,omitempty"`
@@ -71,7 +86,7 @@ type IntegrationParameters struct {
 
 	// The secret (auth key, token, etc.) used to authenticate with the provider.
 	// +kubebuilder:validation:Optional
-	ClientSecret *string `json:"clientSecret,omitempty" tf:"client_secret,omitempty"`
+	ClientSecretSecretRef v1.SecretKeySelector `json:"clientSecretSecretRef" tf:"-"`
 
 	// Identifies which of the provider's clouds to integrate with.
 	// +kubebuilder:validation:Optional
@@ -122,7 +137,7 @@ type IntegrationStatus struct {
 type Integration struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
-	// +kubebuilder:validation:XValidation:rule="!('*' in self.managementPolicies || 'Create' in self.managementPolicies || 'Update' in self.managementPolicies) || has(self.forProvider.clientSecret) || (has(self.initProvider) && has(self.initProvider.clientSecret))",message="spec.forProvider.clientSecret is a required parameter"
+	// +kubebuilder:validation:XValidation:rule="!('*' in self.managementPolicies || 'Create' in self.managementPolicies || 'Update' in self.managementPolicies) || has(self.forProvider.clientSecretSecretRef)",message="spec.forProvider.clientSecretSecretRef is a required parameter"
 	// +kubebuilder:validation:XValidation:rule="!('*' in self.managementPolicies || 'Create' in self.managementPolicies || 'Update' in self.managementPolicies) || has(self.forProvider.postureProvider) || (has(self.initProvider) && has(self.initProvider.postureProvider))",message="spec.forProvider.postureProvider is a required parameter"
 	Spec   IntegrationSpec   `json:"spec"`
 	Status IntegrationStatus `json:"status,omitempty"`
@@ -14,129 +14,96 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// Code generated by upjet. DO NOT EDIT.
-
 package v1alpha1
 
 import (
+	v1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-
-	v1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
 )
 
-type AccountInitParameters struct {
-
-	// Email address to send communications to
-	Email *string `json:"email,omitempty" tf:"email,omitempty"`
-}
-
-type AccountObservation struct {
-
-	// Email address to send communications to
-	Email *string `json:"email,omitempty" tf:"email,omitempty"`
-}
-
-type AccountParameters struct {
-
-	// Email address to send communications to
-	// +kubebuilder:validation:Optional
-	Email *string `json:"email" tf:"email,omitempty"`
-}
-
-type ContactsInitParameters struct {
-
-	// Configuration for communications about important changes to your tailnet
-	Account []AccountInitParameters `json:"account,omitempty" tf:"account,omitempty"`
-
-	// Configuration for communications about security issues affecting your tailnet
-	Security []SecurityInitParameters `json:"security,omitempty" tf:"security,omitempty"`
-
-	// Configuration for communications about misconfigurations in your tailnet
-	Support []SupportInitParameters `json:"support,omitempty" tf:"support,omitempty"`
-}
-
-type ContactsObservation struct {
-
-	// Configuration for communications about important changes to your tailnet
-	Account []AccountObservation `json:"account,omitempty" tf:"account,omitempty"`
-
-	ID *string `json:"id,omitempty" tf:"id,omitempty"`
-
-	// Configuration for communications about security issues affecting your tailnet
-	Security []SecurityObservation `json:"security,omitempty" tf:"security,omitempty"`
-
-	// Configuration for communications about misconfigurations in your tailnet
-	Support []SupportObservation `json:"support,omitempty" tf:"support,omitempty"`
+// Contact is a contact of the tailnet.
+type Contact struct {
+	// Email address to send communications to. Tailscale sends a
+	// verification email to it whenever it changes.
+	// +kubebuilder:validation:Format=email
+	// +kubebuilder:validation:MaxLength=254
+	// +kubebuilder:validation:Pattern=`^[^@\s]+@[^@\s]+$`
+	Email string `json:"email"`
 }
 
+// ContactsParameters are the contacts of the tailnet. Each is a list of a
+// single contact, like the blocks of the tailscale_contacts Terraform
+// resource this kind was generated from.
 type ContactsParameters struct {
-
 	// Configuration for communications about important changes to your tailnet
 	// +kubebuilder:validation:Optional
-	Account []AccountParameters `json:"account,omitempty" tf:"account,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=1
+	Account []Contact `json:"account,omitempty"`
 
 	// Configuration for communications about security issues affecting your tailnet
 	// +kubebuilder:validation:Optional
-	Security []SecurityParameters `json:"security,omitempty" tf:"security,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=1
+	Security []Contact `json:"security,omitempty"`
 
 	// Configuration for communications about misconfigurations in your tailnet
 	// +kubebuilder:validation:Optional
-	Support []SupportParameters `json:"support,omitempty" tf:"support,omitempty"`
-}
-
-type SecurityInitParameters struct {
-
-	// Email address to send communications to
-	Email *string `json:"email,omitempty" tf:"email,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=1
+	Support []Contact `json:"support,omitempty"`
 }
 
-type SecurityObservation struct {
-
-	// Email address to send communications to
-	Email *string `json:"email,omitempty" tf:"email,omitempty"`
-}
+// ContactsInitParameters are the contacts set only when the resource starts
+// managing the contacts of the tailnet.
+type ContactsInitParameters struct {
+	// Configuration for communications about important changes to your tailnet
+	// +kubebuilder:validation:MaxItems=1
+	Account []Contact `json:"account,omitempty"`
 
-type SecurityParameters struct {
+	// Configuration for communications about security issues affecting your tailnet
+	// +kubebuilder:validation:MaxItems=1
+	Security []Contact `json:"security,omitempty"`
 
-	// Email address to send communications to
-	// +kubebuilder:validation:Optional
-	Email *string `json:"email" tf:"email,omitempty"`
+	// Configuration for communications about misconfigurations in your tailnet
+	// +kubebuilder:validation:MaxItems=1
+	Support []Contact `json:"support,omitempty"`
 }
 
-type SupportInitParameters struct {
+// ContactObservation is the observed state of a contact.
+type ContactObservation struct {
+	// Email address communications are sent to.
+	Email string `json:"email,omitempty"`
 
-	// Email address to send communications to
-	Email *string `json:"email,omitempty" tf:"email,omitempty"`
+	// Verified is false until the address has been confirmed through the
+	// verification email Tailscale sent to it.
+	Verified bool `json:"verified"`
 }
 
-type SupportObservation struct {
+// ContactsObservation are the observed contacts of the tailnet.
+type ContactsObservation struct {
+	// ID of the tailnet.
+	ID *string `json:"id,omitempty"`
 
-	// Email address to send communications to
-	Email *string `json:"email,omitempty" tf:"email,omitempty"`
-}
+	// Contact for communications about important changes to your tailnet
+	Account []ContactObservation `json:"account,omitempty"`
 
-type SupportParameters struct {
+	// Contact for communications about security issues affecting your tailnet
+	Security []ContactObservation `json:"security,omitempty"`
 
-	// Email address to send communications to
-	// +kubebuilder:validation:Optional
-	Email *string `json:"email" tf:"email,omitempty"`
+	// Contact for communications about misconfigurations in your tailnet
+	Support []ContactObservation `json:"support,omitempty"`
 }
 
 // ContactsSpec defines the desired state of Contacts
 type ContactsSpec struct {
 	v1.ResourceSpec `json:",inline"`
 	ForProvider     ContactsParameters `json:"forProvider"`
-	// THIS IS A BETA FIELD. It will be honored
-	// unless the Management Policies feature flag is disabled.
-	// InitProvider holds the same fields as ForProvider, with the exception
-	// of Identifier and other resource reference fields. The fields that are
-	// in InitProvider are merged into ForProvider when the resource is created.
-	// The same fields are also added to the terraform ignore_changes hook, to
-	// avoid updating them after creation. This is useful for fields that are
-	// required on creation, but we do not desire to update them after creation,
-	// for example because of an external controller is managing them, like an
-	// autoscaler.
+	// InitProvider holds the same fields as ForProvider. The contacts in
+	// InitProvider are merged into ForProvider when the resource starts
+	// managing the contacts of the tailnet, and are not updated afterwards.
+	// This is useful for contacts that are maintained elsewhere once set.
 	InitProvider ContactsInitParameters `json:"initProvider,omitempty"`
 }
 
@@ -150,9 +117,11 @@ type ContactsStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:storageversion
 
-// Contacts is the Schema for the Contactss API. <no value>
+// Contacts are the account, security and support contacts of a tailnet. The
+// resource is Ready once Tailscale has verified all of them.
 // +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
 // +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="REASON",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].reason",priority=1
 // +kubebuilder:printcolumn:name="EXTERNAL-NAME",type="string",JSONPath=".metadata.annotations.crossplane\\.io/external-name"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,tailscale}
@@ -168,7 +137,7 @@ type Contacts struct {
 
 // +kubebuilder:object:root=true
 
-// ContactsList contains a list of Contactss
+// ContactsList contains a list of Contacts.
 type ContactsList struct {
 	metav1.TypeMeta `json:",inline"`
 	metav1.ListMeta `json:"metadata,omitempty"`
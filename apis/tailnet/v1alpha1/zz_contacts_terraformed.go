@@ -1,141 +0,0 @@
-/*
-Copyright 2025 Millstone HQ.
-
-Licensed under the Apache License, Version 2.0 (the "License");
-you may not use this file except in compliance with the License.
-You may obtain a copy of the License at
-
-    http://www.apache.org/licenses/LICENSE-2.0
-
-Unless required by applicable law or agreed to in writing, software
-distributed under the License is distributed on an "AS IS" BASIS,
-WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-See the License for the specific language governing permissions and
-limitations under the License.
-*/
-
-// Code generated by upjet. DO NOT EDIT.
-
-package v1alpha1
-
-import (
-	"dario.cat/mergo"
-	"github.com/pkg/errors"
-
-	"github.com/crossplane/upjet/v2/pkg/resource"
-	"github.com/crossplane/upjet/v2/pkg/resource/json"
-)
-
-// GetTerraformResourceType returns Terraform resource type for this Contacts
-func (mg *Contacts) GetTerraformResourceType() string {
-	return "tailscale_contacts"
-}
-
-// GetConnectionDetailsMapping for this Contacts
-func (tr *Contacts) GetConnectionDetailsMapping() map[string]string {
-	return nil
-}
-
-// GetObservation of this Contacts
-func (tr *Contacts) GetObservation() (map[string]any, error) {
-	o, err := json.TFParser.Marshal(tr.Status.AtProvider)
-	if err != nil {
-		return nil, err
-	}
-	base := map[string]any{}
-	return base, json.TFParser.Unmarshal(o, &base)
-}
-
-// SetObservation for this Contacts
-func (tr *Contacts) SetObservation(obs map[string]any) error {
-	p, err := json.TFParser.Marshal(obs)
-	if err != nil {
-		return err
-	}
-	return json.TFParser.Unmarshal(p, &tr.Status.AtProvider)
-}
-
-// GetID returns ID of underlying Terraform resource of this Contacts
-func (tr *Contacts) GetID() string {
-	if tr.Status.AtProvider.ID == nil {
-		return ""
-	}
-	return *tr.Status.AtProvider.ID
-}
-
-// GetParameters of this Contacts
-func (tr *Contacts) GetParameters() (map[string]any, error) {
-	p, err := json.TFParser.Marshal(tr.Spec.ForProvider)
-	if err != nil {
-		return nil, err
-	}
-	base := map[string]any{}
-	return base, json.TFParser.Unmarshal(p, &base)
-}
-
-// SetParameters for this Contacts
-func (tr *Contacts) SetParameters(params map[string]any) error {
-	p, err := json.TFParser.Marshal(params)
-	if err != nil {
-		return err
-	}
-	return json.TFParser.Unmarshal(p, &tr.Spec.ForProvider)
-}
-
-// GetInitParameters of this Contacts
-func (tr *Contacts) GetInitParameters() (map[string]any, error) {
-	p, err := json.TFParser.Marshal(tr.Spec.InitProvider)
-	if err != nil {
-		return nil, err
-	}
-	base := map[string]any{}
-	return base, json.TFParser.Unmarshal(p, &base)
-}
-
-// GetInitParameters of this Contacts
-func (tr *Contacts) GetMergedParameters(shouldMergeInitProvider bool) (map[string]any, error) {
-	params, err := tr.GetParameters()
-	if err != nil {
-		return nil, errors.Wrapf(err, "cannot get parameters for resource \"%s/%s\"", tr.GetNamespace(), tr.GetName())
-	}
-	if !shouldMergeInitProvider {
-		return params, nil
-	}
-
-	initParams, err := tr.GetInitParameters()
-	if err != nil {
-		return nil, errors.Wrapf(err, "cannot get init parameters for resource \"%s/%s\"", tr.GetNamespace(), tr.GetName())
-	}
-
-	// Note(lsviben): mergo.WithSliceDeepCopy is needed to merge the
-	// slices from the initProvider to forProvider. As it also sets
-	// overwrite to true, we need to set it back to false, we don't
-	// want to overwrite the forProvider fields with the initProvider
-	// fields.
-	err = mergo.Merge(&params, initParams, mergo.WithSliceDeepCopy, func(c *mergo.Config) {
-		c.Overwrite = false
-	})
-	if err != nil {
-		return nil, errors.Wrapf(err, "cannot merge spec.initProvider and spec.forProvider parameters for resource \"%s/%s\"", tr.GetNamespace(), tr.GetName())
-	}
-
-	return params, nil
-}
-
-// LateInitialize this Contacts using its observed tfState.
-// returns True if there are any spec changes for the resource.
-func (tr *Contacts) LateInitialize(attrs []byte) (bool, error) {
-	params := &ContactsParameters{}
-	if err := json.TFParser.Unmarshal(attrs, params); err != nil {
-		return false, errors.Wrap(err, "failed to unmarshal Terraform state parameters for late-initialization")
-	}
-	opts := []resource.GenericLateInitializerOption{resource.WithZeroValueJSONOmitEmptyFilter(resource.CNameWildcard)}
-
-	li := resource.NewGenericLateInitializer(opts...)
-	return li.LateInitialize(&tr.Spec.ForProvider, params)
-}
-
-// GetTerraformSchemaVersion returns the associated Terraform schema version
-func (tr *Contacts) GetTerraformSchemaVersion() int {
-	return 0
-}
@@ -28,6 +28,7 @@ import (
 type SettingsInitParameters struct {
 
 	// Link to your external ACL definition or management system. Must be a valid URL.
+	// +kubebuilder:validation:XValidation:rule="isURL(self) && url(self).getScheme() == 'https'",message="must be an https URL"
 	AclsExternalLink *string `json:"aclsExternalLink,omitempty" tf:"acls_external_link,omitempty"`
 
 	AclsExternallyManagedOn *bool `json:"aclsExternallyManagedOn,omitempty" tf:"acls_externally_managed_on,omitempty"`
@@ -39,6 +40,8 @@ type SettingsInitParameters struct {
 	DevicesAutoUpdatesOn *bool `json:"devicesAutoUpdatesOn,omitempty" tf:"devices_auto_updates_on,omitempty"`
 
 	// The key expiry duration for devices on this tailnet
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=180
 	DevicesKeyDurationDays *float64 `json:"devicesKeyDurationDays,omitempty" tf:"devices_key_duration_days,omitempty"`
 
 	// Whether network flog logs are enabled for the tailnet
@@ -54,6 +57,7 @@ type SettingsInitParameters struct {
 	UsersApprovalOn *bool `json:"usersApprovalOn,omitempty" tf:"users_approval_on,omitempty"`
 
 	// Which user roles are allowed to join external tailnets
+	// +kubebuilder:validation:Enum=none;admin;member
 	UsersRoleAllowedToJoinExternalTailnet *string `json:"usersRoleAllowedToJoinExternalTailnet,omitempty" tf:"users_role_allowed_to_join_external_tailnet,omitempty"`
 }
 
@@ -91,9 +95,11 @@ type SettingsObservation struct {
 	UsersRoleAllowedToJoinExternalTailnet *string `json:"usersRoleAllowedToJoinExternalTailnet,omitempty" tf:"users_role_allowed_to_join_external_tailnet,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.aclsExternalLink) || (has(self.aclsExternallyManagedOn) && self.aclsExternallyManagedOn)",message="aclsExternalLink requires aclsExternallyManagedOn to be true"
 type SettingsParameters struct {
 
 	// Link to your external ACL definition or management system. Must be a valid URL.
+	// +kubebuilder:validation:XValidation:rule="isURL(self) && url(self).getScheme() == 'https'",message="must be an https URL"
 	// +kubebuilder:validation:Optional
 	AclsExternalLink *string `json:"aclsExternalLink,omitempty" tf:"acls_external_link,omitempty"`
 
@@ -109,6 +115,8 @@ type SettingsParameters struct {
 	DevicesAutoUpdatesOn *bool `json:"devicesAutoUpdatesOn,omitempty" tf:"devices_auto_updates_on,omitempty"`
 
 	// The key expiry duration for devices on this tailnet
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=180
 	// +kubebuilder:validation:Optional
 	DevicesKeyDurationDays *float64 `json:"devicesKeyDurationDays,omitempty" tf:"devices_key_duration_days,omitempty"`
 
@@ -116,6 +124,12 @@ type SettingsParameters struct {
 	// +kubebuilder:validation:Optional
 	NetworkFlowLoggingOn *bool `json:"networkFlowLoggingOn,omitempty" tf:"network_flow_logging_on,omitempty"`
 
+	// What to do with the tailnet settings when this resource is deleted: Retain leaves them as they are, RestoreDefaults resets them to the values of a new tailnet and RestoreAdopted restores the values they had when this resource was created. Defaults to Retain.
+	// +kubebuilder:validation:Enum=Retain;RestoreDefaults;RestoreAdopted
+	// +upjet:crd:field:TFTag=-
+	// +kubebuilder:validation:Optional
+	OnDelete *string `json:"onDelete,omitempty" tf:"-"`
+
 	// Whether identity collection is enabled for device posture integrations for the tailnet
 	// +kubebuilder:validation:Optional
 	PostureIdentityCollectionOn *bool `json:"postureIdentityCollectionOn,omitempty" tf:"posture_identity_collection_on,omitempty"`
@@ -129,6 +143,7 @@ type SettingsParameters struct {
 	UsersApprovalOn *bool `json:"usersApprovalOn,omitempty" tf:"users_approval_on,omitempty"`
 
 	// Which user roles are allowed to join external tailnets
+	// +kubebuilder:validation:Enum=none;admin;member
 	// +kubebuilder:validation:Optional
 	UsersRoleAllowedToJoinExternalTailnet *string `json:"usersRoleAllowedToJoinExternalTailnet,omitempty" tf:"users_role_allowed_to_join_external_tailnet,omitempty"`
 }
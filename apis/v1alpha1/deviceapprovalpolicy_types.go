@@ -0,0 +1,189 @@
+/*
+Copyright 2025 Millstone HQ.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ApprovalRule approves devices matching every field that is set.
+type ApprovalRule struct {
+	// Name of the rule, reported with every approval.
+	Name string `json:"name"`
+
+	// Tags the device must carry, all of them.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// User that must own the device, such as alice@example.com.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// HostnameRegex the hostname of the device must match, using RE2
+	// syntax. It is not anchored.
+	// +optional
+	HostnameRegex string `json:"hostnameRegex,omitempty"`
+
+	// PostureAttributes the device must have, such as
+	// {"node:os": "linux", "custom:compliant": "true"}. Values are compared
+	// as strings.
+	// +optional
+	PostureAttributes map[string]string `json:"postureAttributes,omitempty"`
+}
+
+// NonMatchingAction is taken on devices that match no rule.
+type NonMatchingAction string
+
+// Actions for devices that match no rule.
+const (
+	// NonMatchingIgnore leaves devices waiting for manual approval.
+	NonMatchingIgnore NonMatchingAction = "Ignore"
+	// NonMatchingDeny removes the device from the tailnet.
+	NonMatchingDeny NonMatchingAction = "Deny"
+	// NonMatchingExpire expires the node key of the device.
+	NonMatchingExpire NonMatchingAction = "Expire"
+)
+
+// NonMatchingPolicy describes what happens to devices that match no rule.
+type NonMatchingPolicy struct {
+	// Action taken on devices that still wait for approval after the window.
+	// +kubebuilder:validation:Enum=Ignore;Deny;Expire
+	// +kubebuilder:default=Ignore
+	Action NonMatchingAction `json:"action"`
+
+	// Window devices are given to be approved, manually or by a later
+	// version of the rules, before the action is taken.
+	// +kubebuilder:default="24h"
+	// +optional
+	Window metav1.Duration `json:"window,omitempty"`
+}
+
+// DeviceApprovalPolicySpec defines the desired state of a
+// DeviceApprovalPolicy.
+type DeviceApprovalPolicySpec struct {
+	// Rules approving devices. A device is approved by the first rule it
+	// matches.
+	// +kubebuilder:validation:MinItems=1
+	Rules []ApprovalRule `json:"rules"`
+
+	// NonMatching devices are left waiting for manual approval unless
+	// another action is configured.
+	// +optional
+	NonMatching *NonMatchingPolicy `json:"nonMatching,omitempty"`
+
+	// ProviderConfigReference of the tailnet.
+	// +kubebuilder:default={"name": "default"}
+	// +optional
+	ProviderConfigReference *xpv1.Reference `json:"providerConfigRef,omitempty"`
+}
+
+// ApprovalDecision is the outcome for a device.
+type ApprovalDecision string
+
+// Decisions of a DeviceApprovalPolicy.
+const (
+	DecisionApproved ApprovalDecision = "Approved"
+	DecisionDenied   ApprovalDecision = "Denied"
+	DecisionExpired  ApprovalDecision = "Expired"
+)
+
+// DeviceDecision records a decision taken on a device.
+type DeviceDecision struct {
+	// ID of the device.
+	ID string `json:"id"`
+
+	// Name of the device.
+	Name string `json:"name"`
+
+	// Decision taken.
+	Decision ApprovalDecision `json:"decision"`
+
+	// Rule that approved the device, if any.
+	// +optional
+	Rule string `json:"rule,omitempty"`
+
+	// Reason for the decision.
+	Reason string `json:"reason"`
+
+	// Time of the decision.
+	Time metav1.Time `json:"time"`
+}
+
+// DeviceApprovalPolicyStatus represents the observed state of a
+// DeviceApprovalPolicy.
+type DeviceApprovalPolicyStatus struct {
+	xpv1.ConditionedStatus `json:",inline"`
+
+	// Decisions taken by the policy, most recent first.
+	// +optional
+	Decisions []DeviceDecision `json:"decisions,omitempty"`
+
+	// PendingDevices is the number of devices waiting for approval.
+	// +optional
+	PendingDevices int `json:"pendingDevices"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="PENDING",type="integer",JSONPath=".status.pendingDevices"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,tailscale}
+// +kubebuilder:subresource:status
+
+// A DeviceApprovalPolicy approves devices waiting for approval that match
+// its rules, and optionally denies or expires those that don't.
+type DeviceApprovalPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeviceApprovalPolicySpec   `json:"spec"`
+	Status DeviceApprovalPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DeviceApprovalPolicyList contains a list of DeviceApprovalPolicy.
+type DeviceApprovalPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeviceApprovalPolicy `json:"items"`
+}
+
+// GetCondition of this DeviceApprovalPolicy.
+func (p *DeviceApprovalPolicy) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return p.Status.GetCondition(ct)
+}
+
+// SetConditions of this DeviceApprovalPolicy.
+func (p *DeviceApprovalPolicy) SetConditions(c ...xpv1.Condition) {
+	p.Status.SetConditions(c...)
+}
+
+// DeviceApprovalPolicy type metadata.
+var (
+	DeviceApprovalPolicyKind             = "DeviceApprovalPolicy"
+	DeviceApprovalPolicyGroupKind        = schema.GroupKind{Group: Group, Kind: DeviceApprovalPolicyKind}.String()
+	DeviceApprovalPolicyKindAPIVersion   = DeviceApprovalPolicyKind + "." + SchemeGroupVersion.String()
+	DeviceApprovalPolicyGroupVersionKind = SchemeGroupVersion.WithKind(DeviceApprovalPolicyKind)
+)
+
+func init() {
+	SchemeBuilder.Register(&DeviceApprovalPolicy{}, &DeviceApprovalPolicyList{})
+}
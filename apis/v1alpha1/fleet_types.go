@@ -0,0 +1,159 @@
+/*
+Copyright 2025 Millstone HQ.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DeviceSelector selects devices of a tailnet. A device must match every
+// field that is set.
+type DeviceSelector struct {
+	// NamePrefix matches devices whose name starts with the prefix, like the
+	// name_prefix filter of the tailscale_devices data source.
+	// +optional
+	NamePrefix string `json:"namePrefix,omitempty"`
+
+	// Tags matches devices that carry all of the tags.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// OS matches devices running one of the operating systems, such as
+	// linux, windows, macOS, iOS or android.
+	// +optional
+	OS []string `json:"os,omitempty"`
+
+	// User matches devices owned by the user, such as alice@example.com.
+	// +optional
+	User string `json:"user,omitempty"`
+}
+
+// FleetTemplate describes the device resource created for each device.
+type FleetTemplate struct {
+	// Kind of the device resource.
+	// +kubebuilder:validation:Enum=Tags;Authorization;Key;SubnetRoutes
+	Kind string `json:"kind"`
+
+	// Labels added to every device resource.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// ForProvider of every device resource. The device is set through the
+	// external name of each resource, so it must not be given here.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	ForProvider runtime.RawExtension `json:"forProvider,omitempty"`
+
+	// DeletionPolicy of every device resource.
+	// +kubebuilder:validation:Enum=Orphan;Delete
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy xpv1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// FleetSpec defines the desired state of a Fleet.
+type FleetSpec struct {
+	// Selector of the devices in the fleet.
+	Selector DeviceSelector `json:"selector"`
+
+	// Template of the device resource kept for each device.
+	Template FleetTemplate `json:"template"`
+
+	// ProviderConfigReference of the tailnet, also used by every device
+	// resource.
+	// +kubebuilder:default={"name": "default"}
+	// +optional
+	ProviderConfigReference *xpv1.Reference `json:"providerConfigRef,omitempty"`
+}
+
+// FleetDevice is a device of a fleet.
+type FleetDevice struct {
+	// ID of the device.
+	ID string `json:"id"`
+
+	// Name of the device.
+	Name string `json:"name"`
+
+	// Resource is the name of the device resource kept for the device.
+	Resource string `json:"resource"`
+}
+
+// FleetStatus represents the observed state of a Fleet.
+type FleetStatus struct {
+	xpv1.ConditionedStatus `json:",inline"`
+
+	// Devices currently matched by the selector.
+	// +optional
+	Devices []FleetDevice `json:"devices,omitempty"`
+
+	// MatchedDevices is the number of devices matched by the selector.
+	// +optional
+	MatchedDevices int `json:"matchedDevices"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="KIND",type="string",JSONPath=".spec.template.kind"
+// +kubebuilder:printcolumn:name="DEVICES",type="integer",JSONPath=".status.matchedDevices"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,tailscale}
+// +kubebuilder:subresource:status
+
+// A Fleet keeps one device resource for each device matched by its
+// selector, creating and deleting them as devices come and go.
+type Fleet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FleetSpec   `json:"spec"`
+	Status FleetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FleetList contains a list of Fleet.
+type FleetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Fleet `json:"items"`
+}
+
+// GetCondition of this Fleet.
+func (f *Fleet) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return f.Status.GetCondition(ct)
+}
+
+// SetConditions of this Fleet.
+func (f *Fleet) SetConditions(c ...xpv1.Condition) {
+	f.Status.SetConditions(c...)
+}
+
+// Fleet type metadata.
+var (
+	FleetKind             = "Fleet"
+	FleetGroupKind        = schema.GroupKind{Group: Group, Kind: FleetKind}.String()
+	FleetKindAPIVersion   = FleetKind + "." + SchemeGroupVersion.String()
+	FleetGroupVersionKind = SchemeGroupVersion.WithKind(FleetKind)
+)
+
+func init() {
+	SchemeBuilder.Register(&Fleet{}, &FleetList{})
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 Millstone HQ.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PodAuthKeySpec defines the desired state of a PodAuthKey.
+type PodAuthKeySpec struct {
+	// Selector of the pods in the namespace of the PodAuthKey that get a key.
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// Tags applied to the devices authenticated by the keys.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// ExpirySeconds after which an unused key expires.
+	// +kubebuilder:validation:Minimum=60
+	// +kubebuilder:default=3600
+	// +optional
+	ExpirySeconds int64 `json:"expirySeconds,omitempty"`
+
+	// ProviderConfigReference of the tailnet, used by every key.
+	// +kubebuilder:default={"name": "default"}
+	// +optional
+	ProviderConfigReference *xpv1.Reference `json:"providerConfigRef,omitempty"`
+}
+
+// PodKey is the key of a pod.
+type PodKey struct {
+	// Pod the key was minted for.
+	Pod string `json:"pod"`
+
+	// Resource is the name of the tailnetkey Key resource.
+	Resource string `json:"resource"`
+
+	// Secret the key is written to, in the namespace of the pod.
+	Secret string `json:"secret"`
+}
+
+// PodAuthKeyStatus represents the observed state of a PodAuthKey.
+type PodAuthKeyStatus struct {
+	xpv1.ConditionedStatus `json:",inline"`
+
+	// Keys of the selected pods.
+	// +optional
+	Keys []PodKey `json:"keys,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Namespaced,categories={crossplane,managed,tailscale}
+// +kubebuilder:subresource:status
+
+// A PodAuthKey mints a single-use, ephemeral, preauthorized auth key for
+// every selected pod, writes it to a Secret named after the pod and revokes
+// it when the pod goes away.
+type PodAuthKey struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PodAuthKeySpec   `json:"spec"`
+	Status PodAuthKeyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PodAuthKeyList contains a list of PodAuthKey.
+type PodAuthKeyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PodAuthKey `json:"items"`
+}
+
+// GetCondition of this PodAuthKey.
+func (p *PodAuthKey) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return p.Status.GetCondition(ct)
+}
+
+// SetConditions of this PodAuthKey.
+func (p *PodAuthKey) SetConditions(c ...xpv1.Condition) {
+	p.Status.SetConditions(c...)
+}
+
+// PodAuthKey type metadata.
+var (
+	PodAuthKeyKind             = "PodAuthKey"
+	PodAuthKeyGroupKind        = schema.GroupKind{Group: Group, Kind: PodAuthKeyKind}.String()
+	PodAuthKeyKindAPIVersion   = PodAuthKeyKind + "." + SchemeGroupVersion.String()
+	PodAuthKeyGroupVersionKind = SchemeGroupVersion.WithKind(PodAuthKeyKind)
+)
+
+func init() {
+	SchemeBuilder.Register(&PodAuthKey{}, &PodAuthKeyList{})
+}
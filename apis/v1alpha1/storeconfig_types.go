@@ -0,0 +1,95 @@
+/*
+Copyright 2025 Millstone HQ.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// StoreConfigSpec defines the desired state of a StoreConfig.
+type StoreConfigSpec struct {
+	// DefaultScope prefixes the name of every secret written to the store,
+	// such as a Vault path.
+	// +optional
+	DefaultScope string `json:"defaultScope,omitempty"`
+
+	// Plugin serving the External Secret Store plugin API.
+	Plugin PluginStoreConfig `json:"plugin"`
+}
+
+// PluginStoreConfig configures an External Secret Store plugin.
+type PluginStoreConfig struct {
+	// Endpoint of the plugin, such as ess-plugin-vault.crossplane-system:4040.
+	// +kubebuilder:validation:MinLength=1
+	Endpoint string `json:"endpoint"`
+
+	// ConfigRef is the configuration of the plugin, such as a VaultConfig,
+	// sent to the plugin with every request.
+	// +optional
+	ConfigRef PluginConfigReference `json:"configRef,omitempty"`
+}
+
+// PluginConfigReference refers to the configuration of a plugin.
+type PluginConfigReference struct {
+	// APIVersion of the configuration.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the configuration.
+	Kind string `json:"kind"`
+
+	// Name of the configuration.
+	Name string `json:"name"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="ENDPOINT",type="string",JSONPath=".spec.plugin.endpoint"
+// +kubebuilder:printcolumn:name="SCOPE",type="string",JSONPath=".spec.defaultScope"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,store,tailscale}
+
+// A StoreConfig is an External Secret Store the connection details of
+// resources are written to instead of Kubernetes Secrets, when referenced by
+// their ProviderConfig and the EnableAlphaExternalSecretStores feature is
+// enabled.
+type StoreConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec StoreConfigSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// StoreConfigList contains a list of StoreConfig.
+type StoreConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StoreConfig `json:"items"`
+}
+
+// StoreConfig type metadata.
+var (
+	StoreConfigKind             = "StoreConfig"
+	StoreConfigGroupKind        = schema.GroupKind{Group: Group, Kind: StoreConfigKind}.String()
+	StoreConfigKindAPIVersion   = StoreConfigKind + "." + SchemeGroupVersion.String()
+	StoreConfigGroupVersionKind = SchemeGroupVersion.WithKind(StoreConfigKind)
+)
+
+func init() {
+	SchemeBuilder.Register(&StoreConfig{}, &StoreConfigList{})
+}
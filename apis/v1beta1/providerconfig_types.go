@@ -13,6 +13,40 @@ type ProviderConfigSpec struct {
 	// Credentials required to authenticate to Tailscale API.
 	// +kubebuilder:validation:Required
 	Credentials ProviderCredentials `json:"credentials"`
+
+	// Tailnet the credentials operate on, such as example.com. Defaults to
+	// the tailnet of the credentials.
+	// +optional
+	Tailnet string `json:"tailnet,omitempty"`
+
+	// StoreConfigReference to the StoreConfig the connection details of
+	// auth keys, OAuth clients and webhooks are written to, instead of
+	// Kubernetes Secrets. Requires the EnableAlphaExternalSecretStores
+	// feature.
+	// +optional
+	StoreConfigReference *xpv1.Reference `json:"storeConfigRef,omitempty"`
+
+	// PodAuthKeys lists the namespaces whose PodAuthKeys may mint keys with
+	// this ProviderConfig, and the tags they may apply. PodAuthKeys cannot
+	// use a ProviderConfig that does not list their namespace.
+	// +optional
+	PodAuthKeys []PodAuthKeyGrant `json:"podAuthKeys,omitempty"`
+}
+
+// PodAuthKeyGrant allows the PodAuthKeys of a namespace to mint keys.
+type PodAuthKeyGrant struct {
+	// Namespace of the PodAuthKeys, or * for every namespace.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Tags the keys may carry, such as tag:k8s.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// Untagged allows keys without tags. Their devices belong to the user
+	// or OAuth client of the credentials.
+	// +optional
+	Untagged bool `json:"untagged,omitempty"`
 }
 
 // ProviderCredentials contains credentials for authenticating to Tailscale.
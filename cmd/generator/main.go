@@ -2,29 +2,81 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/crossplane/upjet/v2/pkg/pipeline"
 
 	"github.com/millstonehq/provider-upjet-tailscale/config"
+	"github.com/millstonehq/provider-upjet-tailscale/config/common"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/coverage"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/crdtypes"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	strict := flag.Bool("strict", false, "Fail if a resource of the Terraform provider schema is neither included nor excluded in config/provider.go.")
+	reportFile := flag.String("report", "", "File to write the schema coverage report to, in addition to stdout.")
+	flag.Parse()
+	if flag.NArg() < 1 {
 		panic("root directory is required as argument")
 	}
 
-	rootDir := os.Args[1]
+	rootDir := flag.Arg(0)
 	absRootDir, err := filepath.Abs(rootDir)
 	if err != nil {
 		panic(fmt.Sprintf("cannot get absolute path for root directory: %v", err))
 	}
 
+	// Report the resources and data sources of the schema the include and
+	// exclude lists leave out, before generating anything
+	report, err := coverage.Run(config.ProviderSchema(), config.IncludeList, config.ExcludeList, config.DataSources)
+	if err != nil {
+		panic(fmt.Sprintf("cannot report schema coverage: %v", err))
+	}
+	if err := report.Write(os.Stdout); err != nil {
+		panic(fmt.Sprintf("cannot write schema coverage report: %v", err))
+	}
+	if *reportFile != "" {
+		f, err := os.Create(*reportFile) //nolint:gosec // the path is supplied by the caller
+		if err != nil {
+			panic(fmt.Sprintf("cannot create schema coverage report: %v", err))
+		}
+		if err := report.Write(f); err != nil {
+			panic(fmt.Sprintf("cannot write schema coverage report: %v", err))
+		}
+		if err := f.Close(); err != nil {
+			panic(fmt.Sprintf("cannot write schema coverage report: %v", err))
+		}
+	}
+	if *strict && !report.OK() {
+		fmt.Fprintln(os.Stderr, "Every resource of the Terraform provider schema must match exactly one of IncludeList and ExcludeList in config/provider.go")
+		os.Exit(1)
+	}
+
 	pc := config.GetProvider()
 
+	// Generate every controller with async callbacks, so that any kind can
+	// be made async at runtime with --async-kinds. The resource
+	// configurations only set the default.
+	for _, r := range pc.Resources {
+		r.UseAsync = true
+	}
+
 	// Tailscale resources are cluster-scoped only for v1
 	// Passing nil for namespaced provider generates resources in apis/ directly
 	pipeline.Run(pc, nil, absRootDir)
+
+	// Keep validation to the parameters and the fields that exist only in
+	// Kubernetes out of the Terraform state, which upjet cannot be told to do
+	for _, r := range pc.Resources {
+		spec, status := common.KubernetesOnly(r)
+		k := crdtypes.Kind{Name: r.Kind, SpecOnly: spec, StatusOnly: status, Rules: common.Rules(r)}
+		path := filepath.Join(absRootDir, "apis", r.ShortGroup, r.Version, "zz_"+strings.ToLower(r.Kind)+"_types.go")
+		if err := crdtypes.AdjustFile(path, k); err != nil {
+			panic(fmt.Sprintf("cannot adjust generated types: %v", err))
+		}
+	}
 }
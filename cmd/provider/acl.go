@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/aclpolicy"
+)
+
+// aclCommands are the "acl" subcommands, which evaluate a Tailscale policy
+// file offline with the same evaluator the provider uses.
+type aclCommands struct {
+	check *kingpin.CmdClause
+	ssh   *kingpin.CmdClause
+	test  *kingpin.CmdClause
+
+	checkPolicy *string
+	checkSrc    *string
+	checkDst    *string
+	checkProto  *string
+
+	sshPolicy *string
+	sshSrc    *string
+	sshDst    *string
+	sshUser   *string
+
+	testPolicy *string
+}
+
+func registerACLCommands(app *kingpin.Application) *aclCommands {
+	acl := app.Command("acl", "Evaluate a Tailscale policy file locally.")
+	c := &aclCommands{}
+
+	c.check = acl.Command("check", "Check whether a source can reach a destination port and report the rule that allows it.")
+	c.checkPolicy = c.check.Flag("policy", "Path to the HuJSON policy file.").Required().String()
+	c.checkSrc = c.check.Flag("src", "Source user, group, tag, host or IP.").Required().String()
+	c.checkDst = c.check.Flag("dst", "Destination such as tag:db:5432.").Required().String()
+	c.checkProto = c.check.Flag("proto", "IP protocol.").Default("tcp").String()
+
+	c.ssh = acl.Command("ssh", "List the SSH rules that apply between a source and a destination.")
+	c.sshPolicy = c.ssh.Flag("policy", "Path to the HuJSON policy file.").Required().String()
+	c.sshSrc = c.ssh.Flag("src", "Source user, group or tag.").Required().String()
+	c.sshDst = c.ssh.Flag("dst", "Destination tag, user or host.").Required().String()
+	c.sshUser = c.ssh.Flag("user", "Only report the rule that allows logging in as this user.").String()
+
+	c.test = acl.Command("test", "Run the tests and sshTests blocks of a policy file.")
+	c.testPolicy = c.test.Flag("policy", "Path to the HuJSON policy file.").Required().String()
+
+	return c
+}
+
+// run executes the selected subcommand and reports whether it was one of
+// the acl subcommands. Failed checks and tests exit non-zero.
+func (c *aclCommands) run(cmd string, out io.Writer) bool {
+	var ok bool
+	var err error
+	switch cmd {
+	case c.check.FullCommand():
+		ok, err = c.runCheck(out)
+	case c.ssh.FullCommand():
+		ok, err = c.runSSH(out)
+	case c.test.FullCommand():
+		ok, err = c.runTest(out)
+	default:
+		return false
+	}
+	kingpin.FatalIfError(err, "Cannot evaluate policy")
+	if !ok {
+		os.Exit(1)
+	}
+	return true
+}
+
+func loadPolicy(path string) (*aclpolicy.Policy, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("cannot read policy file: %w", err)
+	}
+	return aclpolicy.Parse(data)
+}
+
+func (c *aclCommands) runCheck(out io.Writer) (bool, error) {
+	p, err := loadPolicy(*c.checkPolicy)
+	if err != nil {
+		return false, err
+	}
+	d, err := p.Check(*c.checkSrc, *c.checkDst, *c.checkProto)
+	if err != nil {
+		return false, err
+	}
+	if !d.Allowed {
+		fmt.Fprintf(out, "deny: %s -> %s (%s): no rule matches\n", *c.checkSrc, *c.checkDst, *c.checkProto)
+		return false, nil
+	}
+	fmt.Fprintf(out, "accept: %s -> %s (%s): allowed by %s\n", *c.checkSrc, *c.checkDst, *c.checkProto, d.Rule)
+	return true, nil
+}
+
+func (c *aclCommands) runSSH(out io.Writer) (bool, error) {
+	p, err := loadPolicy(*c.sshPolicy)
+	if err != nil {
+		return false, err
+	}
+	if *c.sshUser != "" {
+		m := p.CheckSSH(*c.sshSrc, *c.sshDst, *c.sshUser)
+		if m == nil {
+			fmt.Fprintf(out, "deny: %s -> %s as %s: no rule matches\n", *c.sshSrc, *c.sshDst, *c.sshUser)
+			return false, nil
+		}
+		fmt.Fprintf(out, "%s: %s -> %s as %s: allowed by %s\n", m.Action, *c.sshSrc, *c.sshDst, *c.sshUser, m.Rule)
+		return true, nil
+	}
+	rules := p.SSHRules(*c.sshSrc, *c.sshDst)
+	if len(rules) == 0 {
+		fmt.Fprintf(out, "no SSH rules apply to %s -> %s\n", *c.sshSrc, *c.sshDst)
+		return false, nil
+	}
+	for _, m := range rules {
+		line := fmt.Sprintf("%s: %s users=%s", m.Rule, m.Action, strings.Join(m.Users, ","))
+		if m.CheckPeriod != "" {
+			line += " checkPeriod=" + m.CheckPeriod
+		}
+		fmt.Fprintln(out, line)
+	}
+	return true, nil
+}
+
+func (c *aclCommands) runTest(out io.Writer) (bool, error) {
+	p, err := loadPolicy(*c.testPolicy)
+	if err != nil {
+		return false, err
+	}
+	results := p.RunTests()
+	failed := 0
+	for _, r := range results {
+		if r.Passed() {
+			fmt.Fprintf(out, "PASS %s\n", r.Name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(out, "FAIL %s\n", r.Name)
+		for _, f := range r.Failures {
+			fmt.Fprintf(out, "    %s\n", f)
+		}
+	}
+	fmt.Fprintf(out, "%d of %d tests passed\n", len(results)-failed, len(results))
+	return failed == 0, nil
+}
@@ -2,12 +2,15 @@
 package main
 
 import (
+	"crypto/tls"
+	"net"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	xpcontroller "github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
@@ -16,9 +19,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/millstonehq/provider-upjet-tailscale/apis"
@@ -26,38 +31,105 @@ import (
 	"github.com/millstonehq/provider-upjet-tailscale/internal/clients"
 	"github.com/millstonehq/provider-upjet-tailscale/internal/controller"
 	"github.com/millstonehq/provider-upjet-tailscale/internal/features"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/health"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/logs"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/metrics"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/orphan"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/receiver"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/secretstore"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/secretstore/stub"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/selftest"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/shutdown"
 )
 
+// webhookCertDir is where Crossplane mounts the webhook server certificate.
+const webhookCertDir = "/webhook/certs"
+
 func main() {
 	var (
 		app                    = kingpin.New(filepath.Base(os.Args[0]), "Tailscale support for Crossplane.").DefaultEnvars()
 		debug                  = app.Flag("debug", "Run with debug logging.").Short('d').Bool()
+		logFormat              = app.Flag("log-format", "Format of the logs, json or console. Defaults to console with --debug and json otherwise.").Envar("LOG_FORMAT").Enum(logs.FormatJSON, logs.FormatConsole)
+		logLevel               = app.Flag("log-level", "Minimum level of the logs, debug, info or error. --debug implies debug.").Default("info").Envar("LOG_LEVEL").Enum("debug", "info", "error")
 		syncInterval           = app.Flag("sync", "Controller manager sync period such as 300ms, 1.5h, or 2h45m").Short('s').Default("1h").Duration()
 		pollInterval           = app.Flag("poll", "Poll interval controls how often an individual resource should be checked for drift.").Default("10m").Duration()
 		leaderElection         = app.Flag("leader-election", "Use leader election for the controller manager.").Short('l').Default("false").Envar("LEADER_ELECTION").Bool()
 		maxReconcileRate       = app.Flag("max-reconcile-rate", "The global maximum rate per second at which resources may checked for drift from the desired state.").Default("10").Int()
 		enableManagementPolicies = app.Flag("enable-management-policies", "Enable support for Management Policies.").Default("true").Envar("ENABLE_MANAGEMENT_POLICIES").Bool()
+		enableExternalSecretStores = app.Flag("enable-external-secret-stores", "Enable support for External Secret Stores.").Default("false").Envar("ENABLE_EXTERNAL_SECRET_STORES").Bool()
+		essTLSCertDir          = app.Flag("ess-tls-cert-dir", "Directory of the ca.crt, tls.crt and tls.key used to connect to External Secret Store plugins. Plugins are connected to without TLS if empty.").Default("").Envar("ESS_TLS_CERTS_DIR").String()
+		webhookReceiverAddr    = app.Flag("webhook-receiver-address", "Address to receive Tailscale webhook events on, such as :9090. Disabled if empty.").Default("").Envar("WEBHOOK_RECEIVER_ADDRESS").String()
+		asyncKinds             = app.Flag("async-kinds", "Kinds whose Terraform applies and destroys run asynchronously, such as ACL.acl.tailscale.upbound.io, instead of the defaults. Set to none to run every kind synchronously.").Strings()
+		healthProbeAddr        = app.Flag("health-probe-address", "Address to serve the /healthz and /readyz probes on.").Default(":8081").String()
+		pprofAddr              = app.Flag("pprof-address", "Address to serve pprof profiles on, such as localhost:6060. Disabled if empty.").Default("").String()
+		terraformVersion       = app.Flag("terraform-version", "Version of the Terraform CLI on the PATH.").Default(clients.DefaultTerraformVersion).Envar("TERRAFORM_VERSION").String()
+		providerSource         = app.Flag("terraform-provider-source", "Source of the Terraform provider, such as tailscale/tailscale.").Default(clients.TerraformProviderSource).Envar("TERRAFORM_PROVIDER_SOURCE").String()
+		providerVersion        = app.Flag("terraform-provider-version", "Version of the Terraform provider.").Default(clients.TerraformProviderVersion).Envar("TERRAFORM_PROVIDER_VERSION").String()
+		nativeProviderPath     = app.Flag("terraform-native-provider-path", "Path of a Terraform provider binary to run once and share between Terraform runs, instead of installing and starting the provider for every run.").Default("").Envar("TERRAFORM_NATIVE_PROVIDER_PATH").String()
+		pluginProcessTTL       = app.Flag("provider-ttl", "Number of Terraform runs a shared native provider process serves before it is replaced.").Default("100").Int()
+		pluginCacheDir         = app.Flag("terraform-plugin-cache-dir", "Directory of preloaded Terraform provider plugins, used as TF_PLUGIN_CACHE_DIR.").Default("").Envar("TERRAFORM_PLUGIN_CACHE_DIR").String()
+		workspaceDir           = app.Flag("terraform-workspace-dir", "Directory the Terraform workspace of each resource is created in. Defaults to the temporary directory.").Default("").Envar("TERRAFORM_WORKSPACE_DIR").String()
+		shutdownTimeout        = app.Flag("graceful-shutdown-timeout", "How long to wait for reconciles and Terraform operations in progress when the provider stops, before interrupting them.").Default("2m").Envar("GRACEFUL_SHUTDOWN_TIMEOUT").Duration()
+		metricsInterval        = app.Flag("metrics-interval", "How often the metrics of each ProviderConfig, such as key expiries and device counts, are refreshed.").Default("5m").Duration()
+		orphanScanInterval     = app.Flag("orphan-scan-interval", "How often the tailnet of each ProviderConfig is scanned for auth keys, OAuth clients and webhooks the provider created but did not record. Disabled if 0.").Default("1h").Envar("ORPHAN_SCAN_INTERVAL").Duration()
+		orphanRevokeAfter      = app.Flag("orphan-revoke-after", "Age at which leaked auth keys and OAuth clients are revoked, such as 24h. Leaks are only reported if 0. Webhooks are never revoked.").Default("0").Envar("ORPHAN_REVOKE_AFTER").Duration()
+
+		start       = app.Command("start", "Start the Tailscale provider controllers.").Default()
+		selfTest    = app.Command("self-test", "Check that the include list, the controllers and the API types of the provider agree, and exit non-zero if they do not.")
+		essStub     = app.Command("ess-plugin-stub", "Serve an in-memory External Secret Store plugin, for tests.").Hidden()
+		essStubAddr = essStub.Flag("address", "Address to serve the plugin on.").Default(":4040").String()
+		acl         = registerACLCommands(app)
 	)
 
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	cmd := kingpin.MustParse(app.Parse(os.Args[1:]))
+	if acl.run(cmd, os.Stdout) {
+		return
+	}
+	if cmd == selfTest.FullCommand() {
+		report := runSelfTest()
+		kingpin.FatalIfError(report.Write(os.Stdout), "Cannot write self-test report")
+		if !report.OK() {
+			os.Exit(1)
+		}
+		return
+	}
+	if cmd == essStub.FullCommand() {
+		lis, err := net.Listen("tcp", *essStubAddr)
+		kingpin.FatalIfError(err, "Cannot listen on %s", *essStubAddr)
+		kingpin.FatalIfError(stub.New().Serve(lis), "Cannot serve External Secret Store plugin stub")
+		return
+	}
+	if cmd != start.FullCommand() {
+		kingpin.Fatalf("unknown command %q", cmd)
+	}
 
-	zl := zap.New(zap.UseDevMode(*debug))
+	zl, err := logs.New(*logFormat, *logLevel, *debug)
+	kingpin.FatalIfError(err, "Cannot create logger")
 	log := logging.NewLogrLogger(zl.WithName("provider-tailscale"))
-	if *debug {
-		ctrl.SetLogger(zl)
-	}
+	ctrl.SetLogger(zl)
 
 	pollJitter := time.Duration(float64(*pollInterval) * 0.05)
 	log.Debug("Starting", "sync-interval", syncInterval.String(),
 		"poll-interval", pollInterval.String(), "poll-jitter", pollJitter, "max-reconcile-rate", *maxReconcileRate)
 
+	tf := clients.TerraformRuntime{
+		Version:            *terraformVersion,
+		ProviderSource:     *providerSource,
+		ProviderVersion:    *providerVersion,
+		NativeProviderPath: *nativeProviderPath,
+		PluginCacheDir:     *pluginCacheDir,
+		WorkspaceDir:       *workspaceDir,
+	}
+	kingpin.FatalIfError(tf.Validate(), "Invalid Terraform runtime configuration")
+	kingpin.FatalIfError(tf.Configure(), "Cannot configure the Terraform runtime")
+	if tf.ProviderVersion != clients.TerraformProviderVersion {
+		log.Info("Terraform provider version differs from the one the resources were generated from", "version", tf.ProviderVersion, "generated-from", clients.TerraformProviderVersion)
+	}
+
 	cfg, err := ctrl.GetConfig()
 	kingpin.FatalIfError(err, "Cannot get API server rest config")
 
-	// Create scheme and register provider APIs
-	scheme := runtime.NewScheme()
-	kingpin.FatalIfError(apis.AddToScheme(scheme), "Cannot add provider APIs to scheme")
-	kingpin.FatalIfError(corev1.AddToScheme(scheme), "Cannot add Kubernetes core API types to scheme")
+	scheme := newScheme()
 
 	// Setup controller manager
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
@@ -68,49 +140,54 @@ func main() {
 		Cache: cache.Options{
 			SyncPeriod: syncInterval,
 		},
+		// ConfigMaps are only read as ACL policy sources, which are few, so
+		// they are read from the API server instead of caching them all
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.ConfigMap{}}},
+		},
 		WebhookServer: webhook.NewServer(webhook.Options{
-			CertDir: "/webhook/certs",
+			CertDir: webhookCertDir,
 		}),
+		HealthProbeBindAddress:  *healthProbeAddr,
+		PprofBindAddress:        *pprofAddr,
+		GracefulShutdownTimeout: ptr.To(shutdown.ManagerTimeout(*shutdownTimeout)),
 	})
 	kingpin.FatalIfError(err, "Cannot create controller manager")
 
-	// Initialize provider configuration
+	kingpin.FatalIfError(mgr.AddHealthzCheck("ping", healthz.Ping), "Cannot add liveness check")
+	kingpin.FatalIfError(mgr.AddReadyzCheck("cache-sync", health.CacheSynced(mgr.GetCache())), "Cannot add cache readiness check")
+	kingpin.FatalIfError(mgr.AddReadyzCheck("webhook-certs", health.WebhookCerts(webhookCertDir)), "Cannot add webhook certificate readiness check")
+	kingpin.FatalIfError(mgr.AddReadyzCheck("terraform", health.Terraform("terraform", os.TempDir())), "Cannot add Terraform readiness check")
+
+	// Initialize provider configuration, failing early if it does not match
+	// the controllers and API types
+	kingpin.FatalIfError(runSelfTest().Err(), "Provider self-test failed")
 	providerConfig := config.GetProvider()
-	if providerConfig == nil {
-		kingpin.Fatalf("config.GetProvider() returned nil")
-	}
-	if providerConfig.Resources == nil {
-		kingpin.Fatalf("providerConfig.Resources is nil")
-	}
-	if _, ok := providerConfig.Resources["tailscale_acl"]; !ok {
-		kingpin.Fatalf("tailscale_acl not found in Resources map, available: %v", func() []string {
-			keys := make([]string, 0, len(providerConfig.Resources))
-			for k := range providerConfig.Resources {
-				keys = append(keys, k)
-			}
-			return keys
-		}())
+	if len(*asyncKinds) > 0 {
+		kingpin.FatalIfError(config.SetAsyncKinds(providerConfig, *asyncKinds), "Cannot set async kinds")
 	}
-	log.Info("Provider initialized successfully", "resources", len(providerConfig.Resources))
+	log.Info("Provider initialized successfully", "resources", len(providerConfig.Resources), "async-kinds", config.AsyncKinds(providerConfig))
 	
-	setupFn := clients.TerraformSetupBuilder(
-		"1.5.5",
-		clients.TerraformProviderSource,
-		clients.TerraformProviderVersion,
-	)
+	// Reconciles and Terraform operations in progress are drained when the
+	// provider stops, see package shutdown
+	drainer := shutdown.New(*shutdownTimeout, os.TempDir(), event.NewAPIRecorder(mgr.GetEventRecorderFor("shutdown.tailscale.upbound.io")), log)
+	setupFn := drainer.InstrumentSetup(metrics.InstrumentSetup(tf.SetupFn(log, *pluginProcessTTL)))
+
+	// Reconcile lines carry the fields of the resource they are about
+	reconcileLog := logs.NewCorrelator(mgr.GetClient(), scheme).Logger(log)
 
 	// Setup controller options
 	o := tjcontroller.Options{
 		Options: xpcontroller.Options{
-			Logger:                  log,
+			Logger:                  reconcileLog,
 			MaxConcurrentReconciles: *maxReconcileRate,
 			PollInterval:            *pollInterval,
-			GlobalRateLimiter:       ratelimiter.NewGlobal(*maxReconcileRate),
+			GlobalRateLimiter:       drainer.RateLimiter(ratelimiter.NewGlobal(*maxReconcileRate)),
 			Features:                &feature.Flags{},
 		},
 		Provider:       providerConfig,
 		SetupFn:        setupFn,
-		WorkspaceStore: terraform.NewWorkspaceStore(log),
+		WorkspaceStore: terraform.NewWorkspaceStore(reconcileLog),
 		PollJitter:     pollJitter,
 	}
 
@@ -119,8 +196,52 @@ func main() {
 		log.Info("Beta feature enabled", "flag", features.EnableBetaManagementPolicies)
 	}
 
+	// Connection details are written through the client of the manager the
+	// controllers are set up with, see package secretstore.
+	setupMgr := mgr
+	if *enableExternalSecretStores {
+		o.Features.Enable(features.EnableAlphaExternalSecretStores)
+		log.Info("Alpha feature enabled", "flag", features.EnableAlphaExternalSecretStores)
+
+		var essTLS *tls.Config
+		if *essTLSCertDir != "" {
+			essTLS, err = secretstore.TLSConfig(*essTLSCertDir)
+			kingpin.FatalIfError(err, "Cannot load External Secret Store TLS certificates")
+		}
+		setupMgr = secretstore.NewManager(mgr, essTLS, log)
+	}
+
 	// Setup all controllers (including ProviderConfig via generated zz_setup.go)
-	kingpin.FatalIfError(controller.Setup(mgr, o), "Cannot setup controllers")
+	kingpin.FatalIfError(controller.Setup(drainer.Manager(setupMgr), o), "Cannot setup controllers")
+
+	if *webhookReceiverAddr != "" {
+		rec := event.NewAPIRecorder(mgr.GetEventRecorderFor("webhook-receiver.tailscale.upbound.io"))
+		// The signing secrets may be in an external secret store.
+		kingpin.FatalIfError(mgr.Add(receiver.New(*webhookReceiverAddr, setupMgr.GetClient(), rec, log)), "Cannot add webhook receiver")
+	}
+
+	kingpin.FatalIfError(mgr.Add(metrics.NewCollector(mgr.GetClient(), log, *metricsInterval)), "Cannot add metrics collector")
+
+	if *orphanScanInterval > 0 {
+		rec := event.NewAPIRecorder(mgr.GetEventRecorderFor("orphan-scanner.tailscale.upbound.io"))
+		kingpin.FatalIfError(mgr.Add(orphan.NewScanner(mgr.GetClient(), rec, log, *orphanScanInterval, *orphanRevokeAfter)), "Cannot add orphan scanner")
+	}
 
 	kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "Cannot start controller manager")
 }
+
+// newScheme returns a scheme with the provider and Kubernetes core APIs.
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	kingpin.FatalIfError(apis.AddToScheme(scheme), "Cannot add provider APIs to scheme")
+	kingpin.FatalIfError(corev1.AddToScheme(scheme), "Cannot add Kubernetes core API types to scheme")
+	return scheme
+}
+
+// runSelfTest checks the provider configuration against the controllers
+// and API types, see package selftest.
+func runSelfTest() *selftest.Report {
+	report, err := selftest.Run(config.GetProvider(), config.IncludeList, newScheme(), controller.Setup)
+	kingpin.FatalIfError(err, "Cannot run self-test")
+	return report
+}
@@ -3,6 +3,11 @@ package acl
 
 import (
 	"github.com/crossplane/upjet/v2/pkg/config"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/millstonehq/provider-upjet-tailscale/config/common"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/acl/lock"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/acl/source"
 )
 
 // adder is a narrow interface to allow testing without a real Provider.
@@ -27,7 +32,137 @@ func configureWithAdder(a adder) {
 		// Kind will be ACL
 		r.Kind = "ACL"
 
-		// ACL resource supports HuJSON format
-		r.UseAsync = false
+		// Applying a large policy can take a while, so it does not hold a
+		// reconcile worker unless --async-kinds says otherwise.
+		r.UseAsync = true
+
+		// The policy may come from a ConfigMap instead of being inlined, so
+		// it is only required at runtime, by the initializer.
+		r.InitializerFns = append(r.InitializerFns, source.NewInitializer)
+		if r.TerraformResource != nil {
+			if s, ok := r.TerraformResource.Schema["acl"]; ok {
+				s.Required = false
+				s.Optional = true
+			}
+		}
+
+		// aclFrom and the source the policy was copied from exist only in
+		// Kubernetes, for the initializer.
+		common.AddSpecField(r, "acl_from", aclFromSchema())
+		common.AddStatusField(r, "acl_source", aclSourceSchema())
+
+		// Optionally locks the console ACL editor while the ACL exists.
+		r.InitializerFns = append(r.InitializerFns, lock.NewInitializer)
+		common.AddSpecField(r, "lock_console", &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: "Lock the ACL editor of the Tailscale admin console while this ACL exists.",
+		})
+		common.AddSpecField(r, "external_link", &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Description: "URL the locked console editor links to, typically the repository holding the policy. " +
+				"Requires lockConsole.\n" +
+				`+kubebuilder:validation:XValidation:rule="isURL(self) && url(self).getScheme() == 'https'",message="must be an https URL"`,
+		})
+		common.AddRule(r, "!has(self.externalLink) || (has(self.lockConsole) && self.lockConsole)",
+			"externalLink requires lockConsole to be true")
+		common.AddStatusField(r, "console_lock", consoleLockSchema())
 	})
 }
+
+// configMapKeyRefSchema is a reference to a key of a ConfigMap.
+func configMapKeyRefSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "The ConfigMap key holding the policy.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"namespace": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "Namespace of the ConfigMap.",
+				},
+				"name": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "Name of the ConfigMap.",
+				},
+				"key": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "Key of the policy within the ConfigMap. Defaults to " + source.DefaultKey + ".",
+				},
+			},
+		},
+	}
+}
+
+// aclFromSchema is the source of the policy, which is copied into acl
+// whenever it changes. There is no Secret source: the policy applied is
+// reported in status.atProvider.acl, so it is never confidential.
+func aclFromSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Description: "Take the policy from a ConfigMap instead of setting acl inline. " +
+			"The policy is copied into acl whenever the ConfigMap changes.\n" +
+			`+kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef)",message="configMapKeyRef must be set"`,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"config_map_key_ref": configMapKeyRefSchema(),
+			},
+		},
+	}
+}
+
+// aclSourceSchema is the source object the policy was last copied from.
+func aclSourceSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		MaxItems:    1,
+		Description: "The object of aclFrom the policy was last copied from.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"resource_version": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "resourceVersion of the ConfigMap.",
+				},
+				"sha256": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Hex encoded SHA-256 of the policy.",
+				},
+			},
+		},
+	}
+}
+
+// consoleLockSchema is the console lock held by the ACL.
+func consoleLockSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		MaxItems:    1,
+		Description: "The console lock held by this ACL.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"locked": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Whether this ACL locked the ACL editor of the admin console.",
+				},
+				"external_link": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "URL the locked console editor links to.",
+				},
+			},
+		},
+	}
+}
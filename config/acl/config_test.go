@@ -38,7 +38,10 @@ func TestConfigureRegistersConfigurator(t *testing.T) {
 	if r.Kind != "ACL" {
 		t.Errorf("Kind = %q, want %q", r.Kind, "ACL")
 	}
-	if r.UseAsync {
-		t.Error("UseAsync = true, want false")
+	if !r.UseAsync {
+		t.Error("UseAsync = false, want true")
+	}
+	if len(r.InitializerFns) != 2 {
+		t.Errorf("len(InitializerFns) = %d, want 2 (policy source and console lock initializers)", len(r.InitializerFns))
 	}
 }
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tjconfig "github.com/crossplane/upjet/v2/pkg/config"
+)
+
+// AsyncKindsNone disables async operations for every kind.
+const AsyncKindsNone = "none"
+
+// groupKind returns the kind of the resource and its API group, such as
+// ACL.acl.tailscale.upbound.io.
+func groupKind(pc *tjconfig.Provider, r *tjconfig.Resource) string {
+	return r.Kind + "." + r.ShortGroup + "." + pc.RootGroup
+}
+
+// AsyncKinds returns the sorted kinds whose Terraform applies and destroys
+// run asynchronously, without holding a reconcile worker.
+func AsyncKinds(pc *tjconfig.Provider) []string {
+	var kinds []string
+	for _, r := range pc.Resources {
+		if r.UseAsync {
+			kinds = append(kinds, groupKind(pc, r))
+		}
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// SetAsyncKinds makes the supplied kinds, such as
+// ACL.acl.tailscale.upbound.io, run asynchronously and every other kind
+// synchronously, overriding the defaults of the resource configurations.
+// Kinds may also be separated by commas, and are matched case-insensitively.
+// AsyncKindsNone makes every kind synchronous.
+//
+// The generator enables async operations for every resource, so that the
+// controllers are always generated with the callbacks they need.
+func SetAsyncKinds(pc *tjconfig.Provider, kinds []string) error {
+	known := map[string]*tjconfig.Resource{}
+	for _, r := range pc.Resources {
+		known[strings.ToLower(groupKind(pc, r))] = r
+	}
+	want := map[*tjconfig.Resource]bool{}
+	var unknown []string
+	for _, k := range strings.Split(strings.Join(kinds, ","), ",") {
+		k = strings.TrimSpace(k)
+		if k == "" || k == AsyncKindsNone {
+			continue
+		}
+		r, ok := known[strings.ToLower(k)]
+		if !ok {
+			unknown = append(unknown, k)
+			continue
+		}
+		want[r] = true
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown kinds %s, use the form Kind.group such as ACL.acl.tailscale.upbound.io", strings.Join(unknown, ", "))
+	}
+	for _, r := range pc.Resources {
+		r.UseAsync = want[r]
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSetAsyncKinds(t *testing.T) {
+	type want struct {
+		kinds []string
+		err   string
+	}
+	cases := map[string]struct {
+		reason string
+		kinds  []string
+		want   want
+	}{
+		"Defaults": {
+			reason: "Without an override the configured kinds should be async",
+			want: want{kinds: []string{
+				"ACL.acl.tailscale.upbound.io",
+				"Configuration.logstream.tailscale.upbound.io",
+			}},
+		},
+		"Override": {
+			reason: "The supplied kinds should replace the defaults, in any case and comma separated",
+			kinds:  []string{"key.tailnetkey.tailscale.upbound.io,Tags.device.tailscale.upbound.io", " ACL.acl.tailscale.upbound.io"},
+			want: want{kinds: []string{
+				"ACL.acl.tailscale.upbound.io",
+				"Key.tailnetkey.tailscale.upbound.io",
+				"Tags.device.tailscale.upbound.io",
+			}},
+		},
+		"None": {
+			reason: "none should make every kind synchronous",
+			kinds:  []string{AsyncKindsNone},
+		},
+		"Unknown": {
+			reason: "Unknown kinds should be rejected and leave the defaults",
+			kinds:  []string{"ACL", "Key.tailnetkey.tailscale.upbound.io"},
+			want: want{
+				kinds: []string{
+					"ACL.acl.tailscale.upbound.io",
+					"Configuration.logstream.tailscale.upbound.io",
+				},
+				err: "unknown kinds ACL, use the form Kind.group such as ACL.acl.tailscale.upbound.io",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			pc := GetProvider()
+			got := want{}
+			if tc.kinds != nil {
+				if err := SetAsyncKinds(pc, tc.kinds); err != nil {
+					got.err = err.Error()
+				}
+			}
+			got.kinds = AsyncKinds(pc)
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nSetAsyncKinds(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/crossplane/upjet/v2/pkg/config"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// kubernetesOnly is the upjet marker that keeps a field out of the Terraform
+// configuration.
+const kubernetesOnly = "+upjet:crd:field:TFTag=-"
+
+// AddSpecField adds a field that exists only in Kubernetes to
+// spec.forProvider, such as a reference to a ConfigMap a controller reads
+// the Terraform arguments from. It is never sent to Terraform. Single
+// element blocks become objects rather than lists. It is a no-op when the
+// resource has no schema, as in unit tests.
+func AddSpecField(r *config.Resource, attr string, s *schema.Schema) {
+	addKubernetesOnly(r, attr, s)
+}
+
+// AddStatusField adds a field that exists only in Kubernetes to
+// status.atProvider, for controllers to report what they did. The schema
+// must be computed. It is never read from or written to the Terraform
+// state. It is a no-op when the resource has no schema, as in unit tests.
+func AddStatusField(r *config.Resource, attr string, s *schema.Schema) {
+	if addKubernetesOnly(r, attr, s) {
+		r.SchemaElementOptions.SetAddToObservation(attr)
+	}
+}
+
+func addKubernetesOnly(r *config.Resource, attr string, s *schema.Schema) bool {
+	if r.TerraformResource == nil {
+		return false
+	}
+	s.Description = strings.TrimRight(s.Description, "\n") + "\n" + kubernetesOnly
+	r.TerraformResource.Schema[attr] = s
+	embedBlocks(r, attr, s)
+	return true
+}
+
+// embedBlocks generates the single element blocks of s as objects.
+func embedBlocks(r *config.Resource, path string, s *schema.Schema) {
+	el, ok := s.Elem.(*schema.Resource)
+	if !ok || s.MaxItems != 1 {
+		return
+	}
+	r.SchemaElementOptions.SetEmbeddedObject(path)
+	for n, c := range el.Schema {
+		embedBlocks(r, path+"."+n, c)
+	}
+}
+
+// KubernetesOnly returns the top level attributes of the resource added by
+// AddSpecField and AddStatusField.
+func KubernetesOnly(r *config.Resource) (spec, status []string) {
+	if r.TerraformResource == nil {
+		return nil, nil
+	}
+	for n, s := range r.TerraformResource.Schema {
+		if !strings.Contains(s.Description, kubernetesOnly) {
+			continue
+		}
+		if s.Computed && !s.Optional {
+			status = append(status, n)
+			continue
+		}
+		spec = append(spec, n)
+	}
+	slices.Sort(spec)
+	slices.Sort(status)
+	return spec, status
+}
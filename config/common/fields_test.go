@@ -0,0 +1,58 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crossplane/upjet/v2/pkg/config"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestKubernetesOnlyFields(t *testing.T) {
+	r := &config.Resource{
+		TerraformResource: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"acl": {Type: schema.TypeString, Optional: true},
+			},
+		},
+		SchemaElementOptions: config.SchemaElementOptions{},
+	}
+	AddSpecField(r, "acl_from", &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+			"config_map_key_ref": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem:     &schema.Resource{Schema: map[string]*schema.Schema{"name": {Type: schema.TypeString, Required: true}}},
+			},
+		}},
+	})
+	AddStatusField(r, "acl_source", &schema.Schema{Type: schema.TypeString, Computed: true})
+
+	spec, status := KubernetesOnly(r)
+	if diff := cmp.Diff([]string{"acl_from"}, spec); diff != "" {
+		t.Errorf("KubernetesOnly(...): -want spec, +got spec:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"acl_source"}, status); diff != "" {
+		t.Errorf("KubernetesOnly(...): -want status, +got status:\n%s", diff)
+	}
+	if !strings.HasSuffix(r.TerraformResource.Schema["acl_from"].Description, "+upjet:crd:field:TFTag=-") {
+		t.Errorf("AddSpecField(...): description %q lacks the TFTag marker", r.TerraformResource.Schema["acl_from"].Description)
+	}
+	for _, el := range []string{"acl_from", "acl_from.config_map_key_ref"} {
+		if !r.SchemaElementOptions.EmbeddedObject(el) {
+			t.Errorf("AddSpecField(...): %s is not an embedded object", el)
+		}
+	}
+	if !r.SchemaElementOptions.AddToObservation("acl_source") {
+		t.Error("AddStatusField(...): acl_source is not added to the observation")
+	}
+
+	// Resources without a schema, as in the configurator tests, are ignored.
+	AddSpecField(&config.Resource{}, "acl_from", &schema.Schema{Type: schema.TypeString, Optional: true})
+	AddStatusField(&config.Resource{}, "acl_source", &schema.Schema{Type: schema.TypeString, Computed: true})
+}
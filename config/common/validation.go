@@ -0,0 +1,57 @@
+// Package common contains configuration helpers shared by resource groups.
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane/upjet/v2/pkg/config"
+)
+
+// AddValidation appends kubebuilder validation markers, such as
+// "+kubebuilder:validation:Minimum=1", to the generated field for the
+// supplied Terraform attribute. Markers are carried in the attribute's
+// description, which upjet copies into the field's comment; the generator
+// removes them from the observation again. It is a no-op when the resource
+// has no schema, as in unit tests.
+func AddValidation(r *config.Resource, attr string, markers ...string) {
+	if r.TerraformResource == nil {
+		return
+	}
+	s, ok := r.TerraformResource.Schema[attr]
+	if !ok {
+		return
+	}
+	s.Description = strings.TrimRight(s.Description, "\n") + "\n" + strings.Join(markers, "\n")
+}
+
+// ruleMarker prefixes the rules AddRule records.
+const ruleMarker = "+kubebuilder:validation:XValidation:"
+
+// AddRule adds a CEL rule spanning several fields to spec.forProvider of the
+// generated kind; self is spec.forProvider. Such rules cannot be carried in
+// the description of a single attribute, so they are recorded in the
+// description of the resource, which upjet does not use, and added to the
+// parameters type by the generator. It is a no-op when the resource has no
+// schema, as in unit tests.
+func AddRule(r *config.Resource, rule, message string) {
+	if r.TerraformResource == nil {
+		return
+	}
+	r.TerraformResource.Description = strings.TrimRight(r.TerraformResource.Description, "\n") +
+		"\n" + fmt.Sprintf("%srule=%q,message=%q", ruleMarker, rule, message)
+}
+
+// Rules returns the markers of the rules added by AddRule.
+func Rules(r *config.Resource) []string {
+	if r.TerraformResource == nil {
+		return nil
+	}
+	var rules []string
+	for _, l := range strings.Split(r.TerraformResource.Description, "\n") {
+		if strings.HasPrefix(l, ruleMarker) {
+			rules = append(rules, l)
+		}
+	}
+	return rules
+}
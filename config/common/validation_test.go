@@ -0,0 +1,42 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/crossplane/upjet/v2/pkg/config"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAddValidation(t *testing.T) {
+	r := &config.Resource{
+		TerraformResource: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"days": {Type: schema.TypeInt, Description: "The number of days\n"},
+			},
+		},
+	}
+	AddValidation(r, "days", "+kubebuilder:validation:Minimum=1", "+kubebuilder:validation:Maximum=180")
+	AddValidation(r, "missing", "+kubebuilder:validation:Minimum=1")
+
+	want := "The number of days\n+kubebuilder:validation:Minimum=1\n+kubebuilder:validation:Maximum=180"
+	if got := r.TerraformResource.Schema["days"].Description; got != want {
+		t.Errorf("Description = %q, want %q", got, want)
+	}
+
+	// Resources without a schema, as in the configurator tests, are ignored.
+	AddValidation(&config.Resource{}, "days", "+kubebuilder:validation:Minimum=1")
+}
+
+func TestAddRule(t *testing.T) {
+	r := &config.Resource{TerraformResource: &schema.Resource{Description: "Tailnet settings\n"}}
+	AddRule(r, "!has(self.link) || self.on", "link requires on")
+
+	want := []string{`+kubebuilder:validation:XValidation:rule="!has(self.link) || self.on",message="link requires on"`}
+	if diff := cmp.Diff(want, Rules(r)); diff != "" {
+		t.Errorf("Rules(...): -want, +got:\n%s", diff)
+	}
+
+	// Resources without a schema, as in the configurator tests, are ignored.
+	AddRule(&config.Resource{}, "true", "never")
+}
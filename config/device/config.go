@@ -3,6 +3,10 @@ package device
 
 import (
 	"github.com/crossplane/upjet/v2/pkg/config"
+
+	"github.com/millstonehq/provider-upjet-tailscale/config/common"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/device/routes"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/tagowner"
 )
 
 // adder is a narrow interface to allow testing without a real Provider.
@@ -29,6 +33,10 @@ func configureWithAdder(a adder) {
 		r.Kind = "Tags"
 
 		r.UseAsync = false
+
+		// Rejects tags the provider's credentials may not apply, before
+		// the API does.
+		r.InitializerFns = append(r.InitializerFns, tagowner.NewInitializer)
 	})
 
 	// Device authorization resource
@@ -71,5 +79,15 @@ func configureWithAdder(a adder) {
 		r.Kind = "SubnetRoutes"
 
 		r.UseAsync = false
+
+		// Reject anything that is not a CIDR prefix without host bits at
+		// admission. The bounds keep the CEL cost estimate within limits.
+		common.AddValidation(r, "routes",
+			"+kubebuilder:validation:MaxItems=1024",
+			"+kubebuilder:validation:items:MaxLength=43",
+			`+kubebuilder:validation:items:XValidation:rule="isCIDR(self) && cidr(self) == cidr(self).masked()",message="must be a CIDR prefix without host bits, such as 10.0.0.0/24"`)
+
+		// Normalises the routes and reports overlaps with other routers.
+		r.InitializerFns = append(r.InitializerFns, routes.NewInitializer)
 	})
 }
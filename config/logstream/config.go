@@ -27,6 +27,8 @@ func configureWithAdder(a adder) {
 		// Kind will be Configuration
 		r.Kind = "Configuration"
 
-		r.UseAsync = false
+		// Tailscale validates the destination before saving the
+		// configuration, so applies are run asynchronously.
+		r.UseAsync = true
 	})
 }
@@ -3,6 +3,10 @@ package oauth
 
 import (
 	"github.com/crossplane/upjet/v2/pkg/config"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/marker"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/oauth/scopes"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/tagowner"
 )
 
 // adder is a narrow interface to allow testing without a real Provider.
@@ -29,6 +33,18 @@ func configureWithAdder(a adder) {
 
 		r.UseAsync = false
 
+		// Devices authenticated by the OAuth client carry its tags, so it
+		// may only be given tags the provider's credentials own.
+		r.InitializerFns = append(r.InitializerFns, tagowner.NewInitializer)
+
+		// Rejects unknown scopes and flags over-broad ones.
+		r.InitializerFns = append(r.InitializerFns, scopes.NewInitializer)
+
+		// Stamps the description with the UID of the resource, so that an
+		// OAuth client created by a Create whose outcome was lost can be
+		// told apart from ones made by hand.
+		r.InitializerFns = append(r.InitializerFns, marker.NewInitializer)
+
 		// Configure connection details to match Tailscale operator expectations
 		// Operator expects: client_id and client_secret (as files in mounted volume)
 		r.Sensitive.AdditionalConnectionDetailsFn = func(attr map[string]any) (map[string][]byte, error) {
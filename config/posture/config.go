@@ -3,6 +3,10 @@ package posture
 
 import (
 	"github.com/crossplane/upjet/v2/pkg/config"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/millstonehq/provider-upjet-tailscale/config/common"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/posture/credential"
 )
 
 // adder is a narrow interface to allow testing without a real Provider.
@@ -28,5 +32,44 @@ func configureWithAdder(a adder) {
 		r.Kind = "Integration"
 
 		r.UseAsync = false
+
+		// The Terraform schema does not mark the secret sensitive, so it
+		// would be a plain string in the spec. Take it from a Secret
+		// instead, which credential watches for rotations.
+		if r.TerraformResource != nil {
+			if s, ok := r.TerraformResource.Schema["client_secret"]; ok {
+				s.Sensitive = true
+			}
+		}
+
+		// Validates the provider specific arguments and records client
+		// secret rotations in status.atProvider.clientSecretSource.
+		common.AddStatusField(r, "client_secret_source", clientSecretSourceSchema())
+		r.InitializerFns = append(r.InitializerFns, credential.NewInitializer)
 	})
 }
+
+// clientSecretSourceSchema is the client secret last read from
+// clientSecretSecretRef.
+func clientSecretSourceSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		MaxItems:    1,
+		Description: "The client secret last read from clientSecretSecretRef.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"sha256": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Hex encoded SHA-256 of the client secret.",
+				},
+				"changed_at": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "When the client secret last changed, in RFC 3339 format.",
+				},
+			},
+		},
+	}
+}
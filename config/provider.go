@@ -26,6 +26,70 @@ const (
 //go:embed schema.json
 var providerSchema []byte
 
+// IncludeList are the regular expressions of the Terraform resources
+// generated and reconciled through Terraform. Each must match a resource of
+// the provider schema.
+var IncludeList = []string{
+	// ACL resources
+	"tailscale_acl$",
+	// AWS resources
+	"tailscale_aws_external_id$",
+	// Device resources
+	"tailscale_device_authorization$",
+	"tailscale_device_key$",
+	"tailscale_device_subnet_routes$",
+	"tailscale_device_tags$",
+	// DNS resources
+	"tailscale_dns_nameservers$",
+	"tailscale_dns_preferences$",
+	"tailscale_dns_search_paths$",
+	"tailscale_dns_split_nameservers$",
+	// Logstream resources
+	"tailscale_logstream_configuration$",
+	// OAuth resources
+	"tailscale_oauth_client$",
+	// Posture resources
+	"tailscale_posture_integration$",
+	// Tailnet resources (tailscale_contacts is reconciled by the
+	// hand-written tailnet/contacts controller)
+	"tailscale_tailnet_key$",
+	"tailscale_tailnet_settings$",
+	// Webhook resources
+	"tailscale_webhook$",
+}
+
+// ExcludeList are the regular expressions of the Terraform resources that
+// are deliberately not generated. Every resource of the provider schema must
+// match the include list or the exclude list, which the generator checks in
+// strict mode.
+var ExcludeList = []string{
+	// Reconciled by the hand-written tailnet/contacts controller
+	"tailscale_contacts$",
+	// Manages the nameservers, preferences, search paths and split
+	// nameservers of the tailnet at once, which the DNS resources above
+	// manage one by one; both would overwrite each other
+	"tailscale_dns_configuration$",
+}
+
+// DataSources are the Terraform data sources of the provider schema when
+// the include and exclude lists were last reviewed. upjet generates no data
+// sources, so they are only listed to report the ones that appear in newer
+// provider versions.
+var DataSources = []string{
+	"tailscale_4via6",
+	"tailscale_acl",
+	"tailscale_device",
+	"tailscale_devices",
+	"tailscale_user",
+	"tailscale_users",
+}
+
+// ProviderSchema returns the Terraform provider schema the resources are
+// generated from.
+func ProviderSchema() []byte {
+	return providerSchema
+}
+
 // GetProvider returns provider configuration
 func GetProvider() *tjconfig.Provider {
 	pc := tjconfig.NewProvider(
@@ -35,34 +99,26 @@ func GetProvider() *tjconfig.Provider {
 		[]byte{},        // Empty metadata
 		tjconfig.WithRootGroup("tailscale.upbound.io"),
 		tjconfig.WithFeaturesPackage("internal/features"),
-		tjconfig.WithIncludeList([]string{
-			// ACL resources
-			"tailscale_acl$",
-			// AWS resources
-			"tailscale_aws_external_id$",
-			// Device resources
-			"tailscale_device_authorization$",
-			"tailscale_device_key$",
-			"tailscale_device_subnet_routes$",
-			"tailscale_device_tags$",
-			// DNS resources
-			"tailscale_dns_nameservers$",
-			"tailscale_dns_preferences$",
-			"tailscale_dns_search_paths$",
-			"tailscale_dns_split_nameservers$",
-			// Logstream resources
-			"tailscale_logstream_configuration$",
-			// OAuth resources
-			"tailscale_oauth_client$",
-			// Posture resources
-			"tailscale_posture_integration$",
-			// Tailnet resources
-			"tailscale_contacts$",
-			"tailscale_tailnet_key$",
-			"tailscale_tailnet_settings$",
-			// Webhook resources
-			"tailscale_webhook$",
+		tjconfig.WithBasePackages(tjconfig.BasePackages{
+			APIVersion: []string{
+				"v1alpha1",
+				"v1beta1",
+			},
+			// Hand-written controllers, registered in the generated zz_setup.go
+			ControllerMap: map[string]string{
+				"providerconfig":     tjconfig.PackageNameConfig,
+				"acl/source":         "acl",
+				"acl/lock":           "acl",
+				"fleet":              "device",
+				"approval":           "device",
+				"podauthkey":         "tailnetkey",
+				"posture/credential": "posture",
+				"tailnet/ondelete":   "tailnet",
+				"tailnet/contacts":   "tailnet",
+			},
 		}),
+		tjconfig.WithIncludeList(IncludeList),
+		tjconfig.WithSkipList(ExcludeList),
 		tjconfig.WithDefaultResourceOptions(
 			func(r *tjconfig.Resource) {
 				r.ExternalName = tjconfig.NameAsIdentifier
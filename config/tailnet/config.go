@@ -2,7 +2,13 @@
 package tailnet
 
 import (
+	"strings"
+
 	"github.com/crossplane/upjet/v2/pkg/config"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/millstonehq/provider-upjet-tailscale/config/common"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/tailnet/ondelete"
 )
 
 // adder is a narrow interface to allow testing without a real Provider.
@@ -17,19 +23,6 @@ func Configure(p *config.Provider) {
 
 // configureWithAdder is the testable entrypoint.
 func configureWithAdder(a adder) {
-	a.AddResourceConfigurator("tailscale_contacts", func(r *config.Resource) {
-		// Contacts is a singleton resource - use identifier from provider
-		r.ExternalName = config.IdentifierFromProvider
-
-		// Short group for CRD generation
-		r.ShortGroup = "tailnet"
-
-		// Kind will be Contacts
-		r.Kind = "Contacts"
-
-		r.UseAsync = false
-	})
-
 	a.AddResourceConfigurator("tailscale_tailnet_settings", func(r *config.Resource) {
 		// Tailnet settings is a singleton resource - use identifier from provider
 		r.ExternalName = config.IdentifierFromProvider
@@ -41,5 +34,38 @@ func configureWithAdder(a adder) {
 		r.Kind = "Settings"
 
 		r.UseAsync = false
+
+		// Mirror the limits enforced by the Tailscale API so that invalid
+		// settings are rejected at admission instead of on apply.
+		common.AddValidation(r, "devices_key_duration_days",
+			"+kubebuilder:validation:Minimum=1",
+			"+kubebuilder:validation:Maximum=180")
+		common.AddValidation(r, "users_role_allowed_to_join_external_tailnet",
+			"+kubebuilder:validation:Enum=none;admin;member")
+		common.AddValidation(r, "acls_external_link",
+			`+kubebuilder:validation:XValidation:rule="isURL(self) && url(self).getScheme() == 'https'",message="must be an https URL"`)
+		common.AddRule(r, "!has(self.aclsExternalLink) || (has(self.aclsExternallyManagedOn) && self.aclsExternallyManagedOn)",
+			"aclsExternalLink requires aclsExternallyManagedOn to be true")
+
+		// The console ACL lock may be owned by an acl.ACL instead, so observed
+		// values must not be copied into the spec.
+		r.LateInitializer = config.LateInitializer{
+			IgnoredFields: []string{"acls_externally_managed_on", "acls_external_link"},
+		}
+
+		// What happens to the tailnet settings when the resource is
+		// deleted. The initializer captures the adopted settings and holds
+		// deletion until they are restored accordingly.
+		common.AddSpecField(r, "on_delete", &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Description: "What to do with the tailnet settings when this resource is deleted: " +
+				"Retain leaves them as they are, RestoreDefaults resets them to the values of a new tailnet " +
+				"and RestoreAdopted restores the values they had when this resource was created. Defaults to Retain.\n" +
+				"+kubebuilder:validation:Enum=" + strings.Join([]string{
+				string(ondelete.PolicyRetain), string(ondelete.PolicyRestoreDefaults), string(ondelete.PolicyRestoreAdopted),
+			}, ";"),
+		})
+		r.InitializerFns = append(r.InitializerFns, ondelete.NewInitializer)
 	})
 }
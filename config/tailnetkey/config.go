@@ -3,6 +3,9 @@ package tailnetkey
 
 import (
 	"github.com/crossplane/upjet/v2/pkg/config"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/marker"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/tagowner"
 )
 
 // adder is a narrow interface to allow testing without a real Provider.
@@ -29,11 +32,22 @@ func configureWithAdder(a adder) {
 
 		r.UseAsync = false
 
+		// A key with a tag the provider's credentials do not own would be
+		// refused on creation; the initializer reports it up front.
+		r.InitializerFns = append(r.InitializerFns, tagowner.NewInitializer)
+
+		// Stamps the description with the UID of the resource, so that a
+		// key that was created but not recorded can be found.
+		r.InitializerFns = append(r.InitializerFns, marker.NewInitializer)
+
 		// Sensitive fields that should be marked as secret
 		r.Sensitive.AdditionalConnectionDetailsFn = func(attr map[string]any) (map[string][]byte, error) {
 			conn := map[string][]byte{}
 			if key, ok := attr["key"].(string); ok {
 				conn["key"] = []byte(key)
+				// Where Tailscale's containerboot looks for the key in
+				// its state Secret, see podauthkey.
+				conn["authkey"] = []byte(key)
 			}
 			return conn, nil
 		}
@@ -3,6 +3,8 @@ package webhook
 
 import (
 	"github.com/crossplane/upjet/v2/pkg/config"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/webhook/endpoint"
 )
 
 // adder is a narrow interface to allow testing without a real Provider.
@@ -28,5 +30,19 @@ func configureWithAdder(a adder) {
 		r.Kind = "Webhook"
 
 		r.UseAsync = false
+
+		// Rejects unknown events and endpoints that do not match the
+		// provider type.
+		r.InitializerFns = append(r.InitializerFns, endpoint.NewInitializer)
+
+		// Publish the signing secret under a stable key, for the webhook
+		// receiver and for consumers verifying payloads themselves.
+		r.Sensitive.AdditionalConnectionDetailsFn = func(attr map[string]any) (map[string][]byte, error) {
+			conn := map[string][]byte{}
+			if secret, ok := attr["secret"].(string); ok {
+				conn["secret"] = []byte(secret)
+			}
+			return conn, nil
+		}
 	})
 }
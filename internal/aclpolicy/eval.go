@@ -0,0 +1,199 @@
+package aclpolicy
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultProto = "tcp"
+
+// Decision is the outcome of a network access check.
+type Decision struct {
+	// Allowed is true when at least one rule grants access.
+	Allowed bool
+	// Rule names the first rule that grants access, such as "acls[2]" or
+	// "grants[0]". It is empty when access is denied.
+	Rule string
+}
+
+// Check reports whether src may reach dst over proto. The destination must
+// name a single port, for example "tag:db:5432". An empty proto means TCP.
+// Rules in the acls section are considered before grants.
+func (p *Policy) Check(src, dst, proto string) (Decision, error) {
+	target, port, err := parseQueryDestination(dst)
+	if err != nil {
+		return Decision{}, err
+	}
+	if proto == "" {
+		proto = defaultProto
+	}
+	proto = normalizeProto(proto)
+
+	for i, r := range p.ACLs {
+		if r.Proto != "" && normalizeProto(r.Proto) != proto {
+			continue
+		}
+		if !p.coversAny(r.Src, src) {
+			continue
+		}
+		for _, d := range r.Dst {
+			host, ports, err := parseDestination(d)
+			if err != nil || !p.coversTarget(host, target, src) {
+				continue
+			}
+			for _, pr := range ports {
+				if pr.contains(port) {
+					return Decision{Allowed: true, Rule: fmt.Sprintf("acls[%d]", i)}, nil
+				}
+			}
+		}
+	}
+
+	for i, g := range p.Grants {
+		if !p.coversAny(g.Src, src) || !p.grantTargets(g, target, src) {
+			continue
+		}
+		for _, spec := range g.IP {
+			ip, err := parseGrantIP(spec)
+			if err == nil && ip.matches(proto, port) {
+				return Decision{Allowed: true, Rule: fmt.Sprintf("grants[%d]", i)}, nil
+			}
+		}
+	}
+	return Decision{}, nil
+}
+
+func (p *Policy) grantTargets(g Grant, target, src string) bool {
+	for _, d := range g.Dst {
+		if p.coversTarget(d, target, src) {
+			return true
+		}
+	}
+	return false
+}
+
+// SSHMatch is an SSH rule that applies between a source and a destination.
+type SSHMatch struct {
+	// Rule names the rule, such as "ssh[0]".
+	Rule   string
+	Action string
+	Users  []string
+	// CheckPeriod is only set for rules with the check action.
+	CheckPeriod string
+}
+
+// SSHRules returns the SSH rules whose src covers src and whose dst covers
+// dst, in policy order.
+func (p *Policy) SSHRules(src, dst string) []SSHMatch {
+	var out []SSHMatch
+	for i, r := range p.SSH {
+		if !p.coversAny(r.Src, src) {
+			continue
+		}
+		for _, d := range r.Dst {
+			if p.coversTarget(d, dst, src) {
+				out = append(out, SSHMatch{
+					Rule:        fmt.Sprintf("ssh[%d]", i),
+					Action:      r.Action,
+					Users:       r.Users,
+					CheckPeriod: r.CheckPeriod,
+				})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// CheckSSH returns the first SSH rule that lets src log in to dst as login.
+// The returned match is nil when the connection would be denied.
+func (p *Policy) CheckSSH(src, dst, login string) *SSHMatch {
+	for _, m := range p.SSHRules(src, dst) {
+		if sshUserAllowed(m.Users, src, login) {
+			return &m
+		}
+	}
+	return nil
+}
+
+// TestResult is the outcome of one entry of the tests or sshTests section.
+type TestResult struct {
+	// Name identifies the test, such as "tests[0]" or "sshTests[1]".
+	Name     string
+	Failures []string
+}
+
+// Passed reports whether every assertion of the test held.
+func (r TestResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// RunTests evaluates the policy's tests and sshTests sections.
+func (p *Policy) RunTests() []TestResult {
+	results := make([]TestResult, 0, len(p.Tests)+len(p.SSHTests))
+	for i, t := range p.Tests {
+		results = append(results, p.runACLTest(fmt.Sprintf("tests[%d]", i), t))
+	}
+	for i, t := range p.SSHTests {
+		results = append(results, p.runSSHTest(fmt.Sprintf("sshTests[%d]", i), t))
+	}
+	return results
+}
+
+func (p *Policy) runACLTest(name string, t ACLTest) TestResult {
+	res := TestResult{Name: name}
+	proto := t.Proto
+	if proto == "" {
+		proto = defaultProto
+	}
+	expect := func(dst string, allowed bool) {
+		d, err := p.Check(t.Src, dst, proto)
+		switch {
+		case err != nil:
+			res.Failures = append(res.Failures, err.Error())
+		case allowed && !d.Allowed:
+			res.Failures = append(res.Failures, fmt.Sprintf("%s -> %s (%s): expected accept, got deny", t.Src, dst, proto))
+		case !allowed && d.Allowed:
+			res.Failures = append(res.Failures, fmt.Sprintf("%s -> %s (%s): expected deny, accepted by %s", t.Src, dst, proto, d.Rule))
+		}
+	}
+	for _, dst := range t.Accept {
+		expect(dst, true)
+	}
+	for _, dst := range t.Deny {
+		expect(dst, false)
+	}
+	return res
+}
+
+func (p *Policy) runSSHTest(name string, t SSHTest) TestResult {
+	res := TestResult{Name: name}
+	expect := func(dst, login, action string) {
+		got := ""
+		if m := p.CheckSSH(t.Src, dst, login); m != nil {
+			got = m.Action
+		}
+		if got != action {
+			res.Failures = append(res.Failures, fmt.Sprintf("%s -> %s as %s: expected %s, got %s", t.Src, dst, login, describeSSH(action), describeSSH(got)))
+		}
+	}
+	for _, dst := range t.Dst {
+		for _, login := range t.Accept {
+			expect(dst, login, actionAccept)
+		}
+		for _, login := range t.Check {
+			expect(dst, login, actionCheck)
+		}
+		for _, login := range t.Deny {
+			expect(dst, login, "")
+		}
+	}
+	return res
+}
+
+func describeSSH(action string) string {
+	if action == "" {
+		return "deny"
+	}
+	return strings.ToLower(action)
+}
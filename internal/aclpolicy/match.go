@@ -0,0 +1,247 @@
+package aclpolicy
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	prefixGroup     = "group:"
+	prefixTag       = "tag:"
+	prefixAutogroup = "autogroup:"
+
+	wildcard = "*"
+
+	actionAccept = "accept"
+	actionCheck  = "check"
+
+	autogroupMember  = "autogroup:member"
+	autogroupSelf    = "autogroup:self"
+	autogroupNonRoot = "autogroup:nonroot"
+
+	prefixLocalpart = "localpart:*@"
+)
+
+// protoAliases maps IANA protocol numbers to the names used in policies.
+var protoAliases = map[string]string{
+	"1":   "icmp",
+	"6":   "tcp",
+	"17":  "udp",
+	"58":  "ipv6-icmp",
+	"132": "sctp",
+}
+
+func normalizeProto(proto string) string {
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if alias, ok := protoAliases[proto]; ok {
+		return alias
+	}
+	return proto
+}
+
+// portRange is an inclusive range of ports.
+type portRange struct {
+	first, last uint16
+}
+
+func (r portRange) contains(port uint16) bool {
+	return port >= r.first && port <= r.last
+}
+
+// parsePorts parses a port specification such as "*", "22", "80,443" or
+// "8000-8100".
+func parsePorts(spec string) ([]portRange, error) {
+	if spec == wildcard {
+		return []portRange{{first: 0, last: 65535}}, nil
+	}
+	var ranges []portRange
+	for _, part := range strings.Split(spec, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		lo, err := strconv.ParseUint(first, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", part)
+		}
+		hi := lo
+		if isRange {
+			if hi, err = strconv.ParseUint(last, 10, 16); err != nil || hi < lo {
+				return nil, fmt.Errorf("invalid port range %q", part)
+			}
+		}
+		ranges = append(ranges, portRange{first: uint16(lo), last: uint16(hi)})
+	}
+	return ranges, nil
+}
+
+// splitHostPort splits a destination such as "tag:db:5432", "*:*" or
+// "[fd7a:115c:a1e0::1]:22" into its target and port specification.
+func splitHostPort(dst string) (string, string, error) {
+	if strings.HasPrefix(dst, "[") {
+		host, ports, ok := strings.Cut(dst[1:], "]:")
+		if !ok {
+			return "", "", fmt.Errorf("invalid destination %q: missing port", dst)
+		}
+		return host, ports, nil
+	}
+	i := strings.LastIndex(dst, ":")
+	if i <= 0 || i == len(dst)-1 {
+		return "", "", fmt.Errorf("invalid destination %q: expected target:ports", dst)
+	}
+	return dst[:i], dst[i+1:], nil
+}
+
+// parseDestination parses a rule destination into its target and ports.
+func parseDestination(dst string) (string, []portRange, error) {
+	host, ports, err := splitHostPort(dst)
+	if err != nil {
+		return "", nil, err
+	}
+	ranges, err := parsePorts(ports)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid destination %q: %w", dst, err)
+	}
+	return host, ranges, nil
+}
+
+// parseQueryDestination parses a destination being asked about, which must
+// name exactly one port.
+func parseQueryDestination(dst string) (string, uint16, error) {
+	host, port, err := splitHostPort(dst)
+	if err != nil {
+		return "", 0, err
+	}
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid destination %q: port must be a single number", dst)
+	}
+	return host, uint16(n), nil
+}
+
+// grantIP is a parsed entry of a grant's ip list.
+type grantIP struct {
+	proto string
+	ports []portRange
+}
+
+// parseGrantIP parses a grant ip entry such as "*", "443", "tcp:443",
+// "udp:*" or "icmp".
+func parseGrantIP(spec string) (grantIP, error) {
+	if spec == wildcard {
+		ports, _ := parsePorts(wildcard)
+		return grantIP{ports: ports}, nil
+	}
+	proto, ports, hasProto := strings.Cut(spec, ":")
+	if !hasProto {
+		if r, err := parsePorts(spec); err == nil {
+			return grantIP{ports: r}, nil
+		}
+		all, _ := parsePorts(wildcard)
+		return grantIP{proto: normalizeProto(spec), ports: all}, nil
+	}
+	r, err := parsePorts(ports)
+	if err != nil {
+		return grantIP{}, fmt.Errorf("invalid ip %q: %w", spec, err)
+	}
+	return grantIP{proto: normalizeProto(proto), ports: r}, nil
+}
+
+func (g grantIP) matches(proto string, port uint16) bool {
+	if g.proto != "" && g.proto != proto {
+		return false
+	}
+	for _, r := range g.ports {
+		if r.contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUser reports whether a principal names a user login.
+func isUser(principal string) bool {
+	return strings.Contains(principal, "@") &&
+		!strings.HasPrefix(principal, prefixGroup) &&
+		!strings.HasPrefix(principal, prefixTag) &&
+		!strings.HasPrefix(principal, prefixAutogroup)
+}
+
+// prefix resolves a host alias, IP address or CIDR to a prefix.
+func (p *Policy) prefix(s string) (netip.Prefix, bool) {
+	if alias, ok := p.Hosts[s]; ok {
+		s = alias
+	}
+	if pfx, err := netip.ParsePrefix(s); err == nil {
+		return pfx.Masked(), true
+	}
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), true
+	}
+	return netip.Prefix{}, false
+}
+
+// covers reports whether the selector sel, taken from a rule, covers the
+// principal.
+func (p *Policy) covers(sel, principal string) bool {
+	switch {
+	case sel == wildcard, sel == principal:
+		return true
+	case strings.HasPrefix(sel, prefixGroup):
+		for _, m := range p.Groups[sel] {
+			if m == principal {
+				return true
+			}
+		}
+		return false
+	case sel == autogroupMember:
+		// Members of groups are users, so a group is covered as a whole.
+		return isUser(principal) || strings.HasPrefix(principal, prefixGroup)
+	case strings.HasPrefix(sel, prefixTag), strings.HasPrefix(sel, prefixAutogroup), isUser(sel):
+		return false
+	}
+	sp, ok := p.prefix(sel)
+	if !ok {
+		return false
+	}
+	pp, ok := p.prefix(principal)
+	return ok && sp.Bits() <= pp.Bits() && sp.Contains(pp.Addr())
+}
+
+// coversTarget is covers for a destination, where autogroup:self refers to
+// the devices of the source user.
+func (p *Policy) coversTarget(sel, target, src string) bool {
+	if sel == autogroupSelf {
+		return target == autogroupSelf || (isUser(src) && target == src)
+	}
+	return p.covers(sel, target)
+}
+
+func (p *Policy) coversAny(sels []string, principal string) bool {
+	for _, s := range sels {
+		if p.covers(s, principal) {
+			return true
+		}
+	}
+	return false
+}
+
+// sshUserAllowed reports whether login may be used by src according to the
+// users list of an SSH rule.
+func sshUserAllowed(users []string, src, login string) bool {
+	for _, u := range users {
+		switch {
+		case u == login:
+			return true
+		case u == autogroupNonRoot:
+			if login != "root" {
+				return true
+			}
+		case strings.HasPrefix(u, prefixLocalpart):
+			local, domain, ok := strings.Cut(src, "@")
+			if ok && domain == strings.TrimPrefix(u, prefixLocalpart) && local == login {
+				return true
+			}
+		}
+	}
+	return false
+}
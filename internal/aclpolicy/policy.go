@@ -0,0 +1,130 @@
+// Package aclpolicy evaluates Tailscale policy files locally.
+//
+// It answers the questions an operator usually asks the admin console -
+// "can this source reach that destination, and which rule allows it?" and
+// "which SSH rules apply between these two?" - and runs the policy's own
+// tests and sshTests blocks, all without calling the Tailscale API.
+//
+// Evaluation works on principals rather than on concrete devices: a source
+// is a user, group, tag, host alias or IP address, and a destination is one
+// of those followed by a port. Group membership is expanded from the policy's
+// groups section; tag membership of devices is not known offline, so a tag
+// only matches itself and the "*" wildcard.
+package aclpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tailscale/hujson"
+)
+
+// Policy is the subset of a Tailscale policy file that is needed to evaluate
+// access. Sections the evaluator does not use (nodeAttrs, postures,
+// autoApprovers, ...) are ignored when parsing.
+type Policy struct {
+	Groups    map[string][]string `json:"groups,omitempty"`
+	Hosts     map[string]string   `json:"hosts,omitempty"`
+	TagOwners map[string][]string `json:"tagOwners,omitempty"`
+	ACLs      []ACL               `json:"acls,omitempty"`
+	Grants    []Grant             `json:"grants,omitempty"`
+	SSH       []SSHRule           `json:"ssh,omitempty"`
+	Tests     []ACLTest           `json:"tests,omitempty"`
+	SSHTests  []SSHTest           `json:"sshTests,omitempty"`
+}
+
+// ACL is a legacy network access rule from the acls section.
+type ACL struct {
+	Action string   `json:"action"`
+	Src    []string `json:"src"`
+	Dst    []string `json:"dst"`
+	Proto  string   `json:"proto,omitempty"`
+}
+
+// Grant is a network access rule from the grants section. Only the ip
+// capability is evaluated; application capabilities are ignored.
+type Grant struct {
+	Src []string `json:"src"`
+	Dst []string `json:"dst"`
+	IP  []string `json:"ip,omitempty"`
+}
+
+// SSHRule is a Tailscale SSH rule from the ssh section.
+type SSHRule struct {
+	Action      string   `json:"action"`
+	Src         []string `json:"src"`
+	Dst         []string `json:"dst"`
+	Users       []string `json:"users"`
+	CheckPeriod string   `json:"checkPeriod,omitempty"`
+}
+
+// ACLTest is an entry of the tests section.
+type ACLTest struct {
+	Src    string   `json:"src"`
+	Proto  string   `json:"proto,omitempty"`
+	Accept []string `json:"accept,omitempty"`
+	Deny   []string `json:"deny,omitempty"`
+}
+
+// SSHTest is an entry of the sshTests section.
+type SSHTest struct {
+	Src    string   `json:"src"`
+	Dst    []string `json:"dst"`
+	Accept []string `json:"accept,omitempty"`
+	Check  []string `json:"check,omitempty"`
+	Deny   []string `json:"deny,omitempty"`
+}
+
+// Parse parses a HuJSON policy document (JSON with comments and trailing
+// commas) and checks that its rules are well formed.
+func Parse(data []byte) (*Policy, error) {
+	std, err := hujson.Standardize(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse policy as HuJSON: %w", err)
+	}
+	p := &Policy{}
+	if err := json.Unmarshal(std, p); err != nil {
+		return nil, fmt.Errorf("cannot decode policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Policy) validate() error {
+	for name := range p.Groups {
+		if !strings.HasPrefix(name, prefixGroup) {
+			return fmt.Errorf("groups: %q must start with %q", name, prefixGroup)
+		}
+	}
+	for name := range p.TagOwners {
+		if !strings.HasPrefix(name, prefixTag) {
+			return fmt.Errorf("tagOwners: %q must start with %q", name, prefixTag)
+		}
+	}
+	for i, r := range p.ACLs {
+		if r.Action != actionAccept {
+			return fmt.Errorf("acls[%d]: action must be %q, got %q", i, actionAccept, r.Action)
+		}
+		for _, d := range r.Dst {
+			if _, _, err := parseDestination(d); err != nil {
+				return fmt.Errorf("acls[%d]: %w", i, err)
+			}
+		}
+	}
+	for i, g := range p.Grants {
+		for _, ip := range g.IP {
+			if _, err := parseGrantIP(ip); err != nil {
+				return fmt.Errorf("grants[%d]: %w", i, err)
+			}
+		}
+	}
+	for i, r := range p.SSH {
+		if r.Action != actionAccept && r.Action != actionCheck {
+			return fmt.Errorf("ssh[%d]: action must be %q or %q, got %q", i, actionAccept, actionCheck, r.Action)
+		}
+	}
+	return nil
+}
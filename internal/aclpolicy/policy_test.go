@@ -0,0 +1,358 @@
+package aclpolicy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testPolicy = `{
+	// Groups and hosts
+	"groups": {
+		"group:admin": ["alice@example.com"],
+		"group:dev":   ["bob@example.com", "carol@example.com"],
+	},
+	"hosts": {
+		"corp-net": "10.0.0.0/16",
+		"db-primary": "100.64.0.10",
+	},
+	"tagOwners": {
+		"tag:db": ["group:admin"],
+	},
+	"acls": [
+		{"action": "accept", "src": ["group:admin"], "dst": ["*:*"]},
+		{"action": "accept", "src": ["group:dev"], "dst": ["tag:dev:22,80-90"]},
+		{"action": "accept", "src": ["corp-net"], "dst": ["db-primary:5432"], "proto": "tcp"},
+	],
+	"grants": [
+		{"src": ["autogroup:member"], "dst": ["tag:web"], "ip": ["tcp:443", "icmp"]},
+	],
+	"ssh": [
+		{"action": "check", "src": ["group:admin"], "dst": ["tag:prod"], "users": ["root"], "checkPeriod": "12h"},
+		{"action": "accept", "src": ["autogroup:member"], "dst": ["autogroup:self"], "users": ["autogroup:nonroot"]},
+		{"action": "accept", "src": ["group:admin"], "dst": ["tag:prod"], "users": ["autogroup:nonroot", "localpart:*@example.com"]},
+	],
+	"tests": [
+		{"src": "bob@example.com", "accept": ["tag:dev:22", "tag:web:443"], "deny": ["tag:db:5432"]},
+	],
+	"sshTests": [
+		{"src": "alice@example.com", "dst": ["tag:prod"], "check": ["root"], "accept": ["ubuntu"]},
+	],
+}`
+
+func mustParse(t *testing.T, s string) *Policy {
+	t.Helper()
+	p, err := Parse([]byte(s))
+	if err != nil {
+		t.Fatalf("Parse(...): unexpected error: %v", err)
+	}
+	return p
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		policy string
+		err    string
+	}{
+		"ValidHuJSON": {
+			reason: "Should accept comments and trailing commas",
+			policy: testPolicy,
+		},
+		"InvalidSyntax": {
+			reason: "Should reject documents that are not HuJSON",
+			policy: `{"acls": [`,
+			err:    "cannot parse policy as HuJSON",
+		},
+		"BadAction": {
+			reason: "Should reject acls with an action other than accept",
+			policy: `{"acls": [{"action": "deny", "src": ["*"], "dst": ["*:*"]}]}`,
+			err:    `acls[0]: action must be "accept"`,
+		},
+		"BadDestination": {
+			reason: "Should reject destinations without ports",
+			policy: `{"acls": [{"action": "accept", "src": ["*"], "dst": ["tag:db"]}]}`,
+			err:    `invalid destination "tag:db"`,
+		},
+		"BadGroupName": {
+			reason: "Should reject groups without the group: prefix",
+			policy: `{"groups": {"admins": []}}`,
+			err:    `groups: "admins" must start with "group:"`,
+		},
+		"BadSSHAction": {
+			reason: "Should reject ssh rules with an unknown action",
+			policy: `{"ssh": [{"action": "allow", "src": ["*"], "dst": ["*"], "users": ["root"]}]}`,
+			err:    `ssh[0]: action must be "accept" or "check"`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.policy))
+			if tc.err == "" && err != nil {
+				t.Errorf("\n%s\nParse(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Errorf("\n%s\nParse(...): error should contain %q, got %v", tc.reason, tc.err, err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p := mustParse(t, testPolicy)
+
+	type args struct {
+		src   string
+		dst   string
+		proto string
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   Decision
+		err    bool
+	}{
+		"GroupMemberWildcard": {
+			reason: "Group members should be granted access by a rule naming the group",
+			args:   args{src: "alice@example.com", dst: "tag:db:5432"},
+			want:   Decision{Allowed: true, Rule: "acls[0]"},
+		},
+		"PortRange": {
+			reason: "Ports inside a range should match",
+			args:   args{src: "bob@example.com", dst: "tag:dev:85"},
+			want:   Decision{Allowed: true, Rule: "acls[1]"},
+		},
+		"PortOutsideRange": {
+			reason: "Ports outside the listed ports should not match",
+			args:   args{src: "bob@example.com", dst: "tag:dev:443"},
+			want:   Decision{},
+		},
+		"WholeGroup": {
+			reason: "A group should match rules naming that group",
+			args:   args{src: "group:dev", dst: "tag:dev:22"},
+			want:   Decision{Allowed: true, Rule: "acls[1]"},
+		},
+		"HostAliasAndCIDR": {
+			reason: "IP sources inside a CIDR alias should reach a host alias",
+			args:   args{src: "10.0.3.4", dst: "100.64.0.10:5432"},
+			want:   Decision{Allowed: true, Rule: "acls[2]"},
+		},
+		"ProtoMismatch": {
+			reason: "Rules restricted to a protocol should not match another protocol",
+			args:   args{src: "10.0.3.4", dst: "db-primary:5432", proto: "udp"},
+			want:   Decision{},
+		},
+		"Grant": {
+			reason: "Grants should be evaluated after acls",
+			args:   args{src: "carol@example.com", dst: "tag:web:443"},
+			want:   Decision{Allowed: true, Rule: "grants[0]"},
+		},
+		"GrantProtoByNumber": {
+			reason: "Protocol numbers should be treated like their names",
+			args:   args{src: "carol@example.com", dst: "tag:web:0", proto: "1"},
+			want:   Decision{Allowed: true, Rule: "grants[0]"},
+		},
+		"TagSourceIsNotMember": {
+			reason: "Tagged devices should not be covered by autogroup:member",
+			args:   args{src: "tag:ci", dst: "tag:web:443"},
+			want:   Decision{},
+		},
+		"InvalidDestination": {
+			reason: "Destinations must name a single port",
+			args:   args{src: "alice@example.com", dst: "tag:db:*"},
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := p.Check(tc.args.src, tc.args.dst, tc.args.proto)
+			if (err != nil) != tc.err {
+				t.Fatalf("\n%s\nCheck(...): unexpected error state: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nCheck(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheckSSH(t *testing.T) {
+	p := mustParse(t, testPolicy)
+
+	cases := map[string]struct {
+		reason string
+		src    string
+		dst    string
+		login  string
+		want   string
+	}{
+		"CheckRuleFirst": {
+			reason: "The first matching rule should decide the action",
+			src:    "alice@example.com",
+			dst:    "tag:prod",
+			login:  "root",
+			want:   "ssh[0] check",
+		},
+		"NonRoot": {
+			reason: "autogroup:nonroot should allow any login except root",
+			src:    "alice@example.com",
+			dst:    "tag:prod",
+			login:  "ubuntu",
+			want:   "ssh[2] accept",
+		},
+		"Self": {
+			reason: "autogroup:self should cover the source user's own devices",
+			src:    "bob@example.com",
+			dst:    "bob@example.com",
+			login:  "bob",
+			want:   "ssh[1] accept",
+		},
+		"OtherUsersDevices": {
+			reason: "autogroup:self should not cover other users' devices",
+			src:    "bob@example.com",
+			dst:    "carol@example.com",
+			login:  "bob",
+			want:   "deny",
+		},
+		"Denied": {
+			reason: "Root on own devices is not granted",
+			src:    "bob@example.com",
+			dst:    "bob@example.com",
+			login:  "root",
+			want:   "deny",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := "deny"
+			if m := p.CheckSSH(tc.src, tc.dst, tc.login); m != nil {
+				got = m.Rule + " " + m.Action
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nCheckSSH(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSSHRules(t *testing.T) {
+	p := mustParse(t, testPolicy)
+	want := []SSHMatch{
+		{Rule: "ssh[0]", Action: "check", Users: []string{"root"}, CheckPeriod: "12h"},
+		{Rule: "ssh[2]", Action: "accept", Users: []string{"autogroup:nonroot", "localpart:*@example.com"}},
+	}
+	if diff := cmp.Diff(want, p.SSHRules("alice@example.com", "tag:prod")); diff != "" {
+		t.Errorf("SSHRules(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		policy string
+		want   []TestResult
+	}{
+		"Passing": {
+			reason: "The tests embedded in the policy should pass",
+			policy: testPolicy,
+			want: []TestResult{
+				{Name: "tests[0]"},
+				{Name: "sshTests[0]"},
+			},
+		},
+		"Failing": {
+			reason: "Failed assertions should be reported with the rule that caused them",
+			policy: `{
+				"acls": [{"action": "accept", "src": ["*"], "dst": ["*:22"]}],
+				"tests": [{"src": "bob@example.com", "accept": ["tag:web:443"], "deny": ["tag:web:22"]}],
+				"sshTests": [{"src": "bob@example.com", "dst": ["tag:web"], "accept": ["root"]}],
+			}`,
+			want: []TestResult{
+				{Name: "tests[0]", Failures: []string{
+					"bob@example.com -> tag:web:443 (tcp): expected accept, got deny",
+					"bob@example.com -> tag:web:22 (tcp): expected deny, accepted by acls[0]",
+				}},
+				{Name: "sshTests[0]", Failures: []string{
+					"bob@example.com -> tag:web as root: expected accept, got deny",
+				}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := mustParse(t, tc.policy).RunTests()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nRunTests(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestTagOwner(t *testing.T) {
+	p := mustParse(t, `{
+		"groups": {"group:ops": ["ops@example.com"]},
+		"tagOwners": {
+			"tag:db":  ["group:ops"],
+			"tag:ci":  ["ci@example.com", "autogroup:it-admin"],
+			"tag:any": ["autogroup:member"],
+		},
+	}`)
+
+	cases := map[string]struct {
+		reason string
+		tag    string
+		user   User
+		want   TagOwnership
+	}{
+		"Group": {
+			reason: "Members of an owning group should own the tag",
+			tag:    "tag:db",
+			user:   User{Login: "ops@example.com", Role: "member"},
+			want:   TagOwnership{Tag: "tag:db", Defined: true, Owners: []string{"group:ops"}, OwnedBy: "group:ops"},
+		},
+		"RoleAutogroup": {
+			reason: "Users with the role of an owning autogroup should own the tag",
+			tag:    "tag:ci",
+			user:   User{Login: "it@example.com", Role: "it-admin"},
+			want:   TagOwnership{Tag: "tag:ci", Defined: true, Owners: []string{"ci@example.com", "autogroup:it-admin"}, OwnedBy: "autogroup:it-admin"},
+		},
+		"Member": {
+			reason: "autogroup:member should cover every user",
+			tag:    "tag:any",
+			user:   User{Login: "bob@example.com", Role: "member"},
+			want:   TagOwnership{Tag: "tag:any", Defined: true, Owners: []string{"autogroup:member"}, OwnedBy: "autogroup:member"},
+		},
+		"Admin": {
+			reason: "Admins may apply any defined tag",
+			tag:    "tag:db",
+			user:   User{Login: "root@example.com", Role: "admin"},
+			want:   TagOwnership{Tag: "tag:db", Defined: true, Owners: []string{"group:ops"}, OwnedBy: "role:admin"},
+		},
+		"NotOwned": {
+			reason: "Members outside of the owners should not own the tag",
+			tag:    "tag:db",
+			user:   User{Login: "bob@example.com", Role: "member"},
+			want:   TagOwnership{Tag: "tag:db", Defined: true, Owners: []string{"group:ops"}},
+		},
+		"Undefined": {
+			reason: "Nobody, not even an admin, may apply a tag missing from tagOwners",
+			tag:    "tag:web",
+			user:   User{Login: "root@example.com", Role: "owner"},
+			want:   TagOwnership{Tag: "tag:web"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := p.TagOwner(tc.tag, tc.user)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nTagOwner(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package aclpolicy
+
+import (
+	"slices"
+	"strings"
+)
+
+// Roles of tailnet users that may apply any tag defined in tagOwners,
+// whether or not they are listed as its owner.
+var tagAdminRoles = []string{"owner", "admin", "network-admin"}
+
+// User is the user on whose behalf a tag is applied, for example the owner
+// of the API access token creating an auth key.
+type User struct {
+	Login string
+	Role  string
+}
+
+// TagOwnership is the result of checking whether a user may apply a tag.
+type TagOwnership struct {
+	Tag string
+	// Defined is false if the tag is missing from tagOwners, in which case
+	// nobody may apply it.
+	Defined bool
+	// Owners are the tagOwners entries of the tag.
+	Owners []string
+	// OwnedBy is the entry of Owners, or the role, that lets the user apply
+	// the tag. It is empty if the user may not apply it.
+	OwnedBy string
+}
+
+// Owned reports whether the user may apply the tag.
+func (o TagOwnership) Owned() bool {
+	return o.OwnedBy != ""
+}
+
+// TagOwner checks whether u may apply tag according to the tagOwners
+// section. Owners may be users, groups of users or the autogroups of user
+// roles, such as autogroup:admin.
+func (p *Policy) TagOwner(tag string, u User) TagOwnership {
+	owners, ok := p.TagOwners[tag]
+	o := TagOwnership{Tag: tag, Defined: ok, Owners: owners}
+	if !ok {
+		return o
+	}
+	for _, owner := range owners {
+		if p.ownedBy(owner, u) {
+			o.OwnedBy = owner
+			return o
+		}
+	}
+	if slices.Contains(tagAdminRoles, u.Role) {
+		o.OwnedBy = "role:" + u.Role
+	}
+	return o
+}
+
+func (p *Policy) ownedBy(owner string, u User) bool {
+	switch owner {
+	case u.Login:
+		return u.Login != ""
+	case autogroupMember:
+		return u.Login != ""
+	case "autogroup:admin":
+		// The owner of a tailnet has every permission of an admin.
+		return u.Role == "admin" || u.Role == "owner"
+	}
+	if role, ok := strings.CutPrefix(owner, prefixAutogroup); ok {
+		return role == u.Role
+	}
+	if strings.HasPrefix(owner, prefixGroup) {
+		return slices.Contains(p.Groups[owner], u.Login)
+	}
+	return false
+}
@@ -29,8 +29,18 @@ const (
 	TerraformProviderVersion = "0.22.0"
 )
 
+// A SetupOption configures the Terraform setup of every resource.
+type SetupOption func(*terraform.Setup)
+
+// WithScheduler sets the scheduler of the Terraform provider processes.
+func WithScheduler(s terraform.ProviderScheduler) SetupOption {
+	return func(ps *terraform.Setup) {
+		ps.Scheduler = s
+	}
+}
+
 // TerraformSetupBuilder returns Terraform setup with provider config.
-func TerraformSetupBuilder(version, providerSource, providerVersion string) terraform.SetupFn {
+func TerraformSetupBuilder(version, providerSource, providerVersion string, o ...SetupOption) terraform.SetupFn {
 	return func(ctx context.Context, kube client.Client, mg resource.Managed) (terraform.Setup, error) {
 		ps := terraform.Setup{
 			Version: version,
@@ -39,6 +49,9 @@ func TerraformSetupBuilder(version, providerSource, providerVersion string) terr
 				Version: providerVersion,
 			},
 		}
+		for _, fn := range o {
+			fn(&ps)
+		}
 
 		// Resolve provider config reference (v2 API)
 		var configRef *string
@@ -78,6 +91,9 @@ func TerraformSetupBuilder(version, providerSource, providerVersion string) terr
 		if len(credData) > 0 {
 			ps.Configuration["api_key"] = string(credData)
 		}
+		if pc.Spec.Tailnet != "" {
+			ps.Configuration[KeyTailnet] = pc.Spec.Tailnet
+		}
 
 		return ps, nil
 	}
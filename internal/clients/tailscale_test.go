@@ -118,6 +118,72 @@ func TestTerraformSetupBuilder(t *testing.T) {
 				err: nil,
 			},
 		},
+		"SuccessfulSetupWithTailnet": {
+			reason: "Should configure the tailnet of the ProviderConfig",
+			args: args{
+				version:         "v0.1.0",
+				providerSource:  TerraformProviderSource,
+				providerVersion: TerraformProviderVersion,
+				mg: newManagedWithProviderConfigRef(providerConfigName),
+				kube: &test.MockClient{
+					MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+						switch o := obj.(type) {
+						case *v1beta1.ProviderConfig:
+							// Return a valid ProviderConfig
+							*o = v1beta1.ProviderConfig{
+								ObjectMeta: metav1.ObjectMeta{
+									Name: providerConfigName,
+								},
+								Spec: v1beta1.ProviderConfigSpec{
+									Credentials: v1beta1.ProviderCredentials{
+										Source: "Secret",
+										CommonCredentialSelectors: xpv1.CommonCredentialSelectors{
+											SecretRef: &xpv1.SecretKeySelector{
+												SecretReference: xpv1.SecretReference{
+													Name:      secretName,
+													Namespace: secretNamespace,
+												},
+												Key: KeyAPIKey,
+											},
+										},
+									},
+									Tailnet: "example.com",
+								},
+							}
+							return nil
+						case *corev1.Secret:
+							// Return a valid secret with API key
+							*o = corev1.Secret{
+								ObjectMeta: metav1.ObjectMeta{
+									Name:      secretName,
+									Namespace: secretNamespace,
+								},
+								Data: map[string][]byte{
+									KeyAPIKey: []byte(apiKey),
+								},
+							}
+							return nil
+						default:
+							return errors.New("unexpected object type")
+						}
+					},
+				},
+			},
+			want: want{
+				setup: terraform.Setup{
+					Version: "v0.1.0",
+					Requirement: terraform.ProviderRequirement{
+						Source:  TerraformProviderSource,
+						Version: TerraformProviderVersion,
+					},
+					Configuration: map[string]any{
+						"api_key": apiKey,
+						"tailnet": "example.com",
+					},
+				},
+				err: nil,
+			},
+		},
 		"MissingProviderConfigReference": {
 			reason: "Should return error when no provider config is referenced",
 			args: args{
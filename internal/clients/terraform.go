@@ -0,0 +1,132 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/upjet/v2/pkg/terraform"
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	// DefaultTerraformVersion is the version of the Terraform CLI shipped
+	// in the provider image.
+	DefaultTerraformVersion = "1.5.5"
+
+	// envPluginCacheDir is where the Terraform CLI caches and looks up
+	// provider plugins.
+	envPluginCacheDir = "TF_PLUGIN_CACHE_DIR"
+	// envTmpDir is the root of the Terraform workspaces, which upjet
+	// creates in the temporary directory.
+	envTmpDir = "TMPDIR"
+
+	registryHost = "registry.terraform.io"
+)
+
+// TerraformRuntime is how and where the Terraform CLI runs.
+type TerraformRuntime struct {
+	// Version of the Terraform CLI.
+	Version string
+	// ProviderSource and ProviderVersion of the Terraform provider.
+	ProviderSource  string
+	ProviderVersion string
+	// NativeProviderPath is the path of a Terraform provider binary to run
+	// once and share between Terraform runs. Terraform starts the provider
+	// for every run if empty.
+	NativeProviderPath string
+	// PluginCacheDir is a directory of preloaded provider plugins.
+	PluginCacheDir string
+	// WorkspaceDir is where the workspace of each resource is created. The
+	// temporary directory is used if empty.
+	WorkspaceDir string
+}
+
+// Validate checks the versions and that the paths exist.
+func (r TerraformRuntime) Validate() error {
+	var errs []error
+	if _, err := version.NewVersion(r.Version); err != nil {
+		errs = append(errs, fmt.Errorf("terraform version %q: %w", r.Version, err))
+	}
+	if _, err := version.NewVersion(r.ProviderVersion); err != nil {
+		errs = append(errs, fmt.Errorf("terraform provider version %q: %w", r.ProviderVersion, err))
+	}
+	if p := strings.Split(r.ProviderSource, "/"); len(p) < 2 || len(p) > 3 || slices.Contains(p, "") {
+		errs = append(errs, fmt.Errorf("terraform provider source %q: must be [<host>/]<namespace>/<type>", r.ProviderSource))
+	}
+	if r.NativeProviderPath != "" {
+		if fi, err := os.Stat(r.NativeProviderPath); err != nil {
+			errs = append(errs, fmt.Errorf("terraform native provider path: %w", err))
+		} else if fi.IsDir() || fi.Mode().Perm()&0o111 == 0 {
+			errs = append(errs, fmt.Errorf("terraform native provider path %q: not an executable file", r.NativeProviderPath))
+		}
+	}
+	if r.PluginCacheDir != "" {
+		if err := isDir(r.PluginCacheDir); err != nil {
+			errs = append(errs, fmt.Errorf("terraform plugin cache dir: %w", err))
+		}
+	}
+	if r.WorkspaceDir != "" {
+		if err := isDir(r.WorkspaceDir); err != nil {
+			errs = append(errs, fmt.Errorf("terraform workspace dir: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Configure sets the environment inherited by every Terraform run, for the
+// plugin cache and workspace directories.
+func (r TerraformRuntime) Configure() error {
+	if r.PluginCacheDir != "" {
+		if err := os.Setenv(envPluginCacheDir, r.PluginCacheDir); err != nil {
+			return fmt.Errorf("cannot set %s: %w", envPluginCacheDir, err)
+		}
+	}
+	if r.WorkspaceDir != "" {
+		if err := os.Setenv(envTmpDir, r.WorkspaceDir); err != nil {
+			return fmt.Errorf("cannot set %s: %w", envTmpDir, err)
+		}
+	}
+	return nil
+}
+
+// Scheduler returns a scheduler running the native provider binary, which
+// is replaced after ttl Terraform runs, or nil if there is none.
+func (r TerraformRuntime) Scheduler(log logging.Logger, ttl int) terraform.ProviderScheduler {
+	if r.NativeProviderPath == "" {
+		return nil
+	}
+	name := r.ProviderSource
+	if strings.Count(name, "/") == 1 {
+		name = registryHost + "/" + name
+	}
+	return terraform.NewSharedProviderScheduler(log, ttl,
+		terraform.WithSharedProviderOptions(
+			terraform.WithNativeProviderPath(r.NativeProviderPath),
+			terraform.WithNativeProviderName(name),
+		))
+}
+
+// SetupFn returns the setup function of the Terraform runs.
+func (r TerraformRuntime) SetupFn(log logging.Logger, ttl int) terraform.SetupFn {
+	var o []SetupOption
+	if s := r.Scheduler(log, ttl); s != nil {
+		o = append(o, WithScheduler(s))
+	}
+	return TerraformSetupBuilder(r.Version, r.ProviderSource, r.ProviderVersion, o...)
+}
+
+func isDir(p string) error {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%q is not a directory", filepath.Clean(p))
+	}
+	return nil
+}
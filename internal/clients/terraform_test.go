@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+func TestTerraformRuntimeValidate(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "terraform-provider-tailscale")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	valid := TerraformRuntime{
+		Version:         DefaultTerraformVersion,
+		ProviderSource:  TerraformProviderSource,
+		ProviderVersion: TerraformProviderVersion,
+	}
+
+	cases := map[string]struct {
+		reason string
+		rt     func(r *TerraformRuntime)
+		err    bool
+	}{
+		"Defaults": {
+			reason: "The defaults should be valid",
+			rt:     func(_ *TerraformRuntime) {},
+		},
+		"AirGapped": {
+			reason: "An existing native provider, plugin cache and workspace directory should be valid",
+			rt: func(r *TerraformRuntime) {
+				r.ProviderSource = "registry.example.com/tailscale/tailscale"
+				r.NativeProviderPath = bin
+				r.PluginCacheDir = dir
+				r.WorkspaceDir = dir
+			},
+		},
+		"InvalidVersion": {
+			reason: "A Terraform version that cannot be parsed should be invalid",
+			rt:     func(r *TerraformRuntime) { r.Version = "latest" },
+			err:    true,
+		},
+		"InvalidProviderVersion": {
+			reason: "A provider version that cannot be parsed should be invalid",
+			rt:     func(r *TerraformRuntime) { r.ProviderVersion = "" },
+			err:    true,
+		},
+		"InvalidProviderSource": {
+			reason: "A provider source without a namespace should be invalid",
+			rt:     func(r *TerraformRuntime) { r.ProviderSource = "tailscale" },
+			err:    true,
+		},
+		"NativeProviderNotExecutable": {
+			reason: "A native provider that cannot be executed should be invalid",
+			rt:     func(r *TerraformRuntime) { r.NativeProviderPath = file },
+			err:    true,
+		},
+		"NativeProviderMissing": {
+			reason: "A missing native provider should be invalid",
+			rt:     func(r *TerraformRuntime) { r.NativeProviderPath = filepath.Join(dir, "missing") },
+			err:    true,
+		},
+		"PluginCacheDirNotDir": {
+			reason: "A plugin cache directory that is a file should be invalid",
+			rt:     func(r *TerraformRuntime) { r.PluginCacheDir = file },
+			err:    true,
+		},
+		"WorkspaceDirMissing": {
+			reason: "A missing workspace directory should be invalid",
+			rt:     func(r *TerraformRuntime) { r.WorkspaceDir = filepath.Join(dir, "missing") },
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := valid
+			tc.rt(&r)
+			err := r.Validate()
+			if (err != nil) != tc.err {
+				t.Errorf("\n%s\nValidate(...): unexpected error state: %v", tc.reason, err)
+			}
+		})
+	}
+}
+
+func TestTerraformRuntimeScheduler(t *testing.T) {
+	r := TerraformRuntime{ProviderSource: TerraformProviderSource}
+	if s := r.Scheduler(logging.NewNopLogger(), 100); s != nil {
+		t.Errorf("Scheduler(...): want no scheduler without a native provider, got %T", s)
+	}
+	r.NativeProviderPath = "/terraform-provider-tailscale"
+	if s := r.Scheduler(logging.NewNopLogger(), 100); s == nil {
+		t.Errorf("Scheduler(...): want a scheduler for the native provider, got none")
+	}
+}
@@ -0,0 +1,160 @@
+// Package tsapi is a small client for the Tailscale v2 API. It covers the
+// calls the provider needs outside of Terraform, such as reading settings
+// before they are adopted or restoring them when a resource is deleted.
+package tsapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultBaseURL is the Tailscale API endpoint.
+	DefaultBaseURL = "https://api.tailscale.com"
+	// DefaultTailnet refers to the tailnet of the credentials in use.
+	DefaultTailnet = "-"
+
+	defaultTimeout = 30 * time.Second
+)
+
+// Client calls the Tailscale API on behalf of one tailnet.
+type Client struct {
+	baseURL string
+	apiKey  string
+	tailnet string
+	http    *http.Client
+}
+
+// An Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL overrides the API endpoint.
+func WithBaseURL(u string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// WithTailnet selects the tailnet to operate on.
+func WithTailnet(t string) Option {
+	return func(c *Client) {
+		c.tailnet = t
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// New returns a Client authenticating with the supplied API key.
+func New(apiKey string, o ...Option) *Client {
+	c := &Client{
+		baseURL: DefaultBaseURL,
+		apiKey:  apiKey,
+		tailnet: DefaultTailnet,
+		http:    &http.Client{Timeout: defaultTimeout},
+	}
+	for _, fn := range o {
+		fn(c)
+	}
+	return c
+}
+
+// Tailnet returns the tailnet the client operates on.
+func (c *Client) Tailnet() string {
+	return c.tailnet
+}
+
+// Errors counts the errors returned by the API by method and status, so
+// that rate limiting (429) and revoked credentials (401, 403) can be alerted
+// on. It only covers the calls made by this client, not those of the
+// Terraform provider, whose failures surface in the conditions of the
+// managed resources. It is registered by the metrics package.
+var Errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "provider_tailscale",
+	Subsystem: "api",
+	Name:      "errors_total",
+	Help:      "Errors returned by the Tailscale API to the provider's direct API calls by method and status. Calls made by Terraform are not counted.",
+}, []string{"method", "code"})
+
+// Error is returned when the API responds with a non-2xx status.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("tailscale API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an API error with status 404.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// tailnetPath returns the path of a tailnet scoped endpoint.
+func (c *Client) tailnetPath(elem ...string) string {
+	return "/api/v2/tailnet/" + url.PathEscape(c.tailnet) + "/" + strings.Join(elem, "/")
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("cannot encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot call tailscale API: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // nothing to do about it
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		Errors.WithLabelValues(method, strconv.Itoa(resp.StatusCode)).Inc()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(msg, &apiErr) == nil && apiErr.Message != "" {
+			msg = []byte(apiErr.Message)
+		}
+		return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("cannot decode tailscale API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package tsapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Contact types of a tailnet.
+const (
+	ContactAccount  = "account"
+	ContactSecurity = "security"
+	ContactSupport  = "support"
+)
+
+// Contact is a contact of the tailnet. Tailscale sends a verification email
+// when its address changes and only uses it once verified.
+type Contact struct {
+	Email             string `json:"email"`
+	FallbackEmail     string `json:"fallbackEmail,omitempty"`
+	NeedsVerification bool   `json:"needsVerification,omitempty"`
+}
+
+// Contacts are the contacts of a tailnet.
+type Contacts struct {
+	Account  Contact `json:"account"`
+	Security Contact `json:"security"`
+	Support  Contact `json:"support"`
+}
+
+// GetContacts returns the contacts of the tailnet.
+func (c *Client) GetContacts(ctx context.Context) (*Contacts, error) {
+	out := &Contacts{}
+	if err := c.do(ctx, http.MethodGet, c.tailnetPath("contacts"), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateContact changes the email address of a contact, which then needs to
+// be verified again.
+func (c *Client) UpdateContact(ctx context.Context, contactType, email string) error {
+	in := struct {
+		Email string `json:"email"`
+	}{Email: email}
+	return c.do(ctx, http.MethodPatch, c.tailnetPath("contacts", url.PathEscape(contactType)), in, nil)
+}
+
+// ResendContactVerification sends the verification email of a contact again.
+func (c *Client) ResendContactVerification(ctx context.Context, contactType string) error {
+	return c.do(ctx, http.MethodPost, c.tailnetPath("contacts", url.PathEscape(contactType), "resend-verification-email"), nil, nil)
+}
@@ -0,0 +1,82 @@
+package tsapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// providerConfigGroupVersionKind is the GVK of the provider's ProviderConfig.
+// It is read as unstructured data so that this package can be used from the
+// provider configuration, which is compiled before deepcopy functions for
+// the API types exist.
+var providerConfigGroupVersionKind = schema.GroupVersionKind{
+	Group:   "tailscale.upbound.io",
+	Version: "v1beta1",
+	Kind:    "ProviderConfig",
+}
+
+// providerCredentials mirrors v1beta1.ProviderCredentials.
+type providerCredentials struct {
+	Source                         xpv1.CredentialsSource `json:"source"`
+	xpv1.CommonCredentialSelectors `json:",inline"`
+}
+
+// NewForManaged returns a client using the credentials and tailnet of the
+// ProviderConfig referenced by the supplied managed resource.
+func NewForManaged(ctx context.Context, kube client.Client, mg resource.Managed, o ...Option) (*Client, error) {
+	var name string
+	switch m := mg.(type) {
+	case resource.LegacyManaged:
+		if ref := m.GetProviderConfigReference(); ref != nil {
+			name = ref.Name
+		}
+	case resource.ModernManaged:
+		if ref := m.GetProviderConfigReference(); ref != nil {
+			name = ref.Name
+		}
+	}
+	if name == "" {
+		return nil, errors.New("no provider config referenced")
+	}
+	return NewForProviderConfig(ctx, kube, name, o...)
+}
+
+// NewForProviderConfig returns a client using the credentials and tailnet of
+// the named ProviderConfig.
+func NewForProviderConfig(ctx context.Context, kube client.Client, name string, o ...Option) (*Client, error) {
+	pc := &unstructured.Unstructured{}
+	pc.SetGroupVersionKind(providerConfigGroupVersionKind)
+	if err := kube.Get(ctx, types.NamespacedName{Name: name}, pc); err != nil {
+		return nil, fmt.Errorf("cannot get provider config: %w", err)
+	}
+	raw, _, err := unstructured.NestedMap(pc.Object, "spec", "credentials")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read provider config credentials: %w", err)
+	}
+	creds := &providerCredentials{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, creds); err != nil {
+		return nil, fmt.Errorf("cannot read provider config credentials: %w", err)
+	}
+	data, err := resource.CommonCredentialExtractor(ctx, creds.Source, kube, creds.CommonCredentialSelectors)
+	if err != nil {
+		return nil, fmt.Errorf("cannot extract credentials: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return nil, errors.New("provider config credentials are empty")
+	}
+	if tailnet, _, _ := unstructured.NestedString(pc.Object, "spec", "tailnet"); tailnet != "" {
+		o = append([]Option{WithTailnet(tailnet)}, o...)
+	}
+	return New(key, o...), nil
+}
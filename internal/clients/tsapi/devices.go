@@ -0,0 +1,78 @@
+package tsapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Device is a device of a tailnet.
+type Device struct {
+	ID         string    `json:"id"`
+	NodeID     string    `json:"nodeId"`
+	Name       string    `json:"name"`
+	Hostname   string    `json:"hostname"`
+	OS         string    `json:"os"`
+	User       string    `json:"user"`
+	Tags       []string  `json:"tags,omitempty"`
+	Authorized bool      `json:"authorized"`
+	Created    time.Time `json:"created"`
+	Expires    time.Time `json:"expires"`
+}
+
+// devicePath returns the path of a device scoped endpoint.
+func devicePath(id string, elem ...string) string {
+	return "/api/v2/device/" + url.PathEscape(id) + strings.TrimSuffix("/"+strings.Join(elem, "/"), "/")
+}
+
+// ListDevices returns the devices of the tailnet.
+func (c *Client) ListDevices(ctx context.Context) ([]Device, error) {
+	out := struct {
+		Devices []Device `json:"devices"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, c.tailnetPath("devices"), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Devices, nil
+}
+
+// GetDevice returns the device with the supplied ID or node ID.
+func (c *Client) GetDevice(ctx context.Context, id string) (*Device, error) {
+	d := &Device{}
+	if err := c.do(ctx, http.MethodGet, devicePath(id), nil, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetDeviceAttributes returns the posture attributes of a device, such as
+// node:os or custom:compliant.
+func (c *Client) GetDeviceAttributes(ctx context.Context, id string) (map[string]any, error) {
+	out := struct {
+		Attributes map[string]any `json:"attributes"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, devicePath(id, "attributes"), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Attributes, nil
+}
+
+// AuthorizeDevice approves a device that is waiting for approval.
+func (c *Client) AuthorizeDevice(ctx context.Context, id string) error {
+	in := struct {
+		Authorized bool `json:"authorized"`
+	}{Authorized: true}
+	return c.do(ctx, http.MethodPost, devicePath(id, "authorized"), in, nil)
+}
+
+// ExpireDevice expires the node key of a device, logging it out.
+func (c *Client) ExpireDevice(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, devicePath(id, "expire"), nil, nil)
+}
+
+// DeleteDevice removes a device from the tailnet.
+func (c *Client) DeleteDevice(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, devicePath(id), nil, nil)
+}
@@ -0,0 +1,102 @@
+package tsapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiKeyPrefix is the prefix of Tailscale API access tokens.
+const apiKeyPrefix = "tskey-api-"
+
+// Key types.
+const (
+	KeyTypeAuth   = "auth"
+	KeyTypeAPI    = "api"
+	KeyTypeClient = "client"
+)
+
+// Key is an auth key, API access token or OAuth client of a tailnet.
+type Key struct {
+	ID          string    `json:"id"`
+	KeyType     string    `json:"keyType,omitempty"`
+	Description string    `json:"description,omitempty"`
+	UserID      string    `json:"userId,omitempty"`
+	Created     time.Time `json:"created"`
+	Expires     time.Time `json:"expires"`
+	Revoked     time.Time `json:"revoked"`
+	Invalid     bool      `json:"invalid,omitempty"`
+}
+
+// User is a user of a tailnet.
+type User struct {
+	ID          string `json:"id"`
+	LoginName   string `json:"loginName"`
+	DisplayName string `json:"displayName,omitempty"`
+	Role        string `json:"role"`
+}
+
+// GetKey returns the key with the supplied ID.
+func (c *Client) GetKey(ctx context.Context, id string) (*Key, error) {
+	k := &Key{}
+	if err := c.do(ctx, http.MethodGet, c.tailnetPath("keys", url.PathEscape(id)), nil, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// ListKeys returns the keys of every user of the tailnet. Keys revoked or
+// expired recently are included.
+func (c *Client) ListKeys(ctx context.Context) ([]Key, error) {
+	out := struct {
+		Keys []Key `json:"keys"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, c.tailnetPath("keys?all=true"), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Keys, nil
+}
+
+// DeleteKey revokes the key with the supplied ID.
+func (c *Client) DeleteKey(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, c.tailnetPath("keys", url.PathEscape(id)), nil, nil)
+}
+
+// GetUser returns the user with the supplied ID.
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	u := &User{}
+	if err := c.do(ctx, http.MethodGet, "/api/v2/users/"+url.PathEscape(id), nil, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// KeyID returns the ID of the API access token the client authenticates
+// with, which is embedded in the token as tskey-api-<id>-<secret>.
+func (c *Client) KeyID() (string, error) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(c.apiKey, apiKeyPrefix), "-")
+	if !strings.HasPrefix(c.apiKey, apiKeyPrefix) || !ok || id == "" {
+		return "", errors.New("credentials are not a tailscale API access token")
+	}
+	return id, nil
+}
+
+// Caller returns the user owning the API access token the client
+// authenticates with.
+func (c *Client) Caller(ctx context.Context) (*User, error) {
+	id, err := c.KeyID()
+	if err != nil {
+		return nil, err
+	}
+	k, err := c.GetKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if k.UserID == "" {
+		return nil, errors.New("API access token is not owned by a user")
+	}
+	return c.GetUser(ctx, k.UserID)
+}
@@ -0,0 +1,52 @@
+package tsapi
+
+import (
+	"context"
+	"net/http"
+
+	"k8s.io/utils/ptr"
+)
+
+// Settings are the tailnet-wide settings. Nil fields are left unchanged by
+// UpdateSettings.
+type Settings struct {
+	ACLsExternallyManagedOn                *bool   `json:"aclsExternallyManagedOn,omitempty"`
+	ACLsExternalLink                       *string `json:"aclsExternalLink,omitempty"`
+	DevicesApprovalOn                      *bool   `json:"devicesApprovalOn,omitempty"`
+	DevicesAutoUpdatesOn                   *bool   `json:"devicesAutoUpdatesOn,omitempty"`
+	DevicesKeyDurationDays                 *int    `json:"devicesKeyDurationDays,omitempty"`
+	UsersApprovalOn                        *bool   `json:"usersApprovalOn,omitempty"`
+	UsersRoleAllowedToJoinExternalTailnets *string `json:"usersRoleAllowedToJoinExternalTailnets,omitempty"`
+	NetworkFlowLoggingOn                   *bool   `json:"networkFlowLoggingOn,omitempty"`
+	RegionalRoutingOn                      *bool   `json:"regionalRoutingOn,omitempty"`
+	PostureIdentityCollectionOn            *bool   `json:"postureIdentityCollectionOn,omitempty"`
+}
+
+// DefaultSettings returns the settings of a newly created tailnet.
+func DefaultSettings() Settings {
+	return Settings{
+		ACLsExternallyManagedOn:                ptr.To(false),
+		DevicesApprovalOn:                      ptr.To(false),
+		DevicesAutoUpdatesOn:                   ptr.To(true),
+		DevicesKeyDurationDays:                 ptr.To(180),
+		UsersApprovalOn:                        ptr.To(false),
+		UsersRoleAllowedToJoinExternalTailnets: ptr.To("member"),
+		NetworkFlowLoggingOn:                   ptr.To(false),
+		RegionalRoutingOn:                      ptr.To(false),
+		PostureIdentityCollectionOn:            ptr.To(false),
+	}
+}
+
+// GetSettings returns the tailnet settings.
+func (c *Client) GetSettings(ctx context.Context) (*Settings, error) {
+	s := &Settings{}
+	if err := c.do(ctx, http.MethodGet, c.tailnetPath("settings"), nil, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UpdateSettings changes the non-nil fields of s.
+func (c *Client) UpdateSettings(ctx context.Context, s Settings) error {
+	return c.do(ctx, http.MethodPatch, c.tailnetPath("settings"), s, nil)
+}
@@ -0,0 +1,28 @@
+package tsapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Webhook is a webhook endpoint of a tailnet.
+type Webhook struct {
+	EndpointID       string    `json:"endpointId"`
+	EndpointURL      string    `json:"endpointUrl"`
+	ProviderType     string    `json:"providerType,omitempty"`
+	CreatorLoginName string    `json:"creatorLoginName,omitempty"`
+	Created          time.Time `json:"created"`
+	Subscriptions    []string  `json:"subscriptions,omitempty"`
+}
+
+// ListWebhooks returns the webhook endpoints of the tailnet.
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	out := struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, c.tailnetPath("webhooks"), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Webhooks, nil
+}
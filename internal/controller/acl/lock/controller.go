@@ -0,0 +1,100 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/upjet/v2/pkg/controller"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const controllerName = "console-lock.acl.tailscale.upbound.io"
+
+// newACL returns an empty ACL resource. The type is looked up in the scheme
+// to avoid importing the generated API package.
+func newACL(s *runtime.Scheme) (resource.Managed, error) {
+	o, err := s.New(aclGroupVersionKind)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create %s: %w", aclGroupVersionKind, err)
+	}
+	mg, ok := o.(resource.Managed)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a managed resource", aclGroupVersionKind)
+	}
+	return mg, nil
+}
+
+// Setup adds a controller that unlocks the console ACL editor when an ACL
+// holding the console lock finalizer is deleted.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	obj, err := newACL(mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+	r := &Reconciler{
+		kube:      mgr.GetClient(),
+		scheme:    mgr.GetScheme(),
+		log:       o.Logger.WithValues("controller", controllerName),
+		record:    event.NewAPIRecorder(mgr.GetEventRecorderFor(controllerName)),
+		newClient: newAPIClient,
+	}
+	hasFinalizer := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return meta.FinalizerExists(obj, Finalizer)
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(o.ForControllerRuntime()).
+		For(obj, builder.WithPredicates(hasFinalizer)).
+		Complete(r)
+}
+
+// SetupGated adds the controller; the ACL CRD is part of the package.
+func SetupGated(mgr ctrl.Manager, o controller.Options) error {
+	return Setup(mgr, o)
+}
+
+// Reconciler unlocks the console ACL editor for deleted ACL resources.
+type Reconciler struct {
+	kube      client.Client
+	scheme    *runtime.Scheme
+	log       logging.Logger
+	record    event.Recorder
+	newClient newClientFn
+}
+
+// Reconcile an ACL resource.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	mg, err := newACL(r.scheme)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.kube.Get(ctx, req.NamespacedName, mg); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if !meta.WasDeleted(mg) || !meta.FinalizerExists(mg, Finalizer) {
+		return reconcile.Result{}, nil
+	}
+	if orphaned(mg) {
+		r.log.Debug("Not unlocking console for orphaned ACL", "name", mg.GetName())
+	} else {
+		unlocked, err := unlock(ctx, r.kube, r.newClient, mg)
+		if err != nil {
+			r.record.Event(mg, event.Warning(reasonCannotUnlock, err))
+			return reconcile.Result{}, err
+		}
+		if unlocked {
+			r.record.Event(mg, event.Normal(reasonUnlocked, "Unlocked the console ACL editor"))
+		}
+	}
+	meta.RemoveFinalizer(mg, Finalizer)
+	return reconcile.Result{}, r.kube.Update(ctx, mg)
+}
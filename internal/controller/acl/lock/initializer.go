@@ -0,0 +1,207 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+// settingsClient is the part of the Tailscale API used by this package.
+type settingsClient interface {
+	GetSettings(ctx context.Context) (*tsapi.Settings, error)
+	UpdateSettings(ctx context.Context, s tsapi.Settings) error
+}
+
+// newClientFn returns an API client for the tailnet of a managed resource.
+type newClientFn func(ctx context.Context, kube client.Client, mg resource.Managed) (settingsClient, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, mg resource.Managed) (settingsClient, error) {
+	return tsapi.NewForManaged(ctx, kube, mg)
+}
+
+// recheckInterval is how long the outcome of a lock check is reused before
+// the Settings resources and the tailnet are consulted again.
+const recheckInterval = 10 * time.Minute
+
+// A check is the outcome of a lock check for an ACL.
+type check struct {
+	cfg  Config
+	cond xpv1.Condition
+	at   time.Time
+}
+
+// Initializer locks the console ACL editor for ACL resources that ask for
+// it, and unlocks it again when the lock is switched off.
+//
+// Checking the lock takes a List of the Settings resources and a call to the
+// Tailscale API, so the outcome is reused for recheckInterval as long as the
+// requested lock stays the same. Changes made in the console are therefore
+// undone within recheckInterval rather than on the next poll.
+type Initializer struct {
+	kube      client.Client
+	newClient newClientFn
+	interval  time.Duration
+
+	mu      sync.Mutex
+	checked map[types.UID]check
+}
+
+// NewInitializer returns an Initializer using the supplied client. Its
+// signature matches config.NewInitializerFn.
+func NewInitializer(kube client.Client) managed.Initializer {
+	return &Initializer{kube: kube, newClient: newAPIClient, interval: recheckInterval}
+}
+
+// Initialize makes sure the tailnet settings match the console lock
+// requested by the ACL. ACLs being deleted are left to the companion
+// controller, which unlocks the console and removes the finalizer.
+func (i *Initializer) Initialize(ctx context.Context, mg resource.Managed) error {
+	if meta.WasDeleted(mg) {
+		i.forget(mg)
+		return nil
+	}
+	cfg, err := ConfigOf(mg)
+	if err != nil {
+		mg.SetConditions(LockFailed(err))
+		return err
+	}
+	if !cfg.Enabled {
+		i.forget(mg)
+		return i.release(ctx, mg)
+	}
+	if c, ok := i.recent(mg, cfg); ok {
+		return observe(mg, cfg, c.cond)
+	}
+
+	owner, err := SettingsOwner(ctx, i.kube)
+	if err != nil {
+		return err
+	}
+	if owner != "" {
+		i.remember(mg, cfg, ManagedBySettings(owner))
+		return observe(mg, cfg, ManagedBySettings(owner))
+	}
+	if !meta.FinalizerExists(mg, Finalizer) {
+		meta.AddFinalizer(mg, Finalizer)
+		if err := i.kube.Update(ctx, mg); err != nil {
+			return fmt.Errorf("cannot add console lock finalizer: %w", err)
+		}
+	}
+	if err := i.lock(ctx, mg, cfg); err != nil {
+		mg.SetConditions(LockFailed(err))
+		return err
+	}
+	i.remember(mg, cfg, Locked(cfg))
+	return observe(mg, cfg, Locked(cfg))
+}
+
+// observe reports the outcome of a lock check in the ACL's condition and
+// status.
+func observe(mg resource.Managed, cfg Config, cond xpv1.Condition) error {
+	mg.SetConditions(cond)
+	if cond.Reason != ReasonLocked {
+		return SetObserved(mg, false, "")
+	}
+	return SetObserved(mg, true, cfg.Link)
+}
+
+// recent returns the outcome of the last check of the ACL if it was made for
+// the same lock within the recheck interval. A lock is checked again if the
+// ACL lost its finalizer.
+func (i *Initializer) recent(mg resource.Managed, cfg Config) (check, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	c, ok := i.checked[mg.GetUID()]
+	if !ok || c.cfg != cfg || time.Since(c.at) >= i.interval {
+		return check{}, false
+	}
+	if c.cond.Reason == ReasonLocked && !meta.FinalizerExists(mg, Finalizer) {
+		return check{}, false
+	}
+	return c, true
+}
+
+func (i *Initializer) remember(mg resource.Managed, cfg Config, cond xpv1.Condition) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.checked == nil {
+		i.checked = map[types.UID]check{}
+	}
+	i.checked[mg.GetUID()] = check{cfg: cfg, cond: cond, at: time.Now()}
+}
+
+func (i *Initializer) forget(mg resource.Managed) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.checked, mg.GetUID())
+}
+
+func (i *Initializer) lock(ctx context.Context, mg resource.Managed, cfg Config) error {
+	c, err := i.newClient(ctx, i.kube, mg)
+	if err != nil {
+		return err
+	}
+	s, err := c.GetSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get tailnet settings: %w", err)
+	}
+	if cfg.locked(s) {
+		return nil
+	}
+	if err := c.UpdateSettings(ctx, cfg.lockSettings()); err != nil {
+		return fmt.Errorf("cannot lock console ACL editor: %w", err)
+	}
+	return nil
+}
+
+// release unlocks the console if this ACL locked it before its lock was
+// switched off.
+func (i *Initializer) release(ctx context.Context, mg resource.Managed) error {
+	if !meta.FinalizerExists(mg, Finalizer) {
+		return nil
+	}
+	if _, err := unlock(ctx, i.kube, i.newClient, mg); err != nil {
+		mg.SetConditions(LockFailed(err))
+		return err
+	}
+	meta.RemoveFinalizer(mg, Finalizer)
+	if err := i.kube.Update(ctx, mg); err != nil {
+		return fmt.Errorf("cannot remove console lock finalizer: %w", err)
+	}
+	mg.SetConditions(xpv1.Condition{
+		Type:               TypeConsoleLocked,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonUnlocked,
+	})
+	return SetObserved(mg, false, "")
+}
+
+// unlock unlocks the console editor unless a Settings resource owns the
+// lock. It reports whether the tailnet settings were changed.
+func unlock(ctx context.Context, kube client.Client, newClient newClientFn, mg resource.Managed) (bool, error) {
+	owner, err := SettingsOwner(ctx, kube)
+	if err != nil || owner != "" {
+		return false, err
+	}
+	c, err := newClient(ctx, kube, mg)
+	if err != nil {
+		return false, err
+	}
+	if err := c.UpdateSettings(ctx, unlockSettings()); err != nil {
+		return false, fmt.Errorf("cannot unlock console ACL editor: %w", err)
+	}
+	return true, nil
+}
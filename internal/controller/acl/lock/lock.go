@@ -0,0 +1,233 @@
+// Package lock locks the ACL editor of the Tailscale admin console while an
+// ACL resource manages the policy, so that edits made in the console don't
+// drift from the desired state.
+//
+// The lock is enabled in spec.forProvider of the ACL:
+//
+//	lockConsole: true
+//	externalLink: https://github.com/example/tailnet-policy
+//
+// An Initializer sets acls_externally_managed_on, and acls_external_link if a
+// link is given, on the tailnet before the ACL is observed, adds a finalizer
+// and reports the lock in status.atProvider.consoleLock. When the ACL is
+// deleted a companion controller unlocks the editor again and removes the
+// finalizer.
+//
+// Both values are also arguments of tailnet.Settings. A Settings resource
+// that sets either of them owns them: the ACL then leaves the tailnet alone
+// and reports this in its ConsoleLocked condition. Settings never
+// late-initializes them, and does not restore them on delete while an ACL
+// holds the lock.
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+// Finalizer holds an ACL resource until the console is unlocked.
+const Finalizer = "acl.tailscale.upbound.io/console-lock"
+
+// TypeConsoleLocked reports whether the console ACL editor is locked.
+const TypeConsoleLocked xpv1.ConditionType = "ConsoleLocked"
+
+// Reasons of the ConsoleLocked condition.
+const (
+	ReasonLocked            xpv1.ConditionReason = "Locked"
+	ReasonManagedBySettings xpv1.ConditionReason = "ManagedBySettings"
+	ReasonLockFailed        xpv1.ConditionReason = "LockFailed"
+	ReasonUnlocked          xpv1.ConditionReason = "Unlocked"
+)
+
+// Event reasons.
+const (
+	reasonUnlocked     = "UnlockedConsole"
+	reasonCannotUnlock = "CannotUnlockConsole"
+)
+
+// settingsFields are the Settings arguments that control the console lock.
+var settingsFields = []string{"aclsExternallyManagedOn", "aclsExternalLink"}
+
+var (
+	aclGroupVersionKind = schema.GroupVersionKind{
+		Group:   "acl.tailscale.upbound.io",
+		Version: "v1alpha1",
+		Kind:    "ACL",
+	}
+	settingsGroupVersionKind = schema.GroupVersionKind{
+		Group:   "tailnet.tailscale.upbound.io",
+		Version: "v1alpha1",
+		Kind:    "Settings",
+	}
+)
+
+// Config is the console lock requested by an ACL.
+type Config struct {
+	Enabled bool
+	Link    string
+}
+
+// ConfigOf returns the console lock configured in spec.forProvider of the
+// supplied ACL. The CRD validates the same as this function does.
+func ConfigOf(obj runtime.Object) (Config, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return Config{}, fmt.Errorf("cannot convert ACL: %w", err)
+	}
+	var c Config
+	c.Enabled, _, _ = unstructured.NestedBool(u, "spec", "forProvider", "lockConsole")
+	c.Link, _, _ = unstructured.NestedString(u, "spec", "forProvider", "externalLink")
+	if c.Link == "" {
+		return c, nil
+	}
+	if !c.Enabled {
+		return Config{}, errors.New("spec.forProvider.externalLink requires spec.forProvider.lockConsole to be true")
+	}
+	l, err := url.Parse(c.Link)
+	if err != nil || l.Scheme != "https" || l.Host == "" {
+		return Config{}, fmt.Errorf("spec.forProvider.externalLink must be an https URL, got %q", c.Link)
+	}
+	return c, nil
+}
+
+// SetObserved records whether the ACL holds the console lock, and the link
+// it set, in status.atProvider.consoleLock.
+func SetObserved(obj runtime.Object, locked bool, link string) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return fmt.Errorf("cannot convert ACL: %w", err)
+	}
+	observed := map[string]any{"locked": locked}
+	if link != "" {
+		observed["externalLink"] = link
+	}
+	if err := unstructured.SetNestedMap(u, observed, "status", "atProvider", "consoleLock"); err != nil {
+		return fmt.Errorf("cannot set status.atProvider.consoleLock: %w", err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, obj); err != nil {
+		return fmt.Errorf("cannot convert ACL: %w", err)
+	}
+	return nil
+}
+
+// lockSettings returns the tailnet settings that lock the console.
+func (c Config) lockSettings() tsapi.Settings {
+	s := tsapi.Settings{ACLsExternallyManagedOn: ptr.To(true)}
+	if c.Link != "" {
+		s.ACLsExternalLink = ptr.To(c.Link)
+	}
+	return s
+}
+
+// locked reports whether the supplied settings already match the lock.
+func (c Config) locked(s *tsapi.Settings) bool {
+	if s == nil || s.ACLsExternallyManagedOn == nil || !*s.ACLsExternallyManagedOn {
+		return false
+	}
+	return c.Link == "" || (s.ACLsExternalLink != nil && *s.ACLsExternalLink == c.Link)
+}
+
+// unlockSettings returns the tailnet settings that unlock the console.
+func unlockSettings() tsapi.Settings {
+	return tsapi.Settings{ACLsExternallyManagedOn: ptr.To(false), ACLsExternalLink: ptr.To("")}
+}
+
+// SettingsOwner returns the name of the Settings resource that sets the
+// console lock arguments itself, or an empty string if there is none.
+func SettingsOwner(ctx context.Context, kube client.Reader) (string, error) {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(settingsGroupVersionKind.GroupVersion().WithKind(settingsGroupVersionKind.Kind + "List"))
+	if err := kube.List(ctx, l); err != nil {
+		return "", fmt.Errorf("cannot list tailnet settings: %w", err)
+	}
+	for _, s := range l.Items {
+		if s.GetDeletionTimestamp() != nil {
+			continue
+		}
+		for _, spec := range []string{"forProvider", "initProvider"} {
+			for _, f := range settingsFields {
+				if _, ok, _ := unstructured.NestedFieldNoCopy(s.Object, "spec", spec, f); ok {
+					return s.GetName(), nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// Held reports whether an ACL resource holds the console lock.
+func Held(ctx context.Context, kube client.Reader) (bool, error) {
+	l := &metav1.PartialObjectMetadataList{}
+	l.SetGroupVersionKind(aclGroupVersionKind.GroupVersion().WithKind(aclGroupVersionKind.Kind + "List"))
+	if err := kube.List(ctx, l); err != nil {
+		return false, fmt.Errorf("cannot list ACLs: %w", err)
+	}
+	for i := range l.Items {
+		if meta.FinalizerExists(&l.Items[i], Finalizer) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Locked returns a condition indicating the console editor is locked.
+func Locked(c Config) xpv1.Condition {
+	msg := "The Tailscale admin console ACL editor is locked"
+	if c.Link != "" {
+		msg += " and links to " + c.Link
+	}
+	return xpv1.Condition{
+		Type:               TypeConsoleLocked,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonLocked,
+		Message:            msg,
+	}
+}
+
+// ManagedBySettings returns a condition indicating that the lock is left to
+// the named Settings resource.
+func ManagedBySettings(name string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeConsoleLocked,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonManagedBySettings,
+		Message:            fmt.Sprintf("Settings %q sets aclsExternallyManagedOn or aclsExternalLink and owns the console lock", name),
+	}
+}
+
+// LockFailed returns a condition indicating the console could not be locked.
+func LockFailed(err error) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeConsoleLocked,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonLockFailed,
+		Message:            err.Error(),
+	}
+}
+
+// orphaned reports whether the external resources of mg must be left alone.
+func orphaned(mg resource.Managed) bool {
+	lm, ok := mg.(resource.LegacyManaged)
+	return ok && lm.GetDeletionPolicy() == xpv1.DeletionOrphan
+}
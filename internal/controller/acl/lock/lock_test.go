@@ -0,0 +1,406 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aclv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/acl/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+type fakeSettingsClient struct {
+	current *tsapi.Settings
+	updated *tsapi.Settings
+	err     error
+}
+
+func (f *fakeSettingsClient) GetSettings(context.Context) (*tsapi.Settings, error) {
+	return f.current, f.err
+}
+
+func (f *fakeSettingsClient) UpdateSettings(_ context.Context, s tsapi.Settings) error {
+	f.updated = &s
+	return f.err
+}
+
+func (f *fakeSettingsClient) newClient(context.Context, client.Client, resource.Managed) (settingsClient, error) {
+	return f, nil
+}
+
+func newACLResource(lock *bool, link string, finalizers ...string) *aclv1alpha1.ACL {
+	mg := &aclv1alpha1.ACL{ObjectMeta: metav1.ObjectMeta{
+		Name:       "acl",
+		Finalizers: finalizers,
+	}}
+	mg.Spec.ForProvider.LockConsole = lock
+	if link != "" {
+		mg.Spec.ForProvider.ExternalLink = ptr.To(link)
+	}
+	return mg
+}
+
+// listSettings returns a MockListFn that lists a Settings resource with the
+// supplied forProvider arguments.
+func listSettings(forProvider map[string]any) test.MockListFn {
+	return test.NewMockListFn(nil, func(obj client.ObjectList) error {
+		l, ok := obj.(*unstructured.UnstructuredList)
+		if !ok || forProvider == nil {
+			return nil
+		}
+		s := unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"forProvider": forProvider}}}
+		s.SetName("settings")
+		l.Items = append(l.Items, s)
+		return nil
+	})
+}
+
+func TestConfigOf(t *testing.T) {
+	cases := map[string]struct {
+		mg   *aclv1alpha1.ACL
+		want Config
+		err  bool
+	}{
+		"Disabled": {
+			mg: newACLResource(nil, ""),
+		},
+		"SwitchedOff": {
+			mg: newACLResource(ptr.To(false), ""),
+		},
+		"Enabled": {
+			mg:   newACLResource(ptr.To(true), ""),
+			want: Config{Enabled: true},
+		},
+		"WithLink": {
+			mg:   newACLResource(ptr.To(true), "https://github.com/example/policy"),
+			want: Config{Enabled: true, Link: "https://github.com/example/policy"},
+		},
+		"LinkWithoutLock": {
+			mg:  newACLResource(nil, "https://github.com/example/policy"),
+			err: true,
+		},
+		"InsecureLink": {
+			mg:  newACLResource(ptr.To(true), "http://example.com"),
+			err: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ConfigOf(tc.mg)
+			if (err != nil) != tc.err {
+				t.Errorf("ConfigOf(...): unexpected error state: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ConfigOf(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSettingsOwner(t *testing.T) {
+	cases := map[string]struct {
+		reason      string
+		forProvider map[string]any
+		want        string
+	}{
+		"NoSettings": {
+			reason: "Without a Settings resource nobody owns the lock",
+		},
+		"UnrelatedSettings": {
+			reason:      "Settings that don't set the lock arguments don't own it",
+			forProvider: map[string]any{"devicesApprovalOn": true},
+		},
+		"Owner": {
+			reason:      "Settings that set a lock argument own it",
+			forProvider: map[string]any{"aclsExternallyManagedOn": false},
+			want:        "settings",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := SettingsOwner(context.Background(), &test.MockClient{MockList: listSettings(tc.forProvider)})
+			if err != nil {
+				t.Fatalf("\n%s\nSettingsOwner(...): unexpected error: %v", tc.reason, err)
+			}
+			if got != tc.want {
+				t.Errorf("\n%s\nSettingsOwner(...) = %q, want %q", tc.reason, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	type want struct {
+		reason    xpv1.ConditionReason
+		finalizer bool
+		updated   *tsapi.Settings
+		observed  *aclv1alpha1.ConsoleLockObservation
+		err       bool
+	}
+	cases := map[string]struct {
+		reason      string
+		mg          *aclv1alpha1.ACL
+		current     *tsapi.Settings
+		forProvider map[string]any
+		apiErr      error
+		want        want
+	}{
+		"Disabled": {
+			reason: "ACLs without the lock should be left alone",
+			mg:     newACLResource(nil, ""),
+		},
+		"Lock": {
+			reason:  "The console should be locked and the finalizer added",
+			mg:      newACLResource(ptr.To(true), "https://git.example.com/policy"),
+			current: &tsapi.Settings{ACLsExternallyManagedOn: ptr.To(false)},
+			want: want{
+				reason:    ReasonLocked,
+				finalizer: true,
+				updated:   &tsapi.Settings{ACLsExternallyManagedOn: ptr.To(true), ACLsExternalLink: ptr.To("https://git.example.com/policy")},
+				observed:  &aclv1alpha1.ConsoleLockObservation{Locked: ptr.To(true), ExternalLink: ptr.To("https://git.example.com/policy")},
+			},
+		},
+		"AlreadyLocked": {
+			reason:  "Settings that already match should not be written",
+			mg:      newACLResource(ptr.To(true), "", Finalizer),
+			current: &tsapi.Settings{ACLsExternallyManagedOn: ptr.To(true), ACLsExternalLink: ptr.To("https://elsewhere.example.com")},
+			want: want{
+				reason:    ReasonLocked,
+				finalizer: true,
+				observed:  &aclv1alpha1.ConsoleLockObservation{Locked: ptr.To(true)},
+			},
+		},
+		"ManagedBySettings": {
+			reason:      "A Settings resource that sets the lock arguments wins",
+			mg:          newACLResource(ptr.To(true), ""),
+			forProvider: map[string]any{"aclsExternallyManagedOn": false},
+			want: want{
+				reason:   ReasonManagedBySettings,
+				observed: &aclv1alpha1.ConsoleLockObservation{Locked: ptr.To(false)},
+			},
+		},
+		"Released": {
+			reason: "Switching the lock off should unlock the console",
+			mg:     newACLResource(nil, "", Finalizer),
+			want: want{
+				reason:   ReasonUnlocked,
+				updated:  &tsapi.Settings{ACLsExternallyManagedOn: ptr.To(false), ACLsExternalLink: ptr.To("")},
+				observed: &aclv1alpha1.ConsoleLockObservation{Locked: ptr.To(false)},
+			},
+		},
+		"Deleted": {
+			reason: "Deleted ACLs are left to the controller and must not be locked again",
+			mg: func() *aclv1alpha1.ACL {
+				mg := newACLResource(ptr.To(true), "")
+				mg.SetDeletionTimestamp(ptr.To(metav1.Now()))
+				return mg
+			}(),
+			current: &tsapi.Settings{ACLsExternallyManagedOn: ptr.To(false)},
+		},
+		"APIError": {
+			reason:  "API errors should be reported in the condition",
+			mg:      newACLResource(ptr.To(true), ""),
+			current: &tsapi.Settings{},
+			apiErr:  errors.New("boom"),
+			want:    want{reason: ReasonLockFailed, finalizer: true, err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kube := &test.MockClient{
+				MockList:   listSettings(tc.forProvider),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			}
+			api := &fakeSettingsClient{current: tc.current, err: tc.apiErr}
+			i := &Initializer{kube: kube, newClient: api.newClient, interval: recheckInterval}
+			err := i.Initialize(context.Background(), tc.mg)
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nInitialize(...): unexpected error state: %v", tc.reason, err)
+			}
+			if got := tc.mg.GetCondition(TypeConsoleLocked).Reason; got != tc.want.reason {
+				t.Errorf("\n%s\nInitialize(...): condition reason = %q, want %q", tc.reason, got, tc.want.reason)
+			}
+			if got := meta.FinalizerExists(tc.mg, Finalizer); got != tc.want.finalizer {
+				t.Errorf("\n%s\nInitialize(...): finalizer = %t, want %t", tc.reason, got, tc.want.finalizer)
+			}
+			if diff := cmp.Diff(tc.want.observed, tc.mg.Status.AtProvider.ConsoleLock); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want status, +got status:\n%s", tc.reason, diff)
+			}
+			if tc.apiErr == nil {
+				if diff := cmp.Diff(tc.want.updated, api.updated); diff != "" {
+					t.Errorf("\n%s\nInitialize(...): -want updated, +got updated:\n%s", tc.reason, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestInitializeRecheck(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		interval time.Duration
+		change   func(mg *aclv1alpha1.ACL)
+		calls    int
+	}{
+		"Cached": {
+			reason:   "A recent check of the same lock should be reused",
+			interval: recheckInterval,
+			calls:    1,
+		},
+		"Expired": {
+			reason: "Checks older than the interval should be made again",
+			calls:  2,
+		},
+		"Changed": {
+			reason:   "A different lock should be checked again",
+			interval: recheckInterval,
+			change: func(mg *aclv1alpha1.ACL) {
+				mg.Spec.ForProvider.ExternalLink = ptr.To("https://git.example.com/policy")
+			},
+			calls: 2,
+		},
+		"FinalizerRemoved": {
+			reason:   "A lock should be checked again if the finalizer was removed",
+			interval: recheckInterval,
+			change: func(mg *aclv1alpha1.ACL) {
+				meta.RemoveFinalizer(mg, Finalizer)
+			},
+			calls: 2,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			kube := &test.MockClient{
+				MockList: test.NewMockListFn(nil, func(client.ObjectList) error {
+					calls++
+					return nil
+				}),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			}
+			api := &fakeSettingsClient{current: &tsapi.Settings{ACLsExternallyManagedOn: ptr.To(true)}}
+			i := &Initializer{kube: kube, newClient: api.newClient, interval: tc.interval}
+			mg := newACLResource(ptr.To(true), "")
+			mg.SetUID("acl-uid")
+			for range 2 {
+				if err := i.Initialize(context.Background(), mg); err != nil {
+					t.Fatalf("\n%s\nInitialize(...): %v", tc.reason, err)
+				}
+				if tc.change != nil {
+					tc.change(mg)
+					tc.change = nil
+				}
+			}
+			if calls != tc.calls {
+				t.Errorf("\n%s\nInitialize(...): checked %d times, want %d", tc.reason, calls, tc.calls)
+			}
+			if got := mg.GetCondition(TypeConsoleLocked).Reason; got != ReasonLocked {
+				t.Errorf("\n%s\nInitialize(...): condition reason = %q, want %q", tc.reason, got, ReasonLocked)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	deleted := func(mg *aclv1alpha1.ACL) *aclv1alpha1.ACL {
+		mg.SetDeletionTimestamp(ptr.To(metav1.Now()))
+		return mg
+	}
+	orphan := deleted(newACLResource(nil, "", Finalizer))
+	orphan.SetDeletionPolicy(xpv1.DeletionOrphan)
+
+	type want struct {
+		unlocked         *tsapi.Settings
+		finalizerRemoved bool
+		err              bool
+	}
+	cases := map[string]struct {
+		reason      string
+		mg          *aclv1alpha1.ACL
+		forProvider map[string]any
+		apiErr      error
+		want        want
+	}{
+		"NotDeleted": {
+			reason: "Nothing should happen until the ACL is deleted",
+			mg:     newACLResource(nil, "", Finalizer),
+		},
+		"Unlock": {
+			reason: "The console should be unlocked and the finalizer removed",
+			mg:     deleted(newACLResource(nil, "", Finalizer)),
+			want: want{
+				unlocked:         &tsapi.Settings{ACLsExternallyManagedOn: ptr.To(false), ACLsExternalLink: ptr.To("")},
+				finalizerRemoved: true,
+			},
+		},
+		"ManagedBySettings": {
+			reason:      "The console should stay as the owning Settings resource wants it",
+			mg:          deleted(newACLResource(nil, "", Finalizer)),
+			forProvider: map[string]any{"aclsExternalLink": "https://example.com"},
+			want:        want{finalizerRemoved: true},
+		},
+		"Orphan": {
+			reason: "Orphaned ACLs should leave the tailnet alone",
+			mg:     orphan,
+			want:   want{finalizerRemoved: true},
+		},
+		"APIError": {
+			reason: "API errors should be retried and keep the finalizer",
+			mg:     deleted(newACLResource(nil, "", Finalizer)),
+			apiErr: errors.New("boom"),
+			want: want{
+				unlocked: &tsapi.Settings{ACLsExternallyManagedOn: ptr.To(false), ACLsExternalLink: ptr.To("")},
+				err:      true,
+			},
+		},
+	}
+
+	s := runtime.NewScheme()
+	if err := aclv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			removed := false
+			kube := &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					tc.mg.DeepCopyInto(obj.(*aclv1alpha1.ACL))
+					return nil
+				}),
+				MockList: listSettings(tc.forProvider),
+				MockUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+					removed = !meta.FinalizerExists(obj, Finalizer)
+					return nil
+				},
+			}
+			api := &fakeSettingsClient{err: tc.apiErr}
+			r := &Reconciler{kube: kube, scheme: s, log: logging.NewNopLogger(), record: event.NewNopRecorder(), newClient: api.newClient}
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Name: "acl"}})
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nReconcile(...): unexpected error state: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.unlocked, api.updated); diff != "" {
+				t.Errorf("\n%s\nReconcile(...): -want unlocked, +got unlocked:\n%s", tc.reason, diff)
+			}
+			if removed != tc.want.finalizerRemoved {
+				t.Errorf("\n%s\nReconcile(...): finalizer removed = %t, want %t", tc.reason, removed, tc.want.finalizerRemoved)
+			}
+		})
+	}
+}
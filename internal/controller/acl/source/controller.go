@@ -0,0 +1,133 @@
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/upjet/v2/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const controllerName = "acl-source.acl.tailscale.upbound.io"
+
+// aclGroupVersionKind is the GVK of the ACL managed resource.
+var aclGroupVersionKind = schema.GroupVersionKind{
+	Group:   "acl.tailscale.upbound.io",
+	Version: "v1alpha1",
+	Kind:    "ACL",
+}
+
+func newACL() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(aclGroupVersionKind)
+	return u
+}
+
+func newACLList() *unstructured.UnstructuredList {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(aclGroupVersionKind.GroupVersion().WithKind(aclGroupVersionKind.Kind + "List"))
+	return l
+}
+
+// Setup adds a controller that triggers a reconcile of every ACL whose
+// policy source changed. Only the metadata of ConfigMaps is watched, so that
+// their content is not cached.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	r := &Reconciler{
+		kube: mgr.GetClient(),
+		log:  o.Logger.WithValues("controller", controllerName),
+	}
+	hasSource := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok, _ := ReferenceOf(obj)
+		return ok
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(o.ForControllerRuntime()).
+		For(newACL(), builder.WithPredicates(hasSource)).
+		WatchesMetadata(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.referencing)).
+		Complete(r)
+}
+
+// SetupGated adds the controller; it has no CRD of its own to wait for.
+func SetupGated(mgr ctrl.Manager, o controller.Options) error {
+	return Setup(mgr, o)
+}
+
+// Reconciler records the content hash of an ACL's policy source in an
+// annotation. The annotation change is picked up by the ACL controller,
+// whose Initializer then copies the new policy into the spec.
+type Reconciler struct {
+	kube client.Client
+	log  logging.Logger
+}
+
+// Reconcile an ACL with a policy source.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	acl := newACL()
+	if err := r.kube.Get(ctx, req.NamespacedName, acl); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if acl.GetDeletionTimestamp() != nil {
+		return reconcile.Result{}, nil
+	}
+	ref, ok, err := ReferenceOf(acl)
+	if err != nil || !ok {
+		// The ACL controller reports malformed references.
+		return reconcile.Result{}, nil
+	}
+	c, err := Read(ctx, r.kube, ref)
+	if kerrors.IsNotFound(err) {
+		// We'll be requeued when the source is created.
+		return reconcile.Result{}, nil
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if acl.GetAnnotations()[AnnotationRevision] == c.SHA256 {
+		return reconcile.Result{}, nil
+	}
+
+	r.log.Debug("Policy source changed", "acl", acl.GetName(), "source", ref.String(), "sha256", c.SHA256)
+	patch := client.MergeFrom(acl.DeepCopy())
+	meta := acl.GetAnnotations()
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	meta[AnnotationRevision] = c.SHA256
+	acl.SetAnnotations(meta)
+	if err := r.kube.Patch(ctx, acl, patch); err != nil {
+		return reconcile.Result{}, fmt.Errorf("cannot record policy source revision: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// referencing enqueues the ACLs whose source is the supplied ConfigMap.
+func (r *Reconciler) referencing(ctx context.Context, obj client.Object) []reconcile.Request {
+	l := newACLList()
+	if err := r.kube.List(ctx, l); err != nil {
+		r.log.Info("Cannot list ACLs", "error", err)
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, acl := range l.Items {
+		ref, ok, err := ReferenceOf(&acl)
+		if err != nil || !ok {
+			continue
+		}
+		if ref.Namespace == obj.GetNamespace() && ref.Name == obj.GetName() {
+			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&acl)})
+		}
+	}
+	return reqs
+}
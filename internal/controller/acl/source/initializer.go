@@ -0,0 +1,88 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	ujresource "github.com/crossplane/upjet/v2/pkg/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/aclpolicy"
+)
+
+// paramACL is the Terraform argument holding the policy.
+const paramACL = "acl"
+
+// Initializer copies the policy from the source configured on an ACL into
+// its spec before the ACL is observed.
+type Initializer struct {
+	kube client.Client
+}
+
+// NewInitializer returns an Initializer using the supplied client. Its
+// signature matches config.NewInitializerFn.
+func NewInitializer(kube client.Client) managed.Initializer {
+	return &Initializer{kube: kube}
+}
+
+// Initialize updates spec.forProvider.acl from the configured source. ACLs
+// without a source must set the policy inline. Policies that cannot be
+// parsed are rejected before they reach Tailscale. ACLs being deleted are
+// skipped, their source may already be gone.
+func (i *Initializer) Initialize(ctx context.Context, mg resource.Managed) error {
+	if meta.WasDeleted(mg) {
+		return nil
+	}
+	tr, ok := mg.(ujresource.Terraformed)
+	if !ok {
+		return errors.New("managed resource is not a Terraformed resource")
+	}
+	params, err := tr.GetParameters()
+	if err != nil {
+		return fmt.Errorf("cannot get parameters: %w", err)
+	}
+
+	ref, configured, err := ReferenceOf(mg)
+	if err != nil {
+		mg.SetConditions(Unresolved(ReasonSourceUnavailable, err))
+		return err
+	}
+	if !configured {
+		if params[paramACL] == nil {
+			return errors.New("spec.forProvider.acl is required unless spec.forProvider.aclFrom is set")
+		}
+		return nil
+	}
+
+	c, err := Read(ctx, i.kube, ref)
+	if err != nil {
+		mg.SetConditions(Unresolved(ReasonSourceUnavailable, err))
+		return err
+	}
+	if _, err := aclpolicy.Parse([]byte(c.Policy)); err != nil {
+		err = fmt.Errorf("policy source %s is invalid: %w", ref, err)
+		mg.SetConditions(Unresolved(ReasonInvalidPolicy, err))
+		return err
+	}
+
+	if cur, _ := params[paramACL].(string); cur != c.Policy {
+		params[paramACL] = c.Policy
+		if err := tr.SetParameters(params); err != nil {
+			return fmt.Errorf("cannot set parameters: %w", err)
+		}
+		if err := i.kube.Update(ctx, mg); err != nil {
+			return fmt.Errorf("cannot update ACL with policy from %s: %w", ref, err)
+		}
+	}
+	// Updating the ACL replaced its status with the stored one, so the
+	// status is only set now.
+	if err := SetObserved(mg, c); err != nil {
+		return err
+	}
+	mg.SetConditions(Synced(ref))
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package source keeps the policy of an ACL in sync with a ConfigMap, so
+// that the policy can be managed as a plain file (for example by
+// a kustomize configMapGenerator) instead of inline in the ACL manifest.
+//
+// The source is selected with spec.forProvider.aclFrom:
+//
+//	aclFrom:
+//	  configMapKeyRef:
+//	    namespace: <namespace>
+//	    name: <name>
+//	    key: policy.hujson
+//
+// An Initializer copies the ConfigMap into spec.forProvider.acl before every
+// reconcile of the ACL and reports its resourceVersion and content hash in
+// status.atProvider.aclSource. A companion controller watches the metadata
+// of ConfigMaps and triggers a reconcile of every ACL that refers to one that
+// changed. ConfigMaps are read from the API server when needed rather than
+// cached, see cmd/provider.
+//
+// Secrets are not supported as a source. The policy applied is reported in
+// status.atProvider.acl, so it would be readable by anyone who can read
+// ACLs.
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration, which is compiled before the
+// APIs are generated.
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationRevision records the content hash of the source last seen
+	// by the watching controller. Changing it triggers a reconcile.
+	AnnotationRevision = "acl.tailscale.upbound.io/acl-from-revision"
+
+	// DefaultKey is used when the reference to the source sets no key.
+	DefaultKey = "policy.hujson"
+
+	// TypeSourceResolved reports whether the policy source could be read.
+	TypeSourceResolved xpv1.ConditionType = "SourceResolved"
+
+	// ReasonSourceSynced means the policy was copied from the source.
+	ReasonSourceSynced xpv1.ConditionReason = "Synced"
+	// ReasonSourceUnavailable means the source could not be read.
+	ReasonSourceUnavailable xpv1.ConditionReason = "SourceUnavailable"
+	// ReasonInvalidPolicy means the source does not contain a valid policy.
+	ReasonInvalidPolicy xpv1.ConditionReason = "InvalidPolicy"
+
+	kindConfigMap = "ConfigMap"
+)
+
+// Reference identifies a policy source.
+type Reference struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Key       string
+}
+
+func (r Reference) String() string {
+	return fmt.Sprintf("%s %s/%s key=%s", r.Kind, r.Namespace, r.Name, r.Key)
+}
+
+// Content is the policy read from a source.
+type Content struct {
+	Policy          string
+	ResourceVersion string
+	// SHA256 is the hex encoded hash of Policy.
+	SHA256 string
+}
+
+// ReferenceOf returns the policy source configured in spec.forProvider.aclFrom
+// of the supplied ACL. The boolean is false when no source is configured.
+func ReferenceOf(obj runtime.Object) (Reference, bool, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return Reference{}, false, fmt.Errorf("cannot convert ACL: %w", err)
+	}
+	sel, ok := keyRef(u, "configMapKeyRef")
+	if !ok {
+		return Reference{}, false, nil
+	}
+	ref := Reference{Kind: kindConfigMap}
+	ref.Namespace, ref.Name, ref.Key = sel["namespace"], sel["name"], sel["key"]
+	if ref.Namespace == "" || ref.Name == "" {
+		return Reference{}, true, fmt.Errorf("the %s of spec.forProvider.aclFrom must set a namespace and a name", ref.Kind)
+	}
+	if ref.Key == "" {
+		ref.Key = DefaultKey
+	}
+	return ref, true, nil
+}
+
+// keyRef returns the string fields of the supplied key reference of aclFrom.
+func keyRef(u map[string]any, field string) (map[string]string, bool) {
+	m, ok, _ := unstructured.NestedMap(u, "spec", "forProvider", "aclFrom", field)
+	if !ok {
+		return nil, false
+	}
+	sel := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			sel[k] = s
+		}
+	}
+	return sel, true
+}
+
+// Read returns the policy held by the referenced source.
+func Read(ctx context.Context, kube client.Reader, ref Reference) (Content, error) {
+	if ref.Kind != kindConfigMap {
+		return Content{}, fmt.Errorf("unsupported policy source kind %q", ref.Kind)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := kube.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cm); err != nil {
+		return Content{}, fmt.Errorf("cannot get policy source %s: %w", ref, err)
+	}
+	var data []byte
+	if s, ok := cm.Data[ref.Key]; ok {
+		data = []byte(s)
+	} else if b, ok := cm.BinaryData[ref.Key]; ok {
+		data = b
+	} else {
+		return Content{}, fmt.Errorf("policy source %s has no such key", ref)
+	}
+	sum := sha256.Sum256(data)
+	return Content{Policy: string(data), ResourceVersion: cm.GetResourceVersion(), SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+// SetObserved records the resourceVersion and hash of the supplied content
+// in status.atProvider.aclSource of the ACL.
+func SetObserved(obj runtime.Object, c Content) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return fmt.Errorf("cannot convert ACL: %w", err)
+	}
+	observed := map[string]any{"resourceVersion": c.ResourceVersion, "sha256": c.SHA256}
+	if err := unstructured.SetNestedMap(u, observed, "status", "atProvider", "aclSource"); err != nil {
+		return fmt.Errorf("cannot set status.atProvider.aclSource: %w", err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, obj); err != nil {
+		return fmt.Errorf("cannot convert ACL: %w", err)
+	}
+	return nil
+}
+
+// Synced returns a condition indicating that the policy was copied from the
+// supplied source.
+func Synced(ref Reference) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeSourceResolved,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonSourceSynced,
+		Message:            ref.String(),
+	}
+}
+
+// Unresolved returns a condition indicating that the policy could not be
+// taken from its source.
+func Unresolved(reason xpv1.ConditionReason, err error) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeSourceResolved,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            err.Error(),
+	}
+}
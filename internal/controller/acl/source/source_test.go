@@ -0,0 +1,301 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aclv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/acl/v1alpha1"
+)
+
+const testPolicy = `{"acls": [{"action": "accept", "src": ["*"], "dst": ["*:*"]}]}`
+
+func fromConfigMap(namespace, name, key string) *aclv1alpha1.ACLFromParameters {
+	ref := &aclv1alpha1.ConfigMapKeyRefParameters{Namespace: ptr.To(namespace), Name: ptr.To(name)}
+	if key != "" {
+		ref.Key = ptr.To(key)
+	}
+	return &aclv1alpha1.ACLFromParameters{ConfigMapKeyRef: ref}
+}
+
+func aclWith(from *aclv1alpha1.ACLFromParameters, policy *string) *aclv1alpha1.ACL {
+	acl := &aclv1alpha1.ACL{ObjectMeta: metav1.ObjectMeta{Name: "policy"}}
+	acl.Spec.ForProvider.ACL = policy
+	acl.Spec.ForProvider.ACLFrom = from
+	return acl
+}
+
+func TestReferenceOf(t *testing.T) {
+	type want struct {
+		ref        Reference
+		configured bool
+		err        bool
+	}
+	cases := map[string]struct {
+		reason string
+		from   *aclv1alpha1.ACLFromParameters
+		want   want
+	}{
+		"None": {
+			reason: "An ACL without aclFrom has no source",
+		},
+		"ConfigMapDefaultKey": {
+			reason: "The default key should be used when none is set",
+			from:   fromConfigMap("infra", "tailnet-policy", ""),
+			want: want{
+				ref:        Reference{Kind: kindConfigMap, Namespace: "infra", Name: "tailnet-policy", Key: DefaultKey},
+				configured: true,
+			},
+		},
+		"ConfigMapWithKey": {
+			reason: "An explicit key should be honoured",
+			from:   fromConfigMap("infra", "policy", "acl.json"),
+			want: want{
+				ref:        Reference{Kind: kindConfigMap, Namespace: "infra", Name: "policy", Key: "acl.json"},
+				configured: true,
+			},
+		},
+		"NoNamespace": {
+			reason: "Sources must be namespaced",
+			from:   &aclv1alpha1.ACLFromParameters{ConfigMapKeyRef: &aclv1alpha1.ConfigMapKeyRefParameters{Name: ptr.To("policy")}},
+			want:   want{configured: true, err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ref, configured, err := ReferenceOf(aclWith(tc.from, nil))
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nReferenceOf(...): unexpected error state: %v", tc.reason, err)
+			}
+			if configured != tc.want.configured {
+				t.Errorf("\n%s\nReferenceOf(...): configured = %t, want %t", tc.reason, configured, tc.want.configured)
+			}
+			if diff := cmp.Diff(tc.want.ref, ref); diff != "" {
+				t.Errorf("\n%s\nReferenceOf(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func configMapGetter(data map[string]string) test.MockGetFn {
+	return func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return kerrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "policy")
+		}
+		cm.ResourceVersion = "42"
+		cm.Data = data
+		return nil
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	source := fromConfigMap("infra", "policy", "")
+
+	type want struct {
+		err      string
+		policy   *string
+		updated  bool
+		status   corev1.ConditionStatus
+		observed *aclv1alpha1.ACLSourceObservation
+	}
+	cases := map[string]struct {
+		reason string
+		kube   *test.MockClient
+		acl    *aclv1alpha1.ACL
+		want   want
+	}{
+		"InlinePolicy": {
+			reason: "ACLs without a source should be left alone",
+			kube:   &test.MockClient{},
+			acl:    aclWith(nil, ptr.To(testPolicy)),
+			want:   want{policy: ptr.To(testPolicy)},
+		},
+		"NoPolicy": {
+			reason: "ACLs without a source must set the policy inline",
+			kube:   &test.MockClient{},
+			acl:    aclWith(nil, nil),
+			want:   want{err: "spec.forProvider.acl is required"},
+		},
+		"CopyFromConfigMap": {
+			reason: "The policy should be copied from the source and the ACL updated",
+			kube: &test.MockClient{
+				MockGet:    configMapGetter(map[string]string{DefaultKey: testPolicy}),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			},
+			acl: aclWith(source, ptr.To("{}")),
+			want: want{
+				policy:   ptr.To(testPolicy),
+				updated:  true,
+				status:   corev1.ConditionTrue,
+				observed: &aclv1alpha1.ACLSourceObservation{ResourceVersion: ptr.To("42"), Sha256: ptr.To(sha256Hex(testPolicy))},
+			},
+		},
+		"UpToDate": {
+			reason: "The ACL should not be updated when the policy is current",
+			kube: &test.MockClient{
+				MockGet: configMapGetter(map[string]string{DefaultKey: testPolicy}),
+			},
+			acl: aclWith(source, ptr.To(testPolicy)),
+			want: want{
+				policy:   ptr.To(testPolicy),
+				status:   corev1.ConditionTrue,
+				observed: &aclv1alpha1.ACLSourceObservation{ResourceVersion: ptr.To("42"), Sha256: ptr.To(sha256Hex(testPolicy))},
+			},
+		},
+		"Deleted": {
+			reason: "ACLs being deleted should be skipped, their source may be gone",
+			kube:   &test.MockClient{},
+			acl: func() *aclv1alpha1.ACL {
+				acl := aclWith(source, ptr.To(testPolicy))
+				acl.SetDeletionTimestamp(ptr.To(metav1.Now()))
+				return acl
+			}(),
+			want: want{policy: ptr.To(testPolicy)},
+		},
+		"MissingKey": {
+			reason: "A source without the key should be reported",
+			kube: &test.MockClient{
+				MockGet: configMapGetter(map[string]string{"other": testPolicy}),
+			},
+			acl:  aclWith(source, nil),
+			want: want{err: "has no such key", status: corev1.ConditionFalse},
+		},
+		"InvalidPolicy": {
+			reason: "Invalid policies should not be copied",
+			kube: &test.MockClient{
+				MockGet: configMapGetter(map[string]string{DefaultKey: `{"acls": [`}),
+			},
+			acl:  aclWith(source, ptr.To(testPolicy)),
+			want: want{err: "is invalid", policy: ptr.To(testPolicy), status: corev1.ConditionFalse},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			updated := false
+			if tc.kube.MockUpdate != nil {
+				fn := tc.kube.MockUpdate
+				tc.kube.MockUpdate = func(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+					updated = true
+					return fn(ctx, obj, opts...)
+				}
+			}
+			err := NewInitializer(tc.kube).Initialize(context.Background(), tc.acl)
+			switch {
+			case tc.want.err == "" && err != nil:
+				t.Errorf("\n%s\nInitialize(...): unexpected error: %v", tc.reason, err)
+			case tc.want.err != "" && (err == nil || !strings.Contains(err.Error(), tc.want.err)):
+				t.Errorf("\n%s\nInitialize(...): error should contain %q, got %v", tc.reason, tc.want.err, err)
+			}
+			if diff := cmp.Diff(tc.want.policy, tc.acl.Spec.ForProvider.ACL); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want policy, +got policy:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.observed, tc.acl.Status.AtProvider.ACLSource); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want status.atProvider.aclSource, +got:\n%s", tc.reason, diff)
+			}
+			if updated != tc.want.updated {
+				t.Errorf("\n%s\nInitialize(...): updated = %t, want %t", tc.reason, updated, tc.want.updated)
+			}
+			c := tc.acl.GetCondition(TypeSourceResolved)
+			if tc.want.status == "" {
+				tc.want.status = corev1.ConditionUnknown
+			}
+			if c.Status != tc.want.status {
+				t.Errorf("\n%s\nInitialize(...): SourceResolved = %s, want %s", tc.reason, c.Status, tc.want.status)
+			}
+		})
+	}
+}
+
+func TestSyncedMessage(t *testing.T) {
+	ref := Reference{Kind: kindConfigMap, Namespace: "infra", Name: "policy", Key: DefaultKey}
+	c := Synced(ref)
+	want := "ConfigMap infra/policy key=policy.hujson"
+	if c.Message != want {
+		t.Errorf("Synced(...).Message = %q, want %q", c.Message, want)
+	}
+	if c.Reason != ReasonSourceSynced || c.Type != xpv1.ConditionType("SourceResolved") {
+		t.Errorf("Synced(...) = %s/%s, want SourceResolved/Synced", c.Type, c.Reason)
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestReconcile(t *testing.T) {
+	type want struct {
+		revision string
+		err      bool
+	}
+	cases := map[string]struct {
+		reason     string
+		annotation string
+		source     test.MockGetFn
+		want       want
+	}{
+		"RecordRevision": {
+			reason: "A changed source should be recorded on the ACL to trigger a reconcile",
+			source: configMapGetter(map[string]string{DefaultKey: testPolicy}),
+			want:   want{revision: sha256Hex(testPolicy)},
+		},
+		"UpToDate": {
+			reason:     "An unchanged source should not be recorded again",
+			annotation: sha256Hex(testPolicy),
+			source:     configMapGetter(map[string]string{DefaultKey: testPolicy}),
+		},
+		"SourceMissing": {
+			reason: "A missing source should be ignored until it is created",
+			source: func(_ context.Context, _ client.ObjectKey, _ client.Object) error {
+				return kerrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "policy")
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var patched string
+			kube := &test.MockClient{
+				MockGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+					if u, ok := obj.(*unstructured.Unstructured); ok {
+						u.SetName("policy")
+						u.SetAnnotations(map[string]string{AnnotationRevision: tc.annotation})
+						return unstructured.SetNestedStringMap(u.Object, map[string]string{"namespace": "infra", "name": "policy"},
+							"spec", "forProvider", "aclFrom", "configMapKeyRef")
+					}
+					return tc.source(ctx, key, obj)
+				},
+				MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+					patched = obj.GetAnnotations()[AnnotationRevision]
+					return nil
+				},
+			}
+			r := &Reconciler{kube: kube, log: logging.NewNopLogger()}
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Name: "policy"}})
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nReconcile(...): unexpected error state: %v", tc.reason, err)
+			}
+			if patched != tc.want.revision {
+				t.Errorf("\n%s\nReconcile(...): revision = %q, want %q", tc.reason, patched, tc.want.revision)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Package approval approves devices waiting for approval according to the
+// rules of DeviceApprovalPolicy resources.
+//
+// With device approval turned on, new devices can't use the tailnet until
+// they are approved. A DeviceApprovalPolicy lists the devices of its tailnet
+// every poll interval and approves each pending device matching one of its
+// rules, by tags, owning user, hostname and posture attributes. Devices that
+// match no rule of any policy of the tailnet can be denied (removed) or
+// expired once they have waited longer than a window. Every decision is
+// recorded in the status of the policy and emitted as an event.
+package approval
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+// maxDecisions is the number of decisions kept in the status.
+const maxDecisions = 50
+
+// rule is an ApprovalRule with its hostname expression compiled.
+type rule struct {
+	v1alpha1.ApprovalRule
+	hostname *regexp.Regexp
+}
+
+// compile compiles the supplied rules.
+func compile(rules []v1alpha1.ApprovalRule) ([]rule, error) {
+	out := make([]rule, len(rules))
+	for i, r := range rules {
+		out[i].ApprovalRule = r
+		if r.HostnameRegex == "" {
+			continue
+		}
+		re, err := regexp.Compile(r.HostnameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d] (%s): invalid hostnameRegex: %w", i, r.Name, err)
+		}
+		out[i].hostname = re
+	}
+	return out, nil
+}
+
+// needsAttributes reports whether any rule matches posture attributes.
+func needsAttributes(rules []rule) bool {
+	return slices.ContainsFunc(rules, func(r rule) bool { return len(r.PostureAttributes) > 0 })
+}
+
+// match reports whether the device matches the rule, and why.
+func (r rule) match(d tsapi.Device, attrs map[string]any) (bool, string) {
+	var why []string
+	for _, t := range r.Tags {
+		if !slices.Contains(d.Tags, t) {
+			return false, ""
+		}
+	}
+	if len(r.Tags) > 0 {
+		why = append(why, "tags "+strings.Join(r.Tags, ","))
+	}
+	if r.User != "" {
+		if !strings.EqualFold(r.User, d.User) {
+			return false, ""
+		}
+		why = append(why, "user "+d.User)
+	}
+	if r.hostname != nil {
+		if !r.hostname.MatchString(d.Hostname) {
+			return false, ""
+		}
+		why = append(why, fmt.Sprintf("hostname %s matches %s", d.Hostname, r.HostnameRegex))
+	}
+	for _, k := range slices.Sorted(maps.Keys(r.PostureAttributes)) {
+		v, ok := attrs[k]
+		if !ok || fmt.Sprint(v) != r.PostureAttributes[k] {
+			return false, ""
+		}
+		why = append(why, fmt.Sprintf("%s=%s", k, r.PostureAttributes[k]))
+	}
+	if len(why) == 0 {
+		why = append(why, "rule matches every device")
+	}
+	return true, strings.Join(why, ", ")
+}
+
+// evaluate returns the first rule matching the device and why it matched.
+func evaluate(rules []rule, d tsapi.Device, attrs map[string]any) (name, reason string, ok bool) {
+	for _, r := range rules {
+		if ok, why := r.match(d, attrs); ok {
+			return r.Name, why, true
+		}
+	}
+	return "", "", false
+}
+
+// record adds a decision to the status, keeping the most recent ones.
+func record(s *v1alpha1.DeviceApprovalPolicyStatus, d v1alpha1.DeviceDecision) {
+	s.Decisions = append([]v1alpha1.DeviceDecision{d}, s.Decisions...)
+	if len(s.Decisions) > maxDecisions {
+		s.Decisions = s.Decisions[:maxDecisions]
+	}
+}
@@ -0,0 +1,202 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+var now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+type fakeAPI struct {
+	devices    []tsapi.Device
+	attributes map[string]map[string]any
+	err        error
+
+	actions []string
+}
+
+func (f *fakeAPI) ListDevices(context.Context) ([]tsapi.Device, error) { return f.devices, nil }
+
+func (f *fakeAPI) GetDeviceAttributes(_ context.Context, id string) (map[string]any, error) {
+	return f.attributes[id], nil
+}
+
+func (f *fakeAPI) AuthorizeDevice(_ context.Context, id string) error {
+	f.actions = append(f.actions, "authorize "+id)
+	return f.err
+}
+
+func (f *fakeAPI) ExpireDevice(_ context.Context, id string) error {
+	f.actions = append(f.actions, "expire "+id)
+	return f.err
+}
+
+func (f *fakeAPI) DeleteDevice(_ context.Context, id string) error {
+	f.actions = append(f.actions, "delete "+id)
+	return f.err
+}
+
+func (f *fakeAPI) newClient(context.Context, client.Client, *v1alpha1.DeviceApprovalPolicy) (devicesClient, error) {
+	return f, nil
+}
+
+func TestMatch(t *testing.T) {
+	d := tsapi.Device{Name: "exit-1.example.ts.net", Hostname: "exit-1", User: "ops@example.com", Tags: []string{"tag:exit"}}
+	attrs := map[string]any{"node:os": "linux", "custom:compliant": true}
+	cases := map[string]struct {
+		rule v1alpha1.ApprovalRule
+		want bool
+		why  string
+	}{
+		"Empty": {want: true, why: "rule matches every device"},
+		"Everything": {
+			rule: v1alpha1.ApprovalRule{
+				Tags:              []string{"tag:exit"},
+				User:              "OPS@example.com",
+				HostnameRegex:     "^exit-[0-9]+$",
+				PostureAttributes: map[string]string{"node:os": "linux", "custom:compliant": "true"},
+			},
+			want: true,
+			why:  "tags tag:exit, user ops@example.com, hostname exit-1 matches ^exit-[0-9]+$, custom:compliant=true, node:os=linux",
+		},
+		"MissingTag":  {rule: v1alpha1.ApprovalRule{Tags: []string{"tag:db"}}},
+		"OtherUser":   {rule: v1alpha1.ApprovalRule{User: "dev@example.com"}},
+		"OtherHost":   {rule: v1alpha1.ApprovalRule{HostnameRegex: "^db-"}},
+		"BadPosture":  {rule: v1alpha1.ApprovalRule{PostureAttributes: map[string]string{"node:os": "windows"}}},
+		"NoAttribute": {rule: v1alpha1.ApprovalRule{PostureAttributes: map[string]string{"custom:team": "ops"}}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rules, err := compile([]v1alpha1.ApprovalRule{tc.rule})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, why := rules[0].match(d, attrs)
+			if got != tc.want || why != tc.why {
+				t.Errorf("match(...) = %t, %q, want %t, %q", got, why, tc.want, tc.why)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	pending := func(id string, age time.Duration, tags ...string) tsapi.Device {
+		return tsapi.Device{ID: id, Name: "device-" + id, Hostname: "device-" + id, Tags: tags, Created: now.Add(-age)}
+	}
+	policy := func(nm *v1alpha1.NonMatchingPolicy) *v1alpha1.DeviceApprovalPolicy {
+		return &v1alpha1.DeviceApprovalPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Spec: v1alpha1.DeviceApprovalPolicySpec{
+				Rules:       []v1alpha1.ApprovalRule{{Name: "servers", Tags: []string{"tag:server"}}},
+				NonMatching: nm,
+			},
+		}
+	}
+
+	type want struct {
+		actions   []string
+		decisions []v1alpha1.ApprovalDecision
+		pending   int
+		err       bool
+	}
+	cases := map[string]struct {
+		reason  string
+		policy  *v1alpha1.DeviceApprovalPolicy
+		others  []v1alpha1.DeviceApprovalPolicy
+		devices []tsapi.Device
+		apiErr  error
+		want    want
+	}{
+		"Approve": {
+			reason: "Matching pending devices should be approved, others left alone",
+			policy: policy(nil),
+			devices: []tsapi.Device{
+				pending("1", time.Hour, "tag:server"),
+				pending("2", 48*time.Hour),
+				{ID: "3", Tags: []string{"tag:server"}, Authorized: true},
+			},
+			want: want{actions: []string{"authorize 1"}, decisions: []v1alpha1.ApprovalDecision{v1alpha1.DecisionApproved}, pending: 1},
+		},
+		"DenyAfterWindow": {
+			reason:  "Non-matching devices should be denied once the window has passed",
+			policy:  policy(&v1alpha1.NonMatchingPolicy{Action: v1alpha1.NonMatchingDeny, Window: metav1.Duration{Duration: 24 * time.Hour}}),
+			devices: []tsapi.Device{pending("1", time.Hour), pending("2", 48*time.Hour)},
+			want:    want{actions: []string{"delete 2"}, decisions: []v1alpha1.ApprovalDecision{v1alpha1.DecisionDenied}, pending: 1},
+		},
+		"Expire": {
+			reason: "Non-matching devices should be expired once, not again",
+			policy: policy(&v1alpha1.NonMatchingPolicy{Action: v1alpha1.NonMatchingExpire}),
+			devices: []tsapi.Device{
+				pending("1", time.Hour),
+				func() tsapi.Device { d := pending("2", time.Hour); d.Expires = now.Add(-time.Minute); return d }(),
+			},
+			want: want{actions: []string{"expire 1"}, decisions: []v1alpha1.ApprovalDecision{v1alpha1.DecisionExpired}, pending: 1},
+		},
+		"ApprovedElsewhere": {
+			reason: "Devices approved by another policy of the tailnet should not be denied",
+			policy: policy(&v1alpha1.NonMatchingPolicy{Action: v1alpha1.NonMatchingDeny}),
+			others: []v1alpha1.DeviceApprovalPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "laptops"},
+				Spec:       v1alpha1.DeviceApprovalPolicySpec{Rules: []v1alpha1.ApprovalRule{{Name: "laptops", Tags: []string{"tag:laptop"}}}},
+			}},
+			devices: []tsapi.Device{pending("1", 48*time.Hour, "tag:laptop")},
+			want:    want{pending: 1},
+		},
+		"APIError": {
+			reason:  "Errors approving a device should be returned",
+			policy:  policy(nil),
+			devices: []tsapi.Device{pending("1", time.Hour, "tag:server")},
+			apiErr:  errors.New("boom"),
+			want:    want{actions: []string{"authorize 1"}, err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := tc.policy
+			kube := &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					p.DeepCopyInto(obj.(*v1alpha1.DeviceApprovalPolicy))
+					return nil
+				}),
+				MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+					obj.(*v1alpha1.DeviceApprovalPolicyList).Items = append([]v1alpha1.DeviceApprovalPolicy{*p.DeepCopy()}, tc.others...)
+					return nil
+				}),
+				MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+					obj.(*v1alpha1.DeviceApprovalPolicy).DeepCopyInto(p)
+					return nil
+				},
+			}
+			api := &fakeAPI{devices: tc.devices, err: tc.apiErr}
+			r := &Reconciler{kube: kube, log: logging.NewNopLogger(), record: event.NewNopRecorder(), newClient: api.newClient, now: func() time.Time { return now }}
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Name: "policy"}})
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nReconcile(...): unexpected error state: %v", tc.reason, err)
+			}
+			got := want{actions: api.actions, pending: p.Status.PendingDevices, err: err != nil}
+			for _, d := range p.Status.Decisions {
+				got.decisions = append(got.decisions, d.Decision)
+			}
+			if tc.want.err {
+				got.pending = tc.want.pending
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nReconcile(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/upjet/v2/pkg/controller"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+const (
+	controllerName = "deviceapprovalpolicy.tailscale.upbound.io"
+
+	reasonApproved     = "ApprovedDevice"
+	reasonDenied       = "DeniedDevice"
+	reasonExpired      = "ExpiredDevice"
+	reasonCannotDecide = "CannotDecideDevice"
+)
+
+// devicesClient is the part of the Tailscale API used by this package.
+type devicesClient interface {
+	ListDevices(ctx context.Context) ([]tsapi.Device, error)
+	GetDeviceAttributes(ctx context.Context, id string) (map[string]any, error)
+	AuthorizeDevice(ctx context.Context, id string) error
+	ExpireDevice(ctx context.Context, id string) error
+	DeleteDevice(ctx context.Context, id string) error
+}
+
+// newClientFn returns an API client for the tailnet of a policy.
+type newClientFn func(ctx context.Context, kube client.Client, p *v1alpha1.DeviceApprovalPolicy) (devicesClient, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, p *v1alpha1.DeviceApprovalPolicy) (devicesClient, error) {
+	return tsapi.NewForProviderConfig(ctx, kube, providerConfigName(p))
+}
+
+func providerConfigName(p *v1alpha1.DeviceApprovalPolicy) string {
+	if p.Spec.ProviderConfigReference == nil {
+		return "default"
+	}
+	return p.Spec.ProviderConfigReference.Name
+}
+
+// Setup adds a controller that reconciles DeviceApprovalPolicies.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	r := &Reconciler{
+		kube:      mgr.GetClient(),
+		log:       o.Logger.WithValues("controller", controllerName),
+		record:    event.NewAPIRecorder(mgr.GetEventRecorderFor(controllerName)),
+		newClient: newAPIClient,
+		poll:      o.PollInterval,
+		now:       time.Now,
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(o.ForControllerRuntime()).
+		For(&v1alpha1.DeviceApprovalPolicy{}).
+		Complete(r)
+}
+
+// SetupGated adds the controller; the DeviceApprovalPolicy CRD is part of
+// the package.
+func SetupGated(mgr ctrl.Manager, o controller.Options) error {
+	return Setup(mgr, o)
+}
+
+// Reconciler decides on the devices waiting for approval.
+type Reconciler struct {
+	kube      client.Client
+	log       logging.Logger
+	record    event.Recorder
+	newClient newClientFn
+	poll      time.Duration
+	now       func() time.Time
+}
+
+// Reconcile a DeviceApprovalPolicy. New devices appear without any
+// Kubernetes event, so policies are requeued every poll interval.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	p := &v1alpha1.DeviceApprovalPolicy{}
+	if err := r.kube.Get(ctx, req.NamespacedName, p); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if meta.WasDeleted(p) {
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.decide(ctx, p); err != nil {
+		return reconcile.Result{}, r.fail(ctx, p, err)
+	}
+	p.SetConditions(xpv1.Available(), xpv1.ReconcileSuccess())
+	if err := r.kube.Status().Update(ctx, p); err != nil {
+		return reconcile.Result{}, fmt.Errorf("cannot update policy status: %w", err)
+	}
+	return reconcile.Result{RequeueAfter: r.poll}, nil
+}
+
+func (r *Reconciler) decide(ctx context.Context, p *v1alpha1.DeviceApprovalPolicy) error {
+	rules, err := compile(p.Spec.Rules)
+	if err != nil {
+		return err
+	}
+	others, err := r.otherRules(ctx, p)
+	if err != nil {
+		return err
+	}
+	c, err := r.newClient(ctx, r.kube, p)
+	if err != nil {
+		return err
+	}
+	devices, err := c.ListDevices(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list devices: %w", err)
+	}
+
+	pending := 0
+	for _, d := range devices {
+		if d.Authorized {
+			continue
+		}
+		var attrs map[string]any
+		if needsAttributes(rules) || needsAttributes(others) {
+			if attrs, err = c.GetDeviceAttributes(ctx, d.ID); err != nil {
+				return fmt.Errorf("cannot get posture attributes of device %s: %w", d.Name, err)
+			}
+		}
+
+		if name, why, ok := evaluate(rules, d, attrs); ok {
+			if err := c.AuthorizeDevice(ctx, d.ID); err != nil {
+				r.record.Event(p, event.Warning(reasonCannotDecide, fmt.Errorf("cannot approve device %s: %w", d.Name, err)))
+				return fmt.Errorf("cannot approve device %s: %w", d.Name, err)
+			}
+			r.decided(p, d, v1alpha1.DecisionApproved, name, fmt.Sprintf("matches rule %s: %s", name, why), reasonApproved)
+			continue
+		}
+		pending++
+		// Another policy of the tailnet approves it.
+		if _, _, ok := evaluate(others, d, attrs); ok {
+			continue
+		}
+		decided, err := r.nonMatching(ctx, c, p, d)
+		if err != nil {
+			r.record.Event(p, event.Warning(reasonCannotDecide, err))
+			return err
+		}
+		if decided {
+			pending--
+		}
+	}
+	p.Status.PendingDevices = pending
+	return nil
+}
+
+// nonMatching takes the configured action on a device that matches no rule
+// once its window has passed, reporting whether it did.
+func (r *Reconciler) nonMatching(ctx context.Context, c devicesClient, p *v1alpha1.DeviceApprovalPolicy, d tsapi.Device) (bool, error) {
+	nm := p.Spec.NonMatching
+	if nm == nil || nm.Action == "" || nm.Action == v1alpha1.NonMatchingIgnore {
+		return false, nil
+	}
+	now := r.now()
+	if now.Sub(d.Created) < nm.Window.Duration {
+		return false, nil
+	}
+	why := fmt.Sprintf("matches no rule and has waited for approval longer than %s", nm.Window.Duration)
+	switch nm.Action {
+	case v1alpha1.NonMatchingDeny:
+		if err := c.DeleteDevice(ctx, d.ID); err != nil && !tsapi.IsNotFound(err) {
+			return false, fmt.Errorf("cannot deny device %s: %w", d.Name, err)
+		}
+		r.decided(p, d, v1alpha1.DecisionDenied, "", why, reasonDenied)
+	case v1alpha1.NonMatchingExpire:
+		if !d.Expires.IsZero() && !d.Expires.After(now) {
+			// Already expired; it stays pending until it logs in again.
+			return false, nil
+		}
+		if err := c.ExpireDevice(ctx, d.ID); err != nil {
+			return false, fmt.Errorf("cannot expire device %s: %w", d.Name, err)
+		}
+		r.decided(p, d, v1alpha1.DecisionExpired, "", why, reasonExpired)
+	default:
+		return false, fmt.Errorf("unknown nonMatching action %q", nm.Action)
+	}
+	return true, nil
+}
+
+// otherRules returns the rules of the other policies of the same tailnet.
+// Policies whose rules don't compile report their own error.
+func (r *Reconciler) otherRules(ctx context.Context, p *v1alpha1.DeviceApprovalPolicy) ([]rule, error) {
+	l := &v1alpha1.DeviceApprovalPolicyList{}
+	if err := r.kube.List(ctx, l); err != nil {
+		return nil, fmt.Errorf("cannot list device approval policies: %w", err)
+	}
+	var out []rule
+	for i := range l.Items {
+		o := &l.Items[i]
+		if o.GetName() == p.GetName() || meta.WasDeleted(o) || providerConfigName(o) != providerConfigName(p) {
+			continue
+		}
+		rules, err := compile(o.Spec.Rules)
+		if err != nil {
+			continue
+		}
+		out = append(out, rules...)
+	}
+	return out, nil
+}
+
+func (r *Reconciler) decided(p *v1alpha1.DeviceApprovalPolicy, d tsapi.Device, decision v1alpha1.ApprovalDecision, ruleName, why string, reason event.Reason) {
+	record(&p.Status, v1alpha1.DeviceDecision{
+		ID:       d.ID,
+		Name:     d.Name,
+		Decision: decision,
+		Rule:     ruleName,
+		Reason:   why,
+		Time:     metav1.NewTime(r.now()),
+	})
+	r.record.Event(p, event.Normal(reason, fmt.Sprintf("%s device %s: %s", decision, d.Name, why)))
+	r.log.Debug("Decided on device", "policy", p.GetName(), "device", d.Name, "decision", decision, "reason", why)
+}
+
+func (r *Reconciler) fail(ctx context.Context, p *v1alpha1.DeviceApprovalPolicy, err error) error {
+	p.SetConditions(xpv1.ReconcileError(err))
+	if uerr := r.kube.Status().Update(ctx, p); uerr != nil {
+		r.log.Debug("Cannot update policy status", "error", uerr)
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	ujresource "github.com/crossplane/upjet/v2/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// paramRoutes is the Terraform argument holding the routes.
+const paramRoutes = "routes"
+
+// subnetRoutesListGroupVersionKind is the GVK of a list of SubnetRoutes.
+var subnetRoutesListGroupVersionKind = schema.GroupVersionKind{
+	Group:   "device.tailscale.upbound.io",
+	Version: "v1alpha1",
+	Kind:    "SubnetRoutesList",
+}
+
+// Initializer normalises the routes of a SubnetRoutes and reports whether
+// they overlap those of any other SubnetRoutes.
+type Initializer struct {
+	kube client.Client
+}
+
+// NewInitializer returns an Initializer using the supplied client. Its
+// signature matches config.NewInitializerFn.
+func NewInitializer(kube client.Client) managed.Initializer {
+	return &Initializer{kube: kube}
+}
+
+// Initialize normalises spec.forProvider.routes and sets the RoutesOverlap
+// condition. SubnetRoutes being deleted are skipped, so that routes that are
+// invalid or overlapping by now cannot hold up their deletion.
+func (i *Initializer) Initialize(ctx context.Context, mg resource.Managed) error {
+	if meta.WasDeleted(mg) {
+		return nil
+	}
+	tr, ok := mg.(ujresource.Terraformed)
+	if !ok {
+		return errors.New("managed resource is not a Terraformed resource")
+	}
+	params, err := tr.GetParameters()
+	if err != nil {
+		return fmt.Errorf("cannot get parameters: %w", err)
+	}
+	cur, err := toStrings(params[paramRoutes])
+	if err != nil {
+		return err
+	}
+	prefixes, err := Normalize(cur)
+	if err != nil {
+		return fmt.Errorf("spec.forProvider.routes: %w", err)
+	}
+
+	if want := toStringSlice(prefixes); cur != nil && !slices.Equal(cur, want) {
+		params[paramRoutes] = toAnySlice(want)
+		if err := tr.SetParameters(params); err != nil {
+			return fmt.Errorf("cannot set parameters: %w", err)
+		}
+		if err := i.kube.Update(ctx, mg); err != nil {
+			return fmt.Errorf("cannot update normalised routes: %w", err)
+		}
+	}
+
+	others, err := i.routers(ctx)
+	if err != nil {
+		return err
+	}
+	r := Router{Name: mg.GetName(), HAGroup: mg.GetLabels()[LabelHAGroup], Prefixes: prefixes}
+	if o := Overlaps(r, others); len(o) > 0 {
+		mg.SetConditions(Overlapping(o))
+		return nil
+	}
+	mg.SetConditions(NoOverlap())
+	return nil
+}
+
+// routers returns the routers of all SubnetRoutes that are not being
+// deleted. Resources with invalid routes are skipped; they report their own
+// error.
+func (i *Initializer) routers(ctx context.Context) ([]Router, error) {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(subnetRoutesListGroupVersionKind)
+	if err := i.kube.List(ctx, l); err != nil {
+		return nil, fmt.Errorf("cannot list SubnetRoutes: %w", err)
+	}
+	out := make([]Router, 0, len(l.Items))
+	for _, u := range l.Items {
+		if u.GetDeletionTimestamp() != nil {
+			continue
+		}
+		routes, _, _ := unstructured.NestedStringSlice(u.Object, "spec", "forProvider", paramRoutes)
+		prefixes, err := Normalize(routes)
+		if err != nil {
+			continue
+		}
+		out = append(out, Router{Name: u.GetName(), HAGroup: u.GetLabels()[LabelHAGroup], Prefixes: prefixes})
+	}
+	return out, nil
+}
+
+func toStrings(v any) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	l, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("spec.forProvider.routes must be a list, got %T", v)
+	}
+	out := make([]string, 0, len(l))
+	for _, e := range l {
+		s, ok := e.(string)
+		if !ok {
+			return nil, fmt.Errorf("spec.forProvider.routes must be a list of strings, got %T", e)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func toStringSlice(p []netip.Prefix) []string {
+	out := make([]string, len(p))
+	for i := range p {
+		out[i] = p[i].String()
+	}
+	return out
+}
+
+func toAnySlice(s []string) []any {
+	out := make([]any, len(s))
+	for i := range s {
+		out[i] = s[i]
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+// Package routes normalises the routes of SubnetRoutes resources and detects
+// prefixes approved on more than one device.
+//
+// Routes are parsed with net/netip and written back in canonical form, so
+// that "2001:DB8::/32" and "2001:db8::/32" are the same route. Malformed
+// prefixes and prefixes with host bits set are rejected by the CRD and again
+// here.
+//
+// Two routers approved for overlapping prefixes are usually a mistake, so
+// every SubnetRoutes whose routes overlap those of another one gets a
+// RoutesOverlap condition. Routers that are meant to overlap, like a high
+// availability pair, are marked with the same value of the
+// device.tailscale.upbound.io/ha-group label and are not reported.
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration.
+package routes
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LabelHAGroup marks SubnetRoutes resources whose routes are meant to
+// overlap. Resources with the same value are not reported.
+const LabelHAGroup = "device.tailscale.upbound.io/ha-group"
+
+// TypeRoutesOverlap reports whether the routes of a SubnetRoutes overlap
+// those of another one.
+const TypeRoutesOverlap xpv1.ConditionType = "RoutesOverlap"
+
+// Reasons of the RoutesOverlap condition.
+const (
+	ReasonOverlapping xpv1.ConditionReason = "OverlappingRoutes"
+	ReasonNoOverlap   xpv1.ConditionReason = "NoOverlap"
+)
+
+// Normalize parses the supplied routes and returns them in canonical form,
+// sorted and without duplicates. Prefixes with host bits set are rejected
+// rather than masked, since they usually mean the wrong prefix length.
+func Normalize(routes []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(routes))
+	for _, r := range routes {
+		p, err := netip.ParsePrefix(strings.TrimSpace(r))
+		if err != nil {
+			return nil, fmt.Errorf("route %q is not a CIDR prefix: %w", r, err)
+		}
+		if m := p.Masked(); m != p {
+			return nil, fmt.Errorf("route %q has host bits set, did you mean %s?", r, m)
+		}
+		out = append(out, p)
+	}
+	slices.SortFunc(out, comparePrefixes)
+	return slices.Compact(out), nil
+}
+
+func comparePrefixes(a, b netip.Prefix) int {
+	if c := a.Addr().Compare(b.Addr()); c != 0 {
+		return c
+	}
+	return a.Bits() - b.Bits()
+}
+
+// Router is a SubnetRoutes resource and its approved prefixes.
+type Router struct {
+	Name     string
+	HAGroup  string
+	Prefixes []netip.Prefix
+}
+
+// Overlap is a prefix of one router overlapping a prefix of another.
+type Overlap struct {
+	Prefix  netip.Prefix
+	Other   string
+	OtherOf netip.Prefix
+}
+
+func (o Overlap) String() string {
+	if o.Prefix == o.OtherOf {
+		return fmt.Sprintf("%s is also approved on %s", o.Prefix, o.Other)
+	}
+	return fmt.Sprintf("%s overlaps %s approved on %s", o.Prefix, o.OtherOf, o.Other)
+}
+
+// Overlaps returns the prefixes of r overlapping those of the other routers,
+// leaving out routers of the same HA group. Exit node routes (0.0.0.0/0 and
+// ::/0) are approved per device and never overlap.
+func Overlaps(r Router, others []Router) []Overlap {
+	var out []Overlap
+	for _, o := range others {
+		if o.Name == r.Name || (r.HAGroup != "" && r.HAGroup == o.HAGroup) {
+			continue
+		}
+		for _, p := range r.Prefixes {
+			if p.Bits() == 0 {
+				continue
+			}
+			for _, q := range o.Prefixes {
+				if q.Bits() != 0 && p.Overlaps(q) {
+					out = append(out, Overlap{Prefix: p, Other: o.Name, OtherOf: q})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Overlapping returns a condition reporting the supplied overlaps.
+func Overlapping(o []Overlap) xpv1.Condition {
+	msgs := make([]string, len(o))
+	for i := range o {
+		msgs[i] = o[i].String()
+	}
+	return xpv1.Condition{
+		Type:               TypeRoutesOverlap,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonOverlapping,
+		Message:            strings.Join(msgs, "; ") + fmt.Sprintf(". Label the routers with the same %s if this is intended.", LabelHAGroup),
+	}
+}
+
+// NoOverlap returns a condition indicating the routes overlap no others.
+func NoOverlap() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeRoutesOverlap,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonNoOverlap,
+	}
+}
@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	devicev1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/device/v1alpha1"
+)
+
+func prefixes(s ...string) []netip.Prefix {
+	out := make([]netip.Prefix, len(s))
+	for i := range s {
+		out[i] = netip.MustParsePrefix(s[i])
+	}
+	return out
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]struct {
+		routes []string
+		want   []netip.Prefix
+		err    bool
+	}{
+		"Empty": {want: []netip.Prefix{}},
+		"Canonical": {
+			routes: []string{"2001:DB8::/32", " 10.0.0.0/24", "10.0.0.0/24", "0.0.0.0/0"},
+			want:   prefixes("0.0.0.0/0", "10.0.0.0/24", "2001:db8::/32"),
+		},
+		"NotAPrefix": {routes: []string{"10.0.0.1"}, err: true},
+		"HostBits":   {routes: []string{"10.0.0.1/24"}, err: true},
+		"Garbage":    {routes: []string{"office"}, err: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Normalize(tc.routes)
+			if (err != nil) != tc.err {
+				t.Errorf("Normalize(...): unexpected error state: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.Comparer(func(a, b netip.Prefix) bool { return a == b })); diff != "" {
+				t.Errorf("Normalize(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	r := Router{Name: "a", Prefixes: prefixes("10.0.0.0/24", "0.0.0.0/0")}
+	cases := map[string]struct {
+		reason string
+		r      Router
+		others []Router
+		want   []Overlap
+	}{
+		"Disjoint": {
+			reason: "Disjoint prefixes don't overlap",
+			r:      r,
+			others: []Router{r, {Name: "b", Prefixes: prefixes("10.0.1.0/24")}},
+		},
+		"Contained": {
+			reason: "A prefix inside another one overlaps it",
+			r:      r,
+			others: []Router{{Name: "b", Prefixes: prefixes("10.0.0.0/16")}},
+			want:   []Overlap{{Prefix: netip.MustParsePrefix("10.0.0.0/24"), Other: "b", OtherOf: netip.MustParsePrefix("10.0.0.0/16")}},
+		},
+		"ExitNodes": {
+			reason: "Exit node routes never overlap",
+			r:      r,
+			others: []Router{{Name: "b", Prefixes: prefixes("0.0.0.0/0")}},
+		},
+		"HAPair": {
+			reason: "Routers of the same HA group may overlap",
+			r:      Router{Name: "a", HAGroup: "office", Prefixes: prefixes("10.0.0.0/24")},
+			others: []Router{{Name: "b", HAGroup: "office", Prefixes: prefixes("10.0.0.0/24")}},
+		},
+		"OtherHAGroup": {
+			reason: "Routers of different HA groups are reported",
+			r:      Router{Name: "a", HAGroup: "office", Prefixes: prefixes("10.0.0.0/24")},
+			others: []Router{{Name: "b", HAGroup: "lab", Prefixes: prefixes("10.0.0.0/24")}},
+			want:   []Overlap{{Prefix: netip.MustParsePrefix("10.0.0.0/24"), Other: "b", OtherOf: netip.MustParsePrefix("10.0.0.0/24")}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Overlaps(tc.r, tc.others)
+			if diff := cmp.Diff(tc.want, got, cmp.Comparer(func(a, b netip.Prefix) bool { return a == b })); diff != "" {
+				t.Errorf("\n%s\nOverlaps(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	newRoutes := func(name string, labels map[string]string, routes ...string) *devicev1alpha1.SubnetRoutes {
+		mg := &devicev1alpha1.SubnetRoutes{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+		for _, r := range routes {
+			mg.Spec.ForProvider.Routes = append(mg.Spec.ForProvider.Routes, ptr.To(r))
+		}
+		return mg
+	}
+	toUnstructured := func(mg *devicev1alpha1.SubnetRoutes) unstructured.Unstructured {
+		routes := make([]any, len(mg.Spec.ForProvider.Routes))
+		for i, r := range mg.Spec.ForProvider.Routes {
+			routes[i] = *r
+		}
+		u := unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"forProvider": map[string]any{"routes": routes}}}}
+		u.SetName(mg.GetName())
+		u.SetLabels(mg.GetLabels())
+		return u
+	}
+
+	type want struct {
+		routes  []string
+		updated bool
+		reason  xpv1.ConditionReason
+		err     bool
+	}
+	cases := map[string]struct {
+		reason string
+		mg     *devicev1alpha1.SubnetRoutes
+		others []*devicev1alpha1.SubnetRoutes
+		want   want
+	}{
+		"Normalised": {
+			reason: "Routes should be written back in canonical form",
+			mg:     newRoutes("a", nil, "2001:DB8::/32", "10.0.0.0/24"),
+			want:   want{routes: []string{"10.0.0.0/24", "2001:db8::/32"}, updated: true, reason: ReasonNoOverlap},
+		},
+		"HostBits": {
+			reason: "Routes with host bits set should be rejected",
+			mg:     newRoutes("a", nil, "10.0.0.1/24"),
+			want:   want{routes: []string{"10.0.0.1/24"}, err: true},
+		},
+		"Overlap": {
+			reason: "Overlapping routes of another router should be reported",
+			mg:     newRoutes("a", nil, "10.0.0.0/24"),
+			others: []*devicev1alpha1.SubnetRoutes{newRoutes("b", nil, "10.0.0.0/8"), newRoutes("c", nil, "bad")},
+			want:   want{routes: []string{"10.0.0.0/24"}, reason: ReasonOverlapping},
+		},
+		"HAPair": {
+			reason: "Overlaps within an HA group should not be reported",
+			mg:     newRoutes("a", map[string]string{LabelHAGroup: "office"}, "10.0.0.0/24"),
+			others: []*devicev1alpha1.SubnetRoutes{newRoutes("b", map[string]string{LabelHAGroup: "office"}, "10.0.0.0/24")},
+			want:   want{routes: []string{"10.0.0.0/24"}, reason: ReasonNoOverlap},
+		},
+		"Deleted": {
+			reason: "Routes of a SubnetRoutes being deleted should not be validated",
+			mg: func() *devicev1alpha1.SubnetRoutes {
+				mg := newRoutes("a", nil, "10.0.0.1/24")
+				mg.SetDeletionTimestamp(ptr.To(metav1.Now()))
+				return mg
+			}(),
+			want: want{routes: []string{"10.0.0.1/24"}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			updated := false
+			kube := &test.MockClient{
+				MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+					l := obj.(*unstructured.UnstructuredList)
+					l.Items = append(l.Items, toUnstructured(tc.mg))
+					for _, o := range tc.others {
+						l.Items = append(l.Items, toUnstructured(o))
+					}
+					return nil
+				}),
+				MockUpdate: func(context.Context, client.Object, ...client.UpdateOption) error {
+					updated = true
+					return nil
+				},
+			}
+			err := NewInitializer(kube).Initialize(context.Background(), tc.mg)
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nInitialize(...): unexpected error state: %v", tc.reason, err)
+			}
+			got := want{updated: updated, reason: tc.mg.GetCondition(TypeRoutesOverlap).Reason, err: err != nil}
+			for _, r := range tc.mg.Spec.ForProvider.Routes {
+				got.routes = append(got.routes, *r)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
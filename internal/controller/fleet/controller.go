@@ -0,0 +1,204 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/upjet/v2/pkg/controller"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+const (
+	controllerName = "fleet.tailscale.upbound.io"
+
+	reasonCreated      = "CreatedDeviceResource"
+	reasonDeleted      = "DeletedDeviceResource"
+	reasonCannotList   = "CannotListDevices"
+	reasonCannotManage = "CannotManageDeviceResources"
+)
+
+// deviceLister is the part of the Tailscale API used by this package.
+type deviceLister interface {
+	ListDevices(ctx context.Context) ([]tsapi.Device, error)
+}
+
+// newClientFn returns an API client for the tailnet of a Fleet.
+type newClientFn func(ctx context.Context, kube client.Client, f *v1alpha1.Fleet) (deviceLister, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, f *v1alpha1.Fleet) (deviceLister, error) {
+	name := "default"
+	if f.Spec.ProviderConfigReference != nil {
+		name = f.Spec.ProviderConfigReference.Name
+	}
+	return tsapi.NewForProviderConfig(ctx, kube, name)
+}
+
+func newDeviceResource(kind string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(deviceGroupVersion.WithKind(kind))
+	return u
+}
+
+// Setup adds a controller that reconciles Fleets.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	r := &Reconciler{
+		kube:      mgr.GetClient(),
+		log:       o.Logger.WithValues("controller", controllerName),
+		record:    event.NewAPIRecorder(mgr.GetEventRecorderFor(controllerName)),
+		newClient: newAPIClient,
+		poll:      o.PollInterval,
+	}
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(o.ForControllerRuntime()).
+		For(&v1alpha1.Fleet{})
+	for _, k := range kinds {
+		b = b.Owns(newDeviceResource(k))
+	}
+	return b.Complete(r)
+}
+
+// SetupGated adds the controller; the Fleet CRD is part of the package.
+func SetupGated(mgr ctrl.Manager, o controller.Options) error {
+	return Setup(mgr, o)
+}
+
+// Reconciler keeps the device resources of a Fleet in sync with the devices
+// matched by its selector.
+type Reconciler struct {
+	kube      client.Client
+	log       logging.Logger
+	record    event.Recorder
+	newClient newClientFn
+	poll      time.Duration
+}
+
+// Reconcile a Fleet. Devices come and go without any Kubernetes event, so
+// Fleets are requeued every poll interval.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	f := &v1alpha1.Fleet{}
+	if err := r.kube.Get(ctx, req.NamespacedName, f); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if meta.WasDeleted(f) {
+		// Device resources are owned by the Fleet and garbage collected.
+		return reconcile.Result{}, nil
+	}
+
+	devices, err := r.match(ctx, f)
+	if err != nil {
+		r.record.Event(f, event.Warning(reasonCannotList, err))
+		return reconcile.Result{}, r.fail(ctx, f, err)
+	}
+	if err := r.sync(ctx, f, devices); err != nil {
+		r.record.Event(f, event.Warning(reasonCannotManage, err))
+		return reconcile.Result{}, r.fail(ctx, f, err)
+	}
+
+	f.Status.Devices = make([]v1alpha1.FleetDevice, 0, len(devices))
+	for _, d := range devices {
+		f.Status.Devices = append(f.Status.Devices, v1alpha1.FleetDevice{ID: d.ID, Name: d.Name, Resource: resourceName(f, d)})
+	}
+	f.Status.MatchedDevices = len(devices)
+	f.SetConditions(xpv1.Available(), xpv1.ReconcileSuccess())
+	if err := r.kube.Status().Update(ctx, f); err != nil {
+		return reconcile.Result{}, fmt.Errorf("cannot update fleet status: %w", err)
+	}
+	return reconcile.Result{RequeueAfter: r.poll}, nil
+}
+
+// match returns the devices selected by the Fleet, ordered by ID.
+func (r *Reconciler) match(ctx context.Context, f *v1alpha1.Fleet) ([]tsapi.Device, error) {
+	c, err := r.newClient(ctx, r.kube, f)
+	if err != nil {
+		return nil, err
+	}
+	all, err := c.ListDevices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list devices: %w", err)
+	}
+	var devices []tsapi.Device
+	for _, d := range all {
+		if Matches(f.Spec.Selector, d) {
+			devices = append(devices, d)
+		}
+	}
+	slices.SortFunc(devices, func(a, b tsapi.Device) int { return strings.Compare(a.ID, b.ID) })
+	return devices, nil
+}
+
+// sync creates and updates the device resources of the matched devices and
+// deletes those of every other device, including resources of another kind
+// left from an earlier template.
+func (r *Reconciler) sync(ctx context.Context, f *v1alpha1.Fleet, devices []tsapi.Device) error {
+	keep := map[string]bool{}
+	for _, d := range devices {
+		want, err := desired(f, d)
+		if err != nil {
+			return err
+		}
+		keep[want.GetName()] = true
+		got := newDeviceResource(f.Spec.Template.Kind)
+		err = r.kube.Get(ctx, client.ObjectKey{Name: want.GetName()}, got)
+		switch {
+		case kerrors.IsNotFound(err):
+			if err := r.kube.Create(ctx, want); err != nil {
+				return fmt.Errorf("cannot create %s %s: %w", want.GetKind(), want.GetName(), err)
+			}
+			r.record.Event(f, event.Normal(reasonCreated, fmt.Sprintf("Created %s %s for device %s", want.GetKind(), want.GetName(), d.Name)))
+		case err != nil:
+			return fmt.Errorf("cannot get %s %s: %w", want.GetKind(), want.GetName(), err)
+		default:
+			if !metav1.IsControlledBy(got, f) {
+				return fmt.Errorf("%s %s exists and is not controlled by fleet %s", want.GetKind(), want.GetName(), f.GetName())
+			}
+			if merge(want, got) {
+				if err := r.kube.Update(ctx, got); err != nil {
+					return fmt.Errorf("cannot update %s %s: %w", want.GetKind(), want.GetName(), err)
+				}
+			}
+		}
+	}
+
+	for _, k := range kinds {
+		l := &unstructured.UnstructuredList{}
+		l.SetGroupVersionKind(deviceGroupVersion.WithKind(k + "List"))
+		if err := r.kube.List(ctx, l, client.MatchingLabels{LabelFleet: f.GetName()}); err != nil {
+			return fmt.Errorf("cannot list %s resources: %w", k, err)
+		}
+		for i := range l.Items {
+			u := &l.Items[i]
+			if (k == f.Spec.Template.Kind && keep[u.GetName()]) || !metav1.IsControlledBy(u, f) {
+				continue
+			}
+			if err := r.kube.Delete(ctx, u); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("cannot delete %s %s: %w", k, u.GetName(), err)
+			}
+			r.record.Event(f, event.Normal(reasonDeleted, fmt.Sprintf("Deleted %s %s for device %s", k, u.GetName(), u.GetLabels()[LabelDevice])))
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) fail(ctx context.Context, f *v1alpha1.Fleet, err error) error {
+	f.SetConditions(xpv1.ReconcileError(err))
+	if uerr := r.kube.Status().Update(ctx, f); uerr != nil {
+		r.log.Debug("Cannot update fleet status", "error", uerr)
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+// Package fleet keeps one device resource for each device matched by the
+// selector of a Fleet.
+//
+// Device resources (Tags, Authorization, Key and SubnetRoutes) each target a
+// single device through their external name. A Fleet lists the devices of
+// the tailnet, selects them by name prefix, tags, OS and user, and creates
+// a device resource from its template for every match. Resources of devices
+// that no longer match are deleted; all of them are owned by the Fleet and
+// are garbage collected with it.
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+const (
+	// LabelFleet is set on device resources to the name of their Fleet.
+	LabelFleet = "tailscale.upbound.io/fleet"
+	// LabelDevice is set on device resources to the ID of their device.
+	LabelDevice = "tailscale.upbound.io/device-id"
+)
+
+// deviceGroupVersion is the API version of the device resources.
+var deviceGroupVersion = schema.GroupVersion{Group: "device.tailscale.upbound.io", Version: "v1alpha1"}
+
+// kinds are the device resource kinds a Fleet can keep.
+var kinds = []string{"Tags", "Authorization", "Key", "SubnetRoutes"}
+
+// Matches reports whether the device is selected by the selector.
+func Matches(s v1alpha1.DeviceSelector, d tsapi.Device) bool {
+	if s.NamePrefix != "" && !strings.HasPrefix(d.Name, s.NamePrefix) {
+		return false
+	}
+	if s.User != "" && !strings.EqualFold(s.User, d.User) {
+		return false
+	}
+	if len(s.OS) > 0 && !slices.ContainsFunc(s.OS, func(os string) bool { return strings.EqualFold(os, d.OS) }) {
+		return false
+	}
+	for _, t := range s.Tags {
+		if !slices.Contains(d.Tags, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceName returns the name of the device resource for a device.
+func resourceName(f *v1alpha1.Fleet, d tsapi.Device) string {
+	return strings.ToLower(f.GetName() + "-" + d.ID)
+}
+
+// desired returns the device resource a Fleet wants for a device.
+func desired(f *v1alpha1.Fleet, d tsapi.Device) (*unstructured.Unstructured, error) {
+	forProvider := map[string]any{}
+	if len(f.Spec.Template.ForProvider.Raw) > 0 {
+		if err := json.Unmarshal(f.Spec.Template.ForProvider.Raw, &forProvider); err != nil {
+			return nil, fmt.Errorf("cannot decode spec.template.forProvider: %w", err)
+		}
+	}
+	spec := map[string]any{"forProvider": forProvider}
+	if f.Spec.ProviderConfigReference != nil {
+		spec["providerConfigRef"] = map[string]any{"name": f.Spec.ProviderConfigReference.Name}
+	}
+	if f.Spec.Template.DeletionPolicy != "" {
+		spec["deletionPolicy"] = string(f.Spec.Template.DeletionPolicy)
+	}
+
+	u := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	u.SetGroupVersionKind(deviceGroupVersion.WithKind(f.Spec.Template.Kind))
+	u.SetName(resourceName(f, d))
+	labels := map[string]string{}
+	for k, v := range f.Spec.Template.Labels {
+		labels[k] = v
+	}
+	labels[LabelFleet] = f.GetName()
+	labels[LabelDevice] = d.ID
+	u.SetLabels(labels)
+	meta.SetExternalName(u, d.ID)
+	u.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion:         v1alpha1.SchemeGroupVersion.String(),
+		Kind:               v1alpha1.FleetKind,
+		Name:               f.GetName(),
+		UID:                f.GetUID(),
+		Controller:         ptr.To(true),
+		BlockOwnerDeletion: ptr.To(true),
+	}})
+	return u, nil
+}
+
+// merge copies the fields a Fleet controls from want into got, reporting
+// whether got changed. Only the forProvider arguments of the template are
+// compared, so that late-initialized arguments don't cause updates.
+func merge(want, got *unstructured.Unstructured) bool {
+	changed := false
+	wfp, _, _ := unstructured.NestedMap(want.Object, "spec", "forProvider")
+	gfp, _, _ := unstructured.NestedMap(got.Object, "spec", "forProvider")
+	if gfp == nil {
+		gfp = map[string]any{}
+	}
+	for k, w := range wfp {
+		if g, ok := gfp[k]; !ok || !jsonEqual(w, g) {
+			gfp[k] = w
+			changed = true
+		}
+	}
+	_ = unstructured.SetNestedMap(got.Object, gfp, "spec", "forProvider")
+	for _, f := range []string{"providerConfigRef", "deletionPolicy"} {
+		w, wok, _ := unstructured.NestedFieldCopy(want.Object, "spec", f)
+		g, gok, _ := unstructured.NestedFieldCopy(got.Object, "spec", f)
+		if !wok || (gok && jsonEqual(w, g)) {
+			continue
+		}
+		_ = unstructured.SetNestedField(got.Object, w, "spec", f)
+		changed = true
+	}
+	labels := got.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range want.GetLabels() {
+		if labels[k] != v {
+			labels[k] = v
+			changed = true
+		}
+	}
+	got.SetLabels(labels)
+	if meta.GetExternalName(got) != meta.GetExternalName(want) {
+		meta.SetExternalName(got, meta.GetExternalName(want))
+		changed = true
+	}
+	return changed
+}
+
+func jsonEqual(a, b any) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	return err == nil && string(ja) == string(jb)
+}
@@ -0,0 +1,238 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+type fakeLister struct {
+	devices []tsapi.Device
+	err     error
+}
+
+func (f *fakeLister) ListDevices(context.Context) ([]tsapi.Device, error) {
+	return f.devices, f.err
+}
+
+func (f *fakeLister) newClient(context.Context, client.Client, *v1alpha1.Fleet) (deviceLister, error) {
+	return f, nil
+}
+
+func newFleet() *v1alpha1.Fleet {
+	return &v1alpha1.Fleet{
+		ObjectMeta: metav1.ObjectMeta{Name: "exit-nodes", UID: "fleet-uid"},
+		Spec: v1alpha1.FleetSpec{
+			Selector: v1alpha1.DeviceSelector{NamePrefix: "exit-", Tags: []string{"tag:exit"}},
+			Template: v1alpha1.FleetTemplate{
+				Kind:        "Key",
+				ForProvider: runtime.RawExtension{Raw: []byte(`{"keyExpiryDisabled":true}`)},
+			},
+			ProviderConfigReference: &xpv1.Reference{Name: "default"},
+		},
+	}
+}
+
+func TestMatches(t *testing.T) {
+	d := tsapi.Device{ID: "1", Name: "exit-1.example.ts.net", OS: "linux", User: "ops@example.com", Tags: []string{"tag:exit", "tag:prod"}}
+	cases := map[string]struct {
+		selector v1alpha1.DeviceSelector
+		want     bool
+	}{
+		"Empty":         {want: true},
+		"NamePrefix":    {selector: v1alpha1.DeviceSelector{NamePrefix: "exit-"}, want: true},
+		"OtherPrefix":   {selector: v1alpha1.DeviceSelector{NamePrefix: "db-"}},
+		"AllTags":       {selector: v1alpha1.DeviceSelector{Tags: []string{"tag:exit", "tag:prod"}}, want: true},
+		"MissingTag":    {selector: v1alpha1.DeviceSelector{Tags: []string{"tag:exit", "tag:dev"}}},
+		"OS":            {selector: v1alpha1.DeviceSelector{OS: []string{"windows", "Linux"}}, want: true},
+		"OtherOS":       {selector: v1alpha1.DeviceSelector{OS: []string{"macOS"}}},
+		"User":          {selector: v1alpha1.DeviceSelector{User: "ops@example.com"}, want: true},
+		"OtherUser":     {selector: v1alpha1.DeviceSelector{User: "dev@example.com"}},
+		"EveryCriteria": {selector: v1alpha1.DeviceSelector{NamePrefix: "exit-", Tags: []string{"tag:exit"}, OS: []string{"linux"}, User: "ops@example.com"}, want: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := Matches(tc.selector, d); got != tc.want {
+				t.Errorf("Matches(...) = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	want, err := desired(newFleet(), tsapi.Device{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		reason  string
+		got     map[string]any
+		changed bool
+	}{
+		"InSync": {
+			reason: "Late-initialized arguments should not cause an update",
+			got: map[string]any{
+				"forProvider":       map[string]any{"keyExpiryDisabled": true, "id": "1"},
+				"providerConfigRef": map[string]any{"name": "default"},
+			},
+		},
+		"Drifted": {
+			reason: "Arguments of the template should be enforced",
+			got: map[string]any{
+				"forProvider":       map[string]any{"keyExpiryDisabled": false},
+				"providerConfigRef": map[string]any{"name": "default"},
+			},
+			changed: true,
+		},
+		"OtherProviderConfig": {
+			reason: "The provider config should follow the fleet",
+			got: map[string]any{
+				"forProvider":       map[string]any{"keyExpiryDisabled": true},
+				"providerConfigRef": map[string]any{"name": "other"},
+			},
+			changed: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := &unstructured.Unstructured{Object: map[string]any{"spec": tc.got}}
+			got.SetLabels(want.GetLabels())
+			meta.SetExternalName(got, "1")
+			if changed := merge(want, got); changed != tc.changed {
+				t.Errorf("\n%s\nmerge(...) = %t, want %t", tc.reason, changed, tc.changed)
+			}
+			if v, _, _ := unstructured.NestedBool(got.Object, "spec", "forProvider", "keyExpiryDisabled"); !v {
+				t.Errorf("\n%s\nmerge(...): keyExpiryDisabled was not enforced", tc.reason)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	owned := func(f *v1alpha1.Fleet, id string) unstructured.Unstructured {
+		u, _ := desired(f, tsapi.Device{ID: id})
+		return *u
+	}
+
+	type want struct {
+		created []string
+		deleted []string
+		status  []v1alpha1.FleetDevice
+		err     bool
+	}
+	cases := map[string]struct {
+		reason   string
+		devices  []tsapi.Device
+		listErr  error
+		existing []unstructured.Unstructured
+		want     want
+	}{
+		"CreateMatching": {
+			reason: "A device resource should be created for every matching device",
+			devices: []tsapi.Device{
+				{ID: "2", Name: "exit-2", Tags: []string{"tag:exit"}},
+				{ID: "1", Name: "exit-1", Tags: []string{"tag:exit"}},
+				{ID: "3", Name: "db-1", Tags: []string{"tag:exit"}},
+				{ID: "4", Name: "exit-4"},
+			},
+			want: want{
+				created: []string{"exit-nodes-1", "exit-nodes-2"},
+				status: []v1alpha1.FleetDevice{
+					{ID: "1", Name: "exit-1", Resource: "exit-nodes-1"},
+					{ID: "2", Name: "exit-2", Resource: "exit-nodes-2"},
+				},
+			},
+		},
+		"DeleteGone": {
+			reason:   "Device resources of devices that no longer match should be deleted",
+			devices:  []tsapi.Device{{ID: "1", Name: "exit-1", Tags: []string{"tag:exit"}}},
+			existing: []unstructured.Unstructured{owned(newFleet(), "1"), owned(newFleet(), "9")},
+			want: want{
+				deleted: []string{"exit-nodes-9"},
+				status:  []v1alpha1.FleetDevice{{ID: "1", Name: "exit-1", Resource: "exit-nodes-1"}},
+			},
+		},
+		"ListError": {
+			reason:  "Errors listing devices should be returned",
+			listErr: errors.New("boom"),
+			want:    want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := newFleet()
+			var created, deleted []string
+			kube := &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					if ff, ok := obj.(*v1alpha1.Fleet); ok {
+						f.DeepCopyInto(ff)
+						return nil
+					}
+					for _, e := range tc.existing {
+						if e.GetName() == key.Name {
+							e.DeepCopyInto(obj.(*unstructured.Unstructured))
+							return nil
+						}
+					}
+					return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+				},
+				MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+					l := obj.(*unstructured.UnstructuredList)
+					for _, e := range tc.existing {
+						if e.GetKind()+"List" == l.GetKind() {
+							l.Items = append(l.Items, *e.DeepCopy())
+						}
+					}
+					return nil
+				},
+				MockCreate: func(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+					created = append(created, obj.GetName())
+					return nil
+				},
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					deleted = append(deleted, obj.GetName())
+					return nil
+				},
+				MockUpdate: test.NewMockUpdateFn(nil),
+				MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+					obj.(*v1alpha1.Fleet).DeepCopyInto(f)
+					return nil
+				},
+			}
+			api := &fakeLister{devices: tc.devices, err: tc.listErr}
+			r := &Reconciler{kube: kube, log: logging.NewNopLogger(), record: event.NewNopRecorder(), newClient: api.newClient}
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Name: "exit-nodes"}})
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nReconcile(...): unexpected error state: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.created, created); diff != "" {
+				t.Errorf("\n%s\nReconcile(...): -want created, +got created:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.deleted, deleted); diff != "" {
+				t.Errorf("\n%s\nReconcile(...): -want deleted, +got deleted:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.status, f.Status.Devices); diff != "" {
+				t.Errorf("\n%s\nReconcile(...): -want status, +got status:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
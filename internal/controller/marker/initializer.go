@@ -0,0 +1,78 @@
+package marker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	ujresource "github.com/crossplane/upjet/v2/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// paramDescription is the Terraform argument holding the description of
+// keys and OAuth clients.
+const paramDescription = "description"
+
+// Initializer stamps the description of keys and OAuth clients.
+type Initializer struct{}
+
+// NewInitializer returns an Initializer. Its signature matches
+// config.NewInitializerFn.
+func NewInitializer(_ client.Client) managed.Initializer {
+	return &Initializer{}
+}
+
+// Initialize sets the description in spec.forProvider to the one the
+// resource has in the tailnet: stamped with the marker of the resource if
+// it is not created yet, and stamped with the marker it was created with
+// otherwise, so that Terraform does not see the description change. The
+// stamped description is set in memory, and written back to the resource
+// when the reconciler updates it.
+func (i *Initializer) Initialize(_ context.Context, mg resource.Managed) error {
+	tr, ok := mg.(ujresource.Terraformed)
+	if !ok {
+		return errors.New("managed resource is not a Terraformed resource")
+	}
+	params, err := tr.GetMergedParameters(true)
+	if err != nil {
+		return fmt.Errorf("cannot get parameters: %w", err)
+	}
+	desc, _ := params[paramDescription].(string)
+
+	m := Of(mg.GetUID())
+	if meta.GetExternalName(mg) != "" {
+		obs, err := tr.GetObservation()
+		if err != nil {
+			return fmt.Errorf("cannot get observation: %w", err)
+		}
+		observed, _ := obs[paramDescription].(string)
+		if m, ok = Parse(observed); !ok {
+			return nil
+		}
+	}
+	stamped := Stamp(desc, m)
+	if stamped == desc {
+		return nil
+	}
+	return setDescription(mg, stamped)
+}
+
+// setDescription sets the description in forProvider.
+func setDescription(mg resource.Managed, desc string) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mg)
+	if err != nil {
+		return fmt.Errorf("cannot convert resource: %w", err)
+	}
+	if err := unstructured.SetNestedField(u, desc, "spec", "forProvider", paramDescription); err != nil {
+		return fmt.Errorf("cannot set description: %w", err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, mg); err != nil {
+		return fmt.Errorf("cannot convert resource: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Package marker stamps the descriptions of auth keys and OAuth clients with
+// the UID of the managed resource they are created for.
+//
+// The external name of these resources is the ID the API returns when they
+// are created. If the key is created but the external-name annotation cannot
+// be written, no managed resource points at the key, which can still be used
+// to join devices or mint tokens. An Initializer appends a marker, such as
+// crossplane-1a2b3c4d for the resource of UID 1a2b3c4d-..., to the
+// description of resources that are about to be created, so that the orphan
+// scanner can tie a key back to its resource. Resources that were created
+// without a marker, or imported, are left as they are: the description of a
+// key cannot be changed without replacing it.
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration.
+package marker
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// Prefix starts every marker.
+	Prefix = "crossplane-"
+
+	// uidLength is the number of characters of the UID in a marker.
+	uidLength = 8
+
+	// MaxDescriptionLength is the longest description the API accepts.
+	MaxDescriptionLength = 50
+)
+
+// Of returns the marker of the resource with the supplied UID.
+func Of(uid types.UID) string {
+	id := strings.ReplaceAll(string(uid), "-", "")
+	if len(id) > uidLength {
+		id = id[:uidLength]
+	}
+	return Prefix + id
+}
+
+// Parse returns the marker in a description, if any.
+func Parse(description string) (string, bool) {
+	f := strings.Fields(description)
+	if len(f) == 0 {
+		return "", false
+	}
+	m := f[len(f)-1]
+	id, ok := strings.CutPrefix(m, Prefix)
+	if !ok || len(id) != uidLength || strings.Trim(id, "0123456789abcdef") != "" {
+		return "", false
+	}
+	return m, true
+}
+
+// Stamp returns the description followed by the supplied marker. A
+// description that already carries a marker is returned as it is, and one
+// too long to fit the marker is truncated.
+func Stamp(description, marker string) string {
+	if _, ok := Parse(description); ok {
+		return description
+	}
+	d := strings.TrimSpace(description)
+	if d == "" {
+		return marker
+	}
+	if n := MaxDescriptionLength - len(marker) - 1; len(d) > n {
+		d = strings.TrimSpace(d[:n])
+	}
+	return d + " " + marker
+}
@@ -0,0 +1,134 @@
+package marker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	tailnetkeyv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/tailnetkey/v1alpha1"
+)
+
+const testUID = types.UID("1a2b3c4d-5e6f-7081-92a3-b4c5d6e7f809")
+
+func TestStamp(t *testing.T) {
+	m := Of(testUID)
+	cases := map[string]struct {
+		reason string
+		in     string
+		want   string
+	}{
+		"Empty": {
+			reason: "An empty description should be the marker",
+			in:     "",
+			want:   "crossplane-1a2b3c4d",
+		},
+		"Description": {
+			reason: "The marker should follow the description",
+			in:     "ci runners",
+			want:   "ci runners crossplane-1a2b3c4d",
+		},
+		"Stamped": {
+			reason: "A description with a marker should be left as it is",
+			in:     "ci crossplane-00000000",
+			want:   "ci crossplane-00000000",
+		},
+		"TooLong": {
+			reason: "A description too long to fit the marker should be truncated",
+			in:     strings.Repeat("a", MaxDescriptionLength),
+			want:   strings.Repeat("a", MaxDescriptionLength-len(m)-1) + " crossplane-1a2b3c4d",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, Stamp(tc.in, m)); diff != "" {
+				t.Errorf("\n%s\nStamp(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	type want struct {
+		marker string
+		ok     bool
+	}
+	cases := map[string]struct {
+		reason string
+		in     string
+		want   want
+	}{
+		"Marker": {
+			reason: "The marker at the end of a description should be found",
+			in:     "ci crossplane-1a2b3c4d",
+			want:   want{marker: "crossplane-1a2b3c4d", ok: true},
+		},
+		"NotLast": {
+			reason: "A marker must end the description",
+			in:     "crossplane-1a2b3c4d ci",
+		},
+		"NotUID": {
+			reason: "A marker must end with the start of a UID",
+			in:     "crossplane-runners",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			m, ok := Parse(tc.in)
+			if diff := cmp.Diff(tc.want, want{marker: m, ok: ok}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nParse(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	newKey := func(externalName, desc, observed string) *tailnetkeyv1alpha1.Key {
+		k := &tailnetkeyv1alpha1.Key{ObjectMeta: metav1.ObjectMeta{Name: "ci", UID: testUID}}
+		if externalName != "" {
+			meta.SetExternalName(k, externalName)
+		}
+		if desc != "" {
+			k.Spec.ForProvider.Description = ptr.To(desc)
+		}
+		if observed != "" {
+			k.Status.AtProvider.Description = ptr.To(observed)
+		}
+		return k
+	}
+	cases := map[string]struct {
+		reason string
+		mg     *tailnetkeyv1alpha1.Key
+		want   *string
+	}{
+		"Creating": {
+			reason: "Resources that are not created yet should be stamped",
+			mg:     newKey("", "ci", ""),
+			want:   ptr.To("ci crossplane-1a2b3c4d"),
+		},
+		"Created": {
+			reason: "Created resources should keep the marker they were created with",
+			mg:     newKey("k1", "ci", "ci crossplane-00000000"),
+			want:   ptr.To("ci crossplane-00000000"),
+		},
+		"CreatedWithoutMarker": {
+			reason: "Resources created without a marker should not be stamped, which would replace them",
+			mg:     newKey("k1", "", "imported"),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := NewInitializer(nil).Initialize(context.Background(), tc.mg); err != nil {
+				t.Fatalf("\n%s\nInitialize(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, tc.mg.Spec.ForProvider.Description); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want description, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package scopes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	ujresource "github.com/crossplane/upjet/v2/pkg/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Terraform arguments of the OAuth client.
+const (
+	paramScopes = "scopes"
+	paramTags   = "tags"
+)
+
+// Initializer validates the scopes of an OAuth client and reports whether
+// they are broader than necessary.
+type Initializer struct{}
+
+// NewInitializer returns an Initializer. Its signature matches
+// config.NewInitializerFn.
+func NewInitializer(_ client.Client) managed.Initializer {
+	return &Initializer{}
+}
+
+// Initialize returns an error, which keeps the client from being created or
+// updated, if its scopes are invalid, and sets the OverBroadScopes
+// condition otherwise.
+func (i *Initializer) Initialize(_ context.Context, mg resource.Managed) error {
+	tr, ok := mg.(ujresource.Terraformed)
+	if !ok {
+		return errors.New("managed resource is not a Terraformed resource")
+	}
+	// Scopes are only required on creation, they may be in initProvider.
+	params, err := tr.GetMergedParameters(true)
+	if err != nil {
+		return fmt.Errorf("cannot get parameters: %w", err)
+	}
+	s := toStrings(params[paramScopes])
+	if err := Validate(s, toStrings(params[paramTags]), mg.GetAnnotations()[AnnotationAllowUnknown] == "true"); err != nil {
+		return fmt.Errorf("spec.forProvider.scopes: %w", err)
+	}
+	if b := Broad(s); len(b) > 0 {
+		mg.SetConditions(OverBroadScopes(b))
+		return nil
+	}
+	mg.SetConditions(LeastPrivilege())
+	return nil
+}
+
+func toStrings(v any) []string {
+	l, _ := v.([]any)
+	out := make([]string, 0, len(l))
+	for _, e := range l {
+		if s, ok := e.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+// Package scopes validates the scopes of OAuth clients against the scopes
+// the Tailscale API knows, and flags scopes broader than a client is likely
+// to need.
+//
+// The API only rejects an unknown scope, or a device creating scope without
+// tags, when the client is created. An Initializer performs the same checks
+// before that, and the Helm chart's ValidatingAdmissionPolicy rejects such
+// clients at admission. Clients granted all or all:read get an
+// OverBroadScopes condition suggesting to narrow them down.
+//
+// Scopes introduced by Tailscale after this catalogue was written can be
+// allowed by annotating the client with
+// oauth.tailscale.upbound.io/allow-unknown-scopes: "true".
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration.
+package scopes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnnotationAllowUnknown skips the check against the catalogue when "true".
+const AnnotationAllowUnknown = "oauth.tailscale.upbound.io/allow-unknown-scopes"
+
+// TypeOverBroadScopes reports whether a client is granted a scope covering
+// every API.
+const TypeOverBroadScopes xpv1.ConditionType = "OverBroadScopes"
+
+// Reasons of the OverBroadScopes condition.
+const (
+	ReasonOverBroad      xpv1.ConditionReason = "OverBroad"
+	ReasonLeastPrivilege xpv1.ConditionReason = "LeastPrivilege"
+)
+
+// Catalogue lists the scopes of the Tailscale API, see
+// https://tailscale.com/kb/1215/oauth-clients#scopes. Keep it in sync with
+// the oauth-client ValidatingAdmissionPolicy of the Helm chart.
+var Catalogue = []string{
+	"all", "all:read",
+	"auth_keys", "auth_keys:read",
+	"devices:core", "devices:core:read",
+	"devices:posture_attributes", "devices:posture_attributes:read",
+	"devices:routes", "devices:routes:read",
+	"dns", "dns:read",
+	"feature_settings", "feature_settings:read",
+	"logs:configuration", "logs:configuration:read",
+	"logs:network:read",
+	"oauth_keys", "oauth_keys:read",
+	"policy_file", "policy_file:read",
+	"services", "services:read",
+	"user_invites", "user_invites:read",
+	"users", "users:read",
+	"webhooks", "webhooks:read",
+}
+
+// DeviceCreating are the scopes whose tokens can add devices to the
+// tailnet. Clients granted them must list the tags those devices get.
+var DeviceCreating = []string{"devices:core", "auth_keys"}
+
+// OverBroad are the scopes granting access to every API.
+var OverBroad = []string{"all", "all:read"}
+
+// Validate checks the scopes and tags of an OAuth client. Unknown scopes
+// are only allowed if allowUnknown is set.
+func Validate(scopes, tags []string, allowUnknown bool) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	var unknown []string
+	for _, s := range scopes {
+		if !slices.Contains(Catalogue, s) {
+			unknown = append(unknown, s)
+		}
+	}
+	if len(unknown) > 0 && !allowUnknown {
+		return fmt.Errorf("unknown scopes %s, see https://tailscale.com/kb/1215/oauth-clients#scopes or annotate the client with %s: \"true\"",
+			strings.Join(unknown, ", "), AnnotationAllowUnknown)
+	}
+	if len(tags) > 0 {
+		return nil
+	}
+	for _, s := range scopes {
+		if slices.Contains(DeviceCreating, s) {
+			return fmt.Errorf("scope %s requires at least one tag for the devices it creates", s)
+		}
+	}
+	return nil
+}
+
+// Broad returns the scopes that grant access to every API.
+func Broad(scopes []string) []string {
+	var out []string
+	for _, s := range scopes {
+		if slices.Contains(OverBroad, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// OverBroadScopes returns a condition indicating a client is granted
+// access to every API.
+func OverBroadScopes(broad []string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeOverBroadScopes,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonOverBroad,
+		Message:            fmt.Sprintf("scope %s grants access to every API, consider granting only the scopes the client uses", strings.Join(broad, ", ")),
+	}
+}
+
+// LeastPrivilege returns a condition indicating a client is only granted
+// specific scopes.
+func LeastPrivilege() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeOverBroadScopes,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonLeastPrivilege,
+	}
+}
@@ -0,0 +1,114 @@
+package scopes
+
+import (
+	"context"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	oauthv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/oauth/v1alpha1"
+)
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		reason       string
+		scopes       []string
+		tags         []string
+		allowUnknown bool
+		err          string
+	}{
+		"Valid": {
+			reason: "Known scopes should be accepted",
+			scopes: []string{"dns:read", "devices:routes"},
+		},
+		"Typo": {
+			reason: "Scopes missing from the catalogue should be rejected",
+			scopes: []string{"devices:write", "dns"},
+			err:    `unknown scopes devices:write, see https://tailscale.com/kb/1215/oauth-clients#scopes or annotate the client with oauth.tailscale.upbound.io/allow-unknown-scopes: "true"`,
+		},
+		"AllowUnknown": {
+			reason:       "Unknown scopes should be accepted when explicitly allowed",
+			scopes:       []string{"devices:write"},
+			allowUnknown: true,
+		},
+		"DeviceCreatingWithoutTags": {
+			reason: "Device creating scopes should require tags",
+			scopes: []string{"dns", "auth_keys"},
+			err:    "scope auth_keys requires at least one tag for the devices it creates",
+		},
+		"DeviceCreatingWithTags": {
+			reason: "Device creating scopes should be accepted with tags",
+			scopes: []string{"devices:core"},
+			tags:   []string{"tag:k8s"},
+		},
+		"Empty": {
+			reason: "At least one scope should be required",
+			err:    "at least one scope is required",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tc.scopes, tc.tags, tc.allowUnknown)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if diff := cmp.Diff(tc.err, got); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	newClient := func(scopes []string, init []string) *oauthv1alpha1.Client {
+		c := &oauthv1alpha1.Client{ObjectMeta: metav1.ObjectMeta{Name: "client"}}
+		for _, s := range scopes {
+			c.Spec.ForProvider.Scopes = append(c.Spec.ForProvider.Scopes, ptr.To(s))
+		}
+		for _, s := range init {
+			c.Spec.InitProvider.Scopes = append(c.Spec.InitProvider.Scopes, ptr.To(s))
+		}
+		return c
+	}
+
+	type want struct {
+		err    bool
+		reason xpv1.ConditionReason
+	}
+	cases := map[string]struct {
+		reason string
+		mg     *oauthv1alpha1.Client
+		want   want
+	}{
+		"OverBroad": {
+			reason: "Clients granted all should be flagged",
+			mg:     newClient([]string{"all"}, nil),
+			want:   want{reason: ReasonOverBroad},
+		},
+		"LeastPrivilege": {
+			reason: "Clients granted specific scopes should not be flagged",
+			mg:     newClient(nil, []string{"dns:read"}),
+			want:   want{reason: ReasonLeastPrivilege},
+		},
+		"Invalid": {
+			reason: "Clients with invalid scopes should not be reconciled",
+			mg:     newClient([]string{"devices:core"}, nil),
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := (&Initializer{}).Initialize(context.Background(), tc.mg)
+			got := want{err: err != nil, reason: tc.mg.GetCondition(TypeOverBroadScopes).Reason}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
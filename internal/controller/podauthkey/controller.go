@@ -0,0 +1,216 @@
+package podauthkey
+
+import (
+	"context"
+	"fmt"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/upjet/v2/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tailnetkeyv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/tailnetkey/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1beta1"
+)
+
+const (
+	controllerName = "podauthkey.tailscale.upbound.io"
+
+	reasonMinted  = "MintedKey"
+	reasonRevoked = "RevokedKey"
+	reasonFailed  = "CannotManageKeys"
+)
+
+// Setup adds a controller that reconciles PodAuthKeys.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	r := &Reconciler{
+		kube:   mgr.GetClient(),
+		log:    o.Logger.WithValues("controller", controllerName),
+		record: event.NewAPIRecorder(mgr.GetEventRecorderFor(controllerName)),
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(o.ForControllerRuntime()).
+		For(&v1alpha1.PodAuthKey{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.inNamespace)).
+		Watches(&v1beta1.ProviderConfig{}, handler.EnqueueRequestsFromMapFunc(r.using)).
+		Complete(r)
+}
+
+// SetupGated adds the controller; the PodAuthKey CRD is part of the
+// package.
+func SetupGated(mgr ctrl.Manager, o controller.Options) error {
+	return Setup(mgr, o)
+}
+
+// Reconciler keeps a Key resource for every pod selected by a PodAuthKey.
+type Reconciler struct {
+	kube   client.Client
+	log    logging.Logger
+	record event.Recorder
+}
+
+// inNamespace returns a request for every PodAuthKey in the namespace of
+// the supplied pod. A pod that no longer matches a selector may still hold
+// a key, so they are not filtered by selector.
+func (r *Reconciler) inNamespace(ctx context.Context, o client.Object) []reconcile.Request {
+	l := &v1alpha1.PodAuthKeyList{}
+	if err := r.kube.List(ctx, l, client.InNamespace(o.GetNamespace())); err != nil {
+		r.log.Debug("Cannot list PodAuthKeys", "error", err)
+		return nil
+	}
+	reqs := make([]reconcile.Request, 0, len(l.Items))
+	for _, p := range l.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&p)})
+	}
+	return reqs
+}
+
+// using returns a request for every PodAuthKey using the supplied
+// ProviderConfig, whose grants may have changed.
+func (r *Reconciler) using(ctx context.Context, o client.Object) []reconcile.Request {
+	l := &v1alpha1.PodAuthKeyList{}
+	if err := r.kube.List(ctx, l); err != nil {
+		r.log.Debug("Cannot list PodAuthKeys", "error", err)
+		return nil
+	}
+	var reqs []reconcile.Request
+	for i := range l.Items {
+		if providerConfigName(&l.Items[i]) == o.GetName() {
+			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&l.Items[i])})
+		}
+	}
+	return reqs
+}
+
+// Reconcile a PodAuthKey.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	p := &v1alpha1.PodAuthKey{}
+	if err := r.kube.Get(ctx, req.NamespacedName, p); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	keys := &tailnetkeyv1alpha1.KeyList{}
+	if err := r.kube.List(ctx, keys, client.MatchingLabels{LabelOwner: string(p.GetUID())}); err != nil {
+		return reconcile.Result{}, errKeys("list", err)
+	}
+
+	if meta.WasDeleted(p) {
+		for i := range keys.Items {
+			if err := r.revoke(ctx, p, &keys.Items[i]); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		meta.RemoveFinalizer(p, Finalizer)
+		return reconcile.Result{}, client.IgnoreNotFound(r.kube.Update(ctx, p))
+	}
+	if !meta.FinalizerExists(p, Finalizer) {
+		meta.AddFinalizer(p, Finalizer)
+		if err := r.kube.Update(ctx, p); err != nil {
+			return reconcile.Result{}, fmt.Errorf("cannot add finalizer: %w", err)
+		}
+	}
+
+	if err := r.sync(ctx, p, keys.Items); err != nil {
+		r.record.Event(p, event.Warning(reasonFailed, err))
+		p.SetConditions(xpv1.ReconcileError(err))
+		if uerr := r.kube.Status().Update(ctx, p); uerr != nil {
+			r.log.Debug("Cannot update PodAuthKey status", "error", uerr)
+		}
+		return reconcile.Result{}, err
+	}
+	p.SetConditions(xpv1.Available(), xpv1.ReconcileSuccess())
+	return reconcile.Result{}, r.kube.Status().Update(ctx, p)
+}
+
+func (r *Reconciler) sync(ctx context.Context, p *v1alpha1.PodAuthKey, keys []tailnetkeyv1alpha1.Key) error {
+	denied, err := r.authorize(ctx, p)
+	if err != nil {
+		return err
+	}
+	if denied != nil {
+		p.Status.Keys = nil
+		for i := range keys {
+			if err := r.revoke(ctx, p, &keys[i]); err != nil {
+				return err
+			}
+		}
+		return denied
+	}
+	sel, err := metav1.LabelSelectorAsSelector(&p.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+	pods := &corev1.PodList{}
+	if err := r.kube.List(ctx, pods, client.InNamespace(p.GetNamespace()), client.MatchingLabelsSelector{Selector: sel}); err != nil {
+		return fmt.Errorf("cannot list pods: %w", err)
+	}
+
+	have := map[string]bool{}
+	for i := range keys {
+		have[keys[i].GetName()] = true
+	}
+	want := map[string]bool{}
+	p.Status.Keys = nil
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !live(pod) {
+			continue
+		}
+		k := desired(p, pod)
+		want[k.GetName()] = true
+		p.Status.Keys = append(p.Status.Keys, v1alpha1.PodKey{Pod: pod.GetName(), Resource: k.GetName(), Secret: SecretName(pod)})
+		if have[k.GetName()] {
+			continue
+		}
+		if err := r.kube.Create(ctx, k); err != nil && !kerrors.IsAlreadyExists(err) {
+			return errKeys("create", err)
+		}
+		r.record.Event(p, event.Normal(reasonMinted, fmt.Sprintf("Minted key %s for pod %s into Secret %s", k.GetName(), pod.GetName(), SecretName(pod))))
+	}
+	for i := range keys {
+		if !want[keys[i].GetName()] {
+			if err := r.revoke(ctx, p, &keys[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// authorize returns why the ProviderConfig of the PodAuthKey does not allow
+// its namespace and tags, or nil if it does. A ProviderConfig that does not
+// exist allows nothing. Any other error getting it is returned as err, so
+// that the keys minted are kept until it can be read again.
+func (r *Reconciler) authorize(ctx context.Context, p *v1alpha1.PodAuthKey) (denied, err error) {
+	pc := &v1beta1.ProviderConfig{}
+	err = r.kube.Get(ctx, types.NamespacedName{Name: providerConfigName(p)}, pc)
+	if kerrors.IsNotFound(err) {
+		return fmt.Errorf("ProviderConfig %s does not exist", providerConfigName(p)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get ProviderConfig: %w", err)
+	}
+	return Authorize(pc, p.GetNamespace(), p.Spec.Tags), nil
+}
+
+// revoke deletes a Key resource, which revokes the key and its Secret.
+func (r *Reconciler) revoke(ctx context.Context, p *v1alpha1.PodAuthKey, k *tailnetkeyv1alpha1.Key) error {
+	if meta.WasDeleted(k) {
+		return nil
+	}
+	if err := r.kube.Delete(ctx, k); client.IgnoreNotFound(err) != nil {
+		return errKeys("delete", err)
+	}
+	r.record.Event(p, event.Normal(reasonRevoked, fmt.Sprintf("Revoked key %s of pod %s", k.GetName(), podName(k))))
+	return nil
+}
@@ -0,0 +1,162 @@
+// Package podauthkey mints an auth key for every pod selected by a
+// PodAuthKey.
+//
+// Sharing one reusable auth key between all replicas of a workload means a
+// leaked key can join any number of devices for months. A PodAuthKey instead
+// creates a tailnetkey Key resource per selected pod: single-use, ephemeral
+// and preauthorized. The Key publishes its connection details, including
+// the key as authkey, to a Secret named <pod>-tailscale-authkey in the
+// namespace of the pod, which is where Tailscale's containerboot looks for
+// it when TS_KUBE_SECRET is set to that name. When the pod is deleted or
+// has terminated its Key resource is deleted, which revokes the key and
+// removes the Secret.
+//
+// The Keys are cluster scoped and use the credentials of a ProviderConfig,
+// so a PodAuthKey could otherwise mint keys with any tag in any tailnet.
+// The ProviderConfig therefore lists the namespaces allowed to use it and
+// the tags they may apply in spec.podAuthKeys. A PodAuthKey that is not
+// allowed mints no keys and revokes those it has.
+package podauthkey
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	tailnetkeyv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/tailnetkey/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1beta1"
+)
+
+const (
+	// LabelOwner is set on Key resources to the UID of their PodAuthKey.
+	LabelOwner = "tailscale.upbound.io/pod-auth-key-uid"
+	// LabelPod is set on Key resources to the UID of their pod.
+	LabelPod = "tailscale.upbound.io/pod-uid"
+	// AnnotationPod is set on Key resources to the namespace and name of
+	// their pod.
+	AnnotationPod = "tailscale.upbound.io/pod"
+
+	// Finalizer holds a PodAuthKey until its Key resources are deleted.
+	// They are cluster scoped and can't be garbage collected with it.
+	Finalizer = "tailscale.upbound.io/pod-auth-keys"
+
+	// SecretSuffix is appended to the pod name to name its Secret.
+	SecretSuffix = "-tailscale-authkey"
+
+	// maxDescription is the longest key description Tailscale accepts.
+	maxDescription = 50
+)
+
+// nonDescription matches the characters not allowed in key descriptions.
+var nonDescription = regexp.MustCompile(`[^a-zA-Z0-9 -]+`)
+
+// ResourceName returns the name of the Key resource of a pod.
+func ResourceName(pod *corev1.Pod) string {
+	return "pod-" + string(pod.GetUID())
+}
+
+// SecretName returns the name of the Secret holding the key of a pod.
+func SecretName(pod *corev1.Pod) string {
+	return pod.GetName() + SecretSuffix
+}
+
+// live reports whether a pod still needs its key.
+func live(pod *corev1.Pod) bool {
+	if pod.GetDeletionTimestamp() != nil {
+		return false
+	}
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// description returns a key description identifying the pod.
+func description(pod *corev1.Pod) string {
+	d := nonDescription.ReplaceAllString("pod "+pod.GetNamespace()+" "+pod.GetName(), "-")
+	if len(d) > maxDescription {
+		d = d[:maxDescription]
+	}
+	return strings.TrimSpace(d)
+}
+
+// desired returns the Key resource for a pod.
+func desired(p *v1alpha1.PodAuthKey, pod *corev1.Pod) *tailnetkeyv1alpha1.Key {
+	k := &tailnetkeyv1alpha1.Key{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ResourceName(pod),
+			Labels: map[string]string{
+				LabelOwner: string(p.GetUID()),
+				LabelPod:   string(pod.GetUID()),
+			},
+			Annotations: map[string]string{AnnotationPod: pod.GetNamespace() + "/" + pod.GetName()},
+		},
+	}
+	k.Spec.ForProvider = tailnetkeyv1alpha1.KeyParameters{
+		Description:       ptr.To(description(pod)),
+		Ephemeral:         ptr.To(true),
+		Preauthorized:     ptr.To(true),
+		Reusable:          ptr.To(false),
+		RecreateIfInvalid: ptr.To("never"),
+	}
+	if p.Spec.ExpirySeconds > 0 {
+		k.Spec.ForProvider.Expiry = ptr.To(float64(p.Spec.ExpirySeconds))
+	}
+	for _, t := range p.Spec.Tags {
+		k.Spec.ForProvider.Tags = append(k.Spec.ForProvider.Tags, ptr.To(t))
+	}
+	k.Spec.DeletionPolicy = xpv1.DeletionDelete
+	k.Spec.ProviderConfigReference = &xpv1.Reference{Name: providerConfigName(p)}
+	k.Spec.WriteConnectionSecretToReference = &xpv1.SecretReference{Name: SecretName(pod), Namespace: pod.GetNamespace()}
+	return k
+}
+
+// Authorize returns an error unless the supplied ProviderConfig allows the
+// PodAuthKeys of the namespace to mint keys with the supplied tags. Every
+// grant for the namespace, or for all namespaces, is considered.
+func Authorize(pc *v1beta1.ProviderConfig, namespace string, tags []string) error {
+	var granted []v1beta1.PodAuthKeyGrant
+	for _, g := range pc.Spec.PodAuthKeys {
+		if g.Namespace == namespace || g.Namespace == "*" {
+			granted = append(granted, g)
+		}
+	}
+	if len(granted) == 0 {
+		return fmt.Errorf("ProviderConfig %s does not allow PodAuthKeys in namespace %s, see spec.podAuthKeys", pc.GetName(), namespace)
+	}
+	if len(tags) == 0 {
+		if slices.ContainsFunc(granted, func(g v1beta1.PodAuthKeyGrant) bool { return g.Untagged }) {
+			return nil
+		}
+		return fmt.Errorf("ProviderConfig %s does not allow untagged keys for PodAuthKeys in namespace %s", pc.GetName(), namespace)
+	}
+	for _, t := range tags {
+		if !slices.ContainsFunc(granted, func(g v1beta1.PodAuthKeyGrant) bool { return slices.Contains(g.Tags, t) }) {
+			return fmt.Errorf("ProviderConfig %s does not allow %s for PodAuthKeys in namespace %s", pc.GetName(), t, namespace)
+		}
+	}
+	return nil
+}
+
+// providerConfigName returns the name of the ProviderConfig of a
+// PodAuthKey.
+func providerConfigName(p *v1alpha1.PodAuthKey) string {
+	if p.Spec.ProviderConfigReference != nil && p.Spec.ProviderConfigReference.Name != "" {
+		return p.Spec.ProviderConfigReference.Name
+	}
+	return "default"
+}
+
+// podName returns the name of the pod of a Key resource.
+func podName(k *tailnetkeyv1alpha1.Key) string {
+	_, name, _ := strings.Cut(k.GetAnnotations()[AnnotationPod], "/")
+	return name
+}
+
+func errKeys(action string, err error) error {
+	return fmt.Errorf("cannot %s key resources: %w", action, err)
+}
@@ -0,0 +1,265 @@
+package podauthkey
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tailnetkeyv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/tailnetkey/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1beta1"
+)
+
+func newPod(name string, phase corev1.PodPhase) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps", UID: types.UID(name + "-uid"), Labels: map[string]string{"app": "proxy"}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func newPodAuthKey() *v1alpha1.PodAuthKey {
+	return &v1alpha1.PodAuthKey{
+		ObjectMeta: metav1.ObjectMeta{Name: "proxy", Namespace: "apps", UID: "pak-uid", Finalizers: []string{Finalizer}},
+		Spec: v1alpha1.PodAuthKeySpec{
+			Selector:      metav1.LabelSelector{MatchLabels: map[string]string{"app": "proxy"}},
+			Tags:          []string{"tag:proxy"},
+			ExpirySeconds: 600,
+		},
+	}
+}
+
+func TestDesired(t *testing.T) {
+	pod := newPod("proxy-7d9c5-abcde", corev1.PodRunning)
+	got := desired(newPodAuthKey(), &pod)
+
+	want := tailnetkeyv1alpha1.KeyParameters{
+		Description:       ptr.To("pod apps proxy-7d9c5-abcde"),
+		Ephemeral:         ptr.To(true),
+		Preauthorized:     ptr.To(true),
+		Reusable:          ptr.To(false),
+		RecreateIfInvalid: ptr.To("never"),
+		Expiry:            ptr.To(600.0),
+		Tags:              []*string{ptr.To("tag:proxy")},
+	}
+	if diff := cmp.Diff(want, got.Spec.ForProvider); diff != "" {
+		t.Errorf("desired(...): -want forProvider, +got forProvider:\n%s", diff)
+	}
+	if diff := cmp.Diff(&xpv1.SecretReference{Name: "proxy-7d9c5-abcde-tailscale-authkey", Namespace: "apps"}, got.Spec.WriteConnectionSecretToReference); diff != "" {
+		t.Errorf("desired(...): -want secret, +got secret:\n%s", diff)
+	}
+	if got.GetName() != "pod-proxy-7d9c5-abcde-uid" || got.GetLabels()[LabelOwner] != "pak-uid" {
+		t.Errorf("desired(...): name %q, labels %v", got.GetName(), got.GetLabels())
+	}
+}
+
+func TestDescription(t *testing.T) {
+	pod := newPod("a.very-long-pod-name-that-goes-on-and-on-and-on", corev1.PodRunning)
+	got := description(&pod)
+	if len(got) > maxDescription || got != "pod apps a-very-long-pod-name-that-goes-on-and-on-" {
+		t.Errorf("description(...) = %q", got)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	pc := func(grants ...v1beta1.PodAuthKeyGrant) *v1beta1.ProviderConfig {
+		return &v1beta1.ProviderConfig{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1beta1.ProviderConfigSpec{PodAuthKeys: grants}}
+	}
+	cases := map[string]struct {
+		reason string
+		pc     *v1beta1.ProviderConfig
+		tags   []string
+		err    string
+	}{
+		"Allowed": {
+			reason: "Tags granted to the namespace should be allowed",
+			pc:     pc(v1beta1.PodAuthKeyGrant{Namespace: "apps", Tags: []string{"tag:proxy", "tag:k8s"}}),
+			tags:   []string{"tag:proxy"},
+		},
+		"AllNamespaces": {
+			reason: "Grants for every namespace should be combined with those of the namespace",
+			pc: pc(
+				v1beta1.PodAuthKeyGrant{Namespace: "*", Tags: []string{"tag:k8s"}},
+				v1beta1.PodAuthKeyGrant{Namespace: "apps", Tags: []string{"tag:proxy"}},
+			),
+			tags: []string{"tag:proxy", "tag:k8s"},
+		},
+		"NoGrant": {
+			reason: "ProviderConfigs without a grant for the namespace should not be usable",
+			pc:     pc(v1beta1.PodAuthKeyGrant{Namespace: "ops", Tags: []string{"tag:proxy"}}),
+			tags:   []string{"tag:proxy"},
+			err:    "ProviderConfig default does not allow PodAuthKeys in namespace apps, see spec.podAuthKeys",
+		},
+		"TagNotGranted": {
+			reason: "Tags not granted to the namespace should be rejected",
+			pc:     pc(v1beta1.PodAuthKeyGrant{Namespace: "apps", Tags: []string{"tag:proxy"}}),
+			tags:   []string{"tag:proxy", "tag:prod"},
+			err:    "ProviderConfig default does not allow tag:prod for PodAuthKeys in namespace apps",
+		},
+		"Untagged": {
+			reason: "Untagged keys should only be allowed if granted explicitly",
+			pc:     pc(v1beta1.PodAuthKeyGrant{Namespace: "apps", Tags: []string{"tag:proxy"}}),
+			err:    "ProviderConfig default does not allow untagged keys for PodAuthKeys in namespace apps",
+		},
+		"UntaggedGranted": {
+			reason: "Untagged keys should be allowed if granted",
+			pc:     pc(v1beta1.PodAuthKeyGrant{Namespace: "apps", Untagged: true}),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Authorize(tc.pc, "apps", tc.tags)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if diff := cmp.Diff(tc.err, got); diff != "" {
+				t.Errorf("\n%s\nAuthorize(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	key := func(pod string) tailnetkeyv1alpha1.Key {
+		p := newPod(pod, corev1.PodRunning)
+		return *desired(newPodAuthKey(), &p)
+	}
+	allowed := []v1beta1.PodAuthKeyGrant{{Namespace: "apps", Tags: []string{"tag:proxy"}}}
+
+	type want struct {
+		err     bool
+		created []string
+		deleted []string
+		keys    []v1alpha1.PodKey
+		removed bool
+	}
+	cases := map[string]struct {
+		reason  string
+		deleted bool
+		grants  []v1beta1.PodAuthKeyGrant
+		pcErr   error
+		pods    []corev1.Pod
+		keys    []tailnetkeyv1alpha1.Key
+		want    want
+	}{
+		"Mint": {
+			reason: "Running pods without a key should get one",
+			grants: allowed,
+			pods:   []corev1.Pod{newPod("a", corev1.PodRunning), newPod("b", corev1.PodPending)},
+			keys:   []tailnetkeyv1alpha1.Key{key("a")},
+			want: want{
+				created: []string{"pod-b-uid"},
+				keys: []v1alpha1.PodKey{
+					{Pod: "a", Resource: "pod-a-uid", Secret: "a-tailscale-authkey"},
+					{Pod: "b", Resource: "pod-b-uid", Secret: "b-tailscale-authkey"},
+				},
+			},
+		},
+		"Revoke": {
+			reason: "Keys of terminated and deleted pods should be revoked",
+			grants: allowed,
+			pods:   []corev1.Pod{newPod("a", corev1.PodSucceeded)},
+			keys:   []tailnetkeyv1alpha1.Key{key("a"), key("gone")},
+			want:   want{deleted: []string{"pod-a-uid", "pod-gone-uid"}},
+		},
+		"Deleted": {
+			reason:  "Deleting the PodAuthKey should revoke every key and release the finalizer",
+			deleted: true,
+			pods:    []corev1.Pod{newPod("a", corev1.PodRunning)},
+			keys:    []tailnetkeyv1alpha1.Key{key("a")},
+			want:    want{deleted: []string{"pod-a-uid"}, removed: true},
+		},
+		"NotAllowed": {
+			reason: "PodAuthKeys the ProviderConfig does not allow should mint no keys and revoke theirs",
+			grants: []v1beta1.PodAuthKeyGrant{{Namespace: "ops", Tags: []string{"tag:proxy"}}},
+			pods:   []corev1.Pod{newPod("a", corev1.PodRunning), newPod("b", corev1.PodRunning)},
+			keys:   []tailnetkeyv1alpha1.Key{key("a")},
+			want:   want{err: true, deleted: []string{"pod-a-uid"}},
+		},
+		"ProviderConfigNotFound": {
+			reason: "PodAuthKeys whose ProviderConfig does not exist should revoke their keys",
+			pcErr:  kerrors.NewNotFound(schema.GroupResource{Resource: "providerconfigs"}, "default"),
+			pods:   []corev1.Pod{newPod("a", corev1.PodRunning)},
+			keys:   []tailnetkeyv1alpha1.Key{key("a")},
+			want:   want{err: true, deleted: []string{"pod-a-uid"}},
+		},
+		"ProviderConfigUnavailable": {
+			reason: "Keys should be kept when the ProviderConfig cannot be read, the reconcile is retried",
+			grants: allowed,
+			pcErr:  errors.New("boom"),
+			pods:   []corev1.Pod{newPod("a", corev1.PodRunning)},
+			keys:   []tailnetkeyv1alpha1.Key{key("a")},
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := newPodAuthKey()
+			if tc.deleted {
+				p.SetDeletionTimestamp(ptr.To(metav1.Now()))
+			}
+			var got want
+			kube := &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					switch o := obj.(type) {
+					case *v1alpha1.PodAuthKey:
+						p.DeepCopyInto(o)
+					case *v1beta1.ProviderConfig:
+						if tc.pcErr != nil {
+							return tc.pcErr
+						}
+						o.Spec.PodAuthKeys = tc.grants
+					}
+					return nil
+				}),
+				MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+					switch l := obj.(type) {
+					case *corev1.PodList:
+						l.Items = tc.pods
+					case *tailnetkeyv1alpha1.KeyList:
+						l.Items = tc.keys
+					}
+					return nil
+				}),
+				MockCreate: func(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+					got.created = append(got.created, obj.GetName())
+					return nil
+				},
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					got.deleted = append(got.deleted, obj.GetName())
+					return nil
+				},
+				MockUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+					got.removed = !meta.FinalizerExists(obj, Finalizer)
+					return nil
+				},
+				MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+					got.keys = obj.(*v1alpha1.PodAuthKey).Status.Keys
+					return nil
+				},
+			}
+			r := &Reconciler{kube: kube, log: logging.NewNopLogger(), record: event.NewNopRecorder()}
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(p)})
+			got.err = err != nil
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nReconcile(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
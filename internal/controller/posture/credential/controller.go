@@ -0,0 +1,128 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/upjet/v2/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const controllerName = "client-secret.posture.tailscale.upbound.io"
+
+// integrationGroupVersionKind is the GVK of the posture Integration managed
+// resource.
+var integrationGroupVersionKind = schema.GroupVersionKind{
+	Group:   "posture.tailscale.upbound.io",
+	Version: "v1alpha1",
+	Kind:    "Integration",
+}
+
+func newIntegration() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(integrationGroupVersionKind)
+	return u
+}
+
+func newIntegrationList() *unstructured.UnstructuredList {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(integrationGroupVersionKind.GroupVersion().WithKind(integrationGroupVersionKind.Kind + "List"))
+	return l
+}
+
+// Setup adds a controller that triggers a reconcile of every posture
+// integration whose client secret changed.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	r := &Reconciler{
+		kube: mgr.GetClient(),
+		log:  o.Logger.WithValues("controller", controllerName),
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(o.ForControllerRuntime()).
+		For(newIntegration()).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.referencing)).
+		Complete(r)
+}
+
+// SetupGated adds the controller; it has no CRD of its own to wait for.
+func SetupGated(mgr ctrl.Manager, o controller.Options) error {
+	return Setup(mgr, o)
+}
+
+// Reconciler records the hash of a posture integration's client secret in
+// an annotation. The annotation change is picked up by the integration's
+// controller, which then pushes the new secret.
+type Reconciler struct {
+	kube client.Client
+	log  logging.Logger
+}
+
+// Reconcile a posture integration.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	in := newIntegration()
+	if err := r.kube.Get(ctx, req.NamespacedName, in); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if in.GetDeletionTimestamp() != nil {
+		return reconcile.Result{}, nil
+	}
+	ref, err := ReferenceOf(in)
+	if err != nil {
+		// The integration's controller reports missing references.
+		return reconcile.Result{}, nil
+	}
+	sum, err := Hash(ctx, r.kube, ref)
+	if kerrors.IsNotFound(err) {
+		// We'll be requeued when the Secret is created.
+		return reconcile.Result{}, nil
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if in.GetAnnotations()[AnnotationSecretRevision] == sum {
+		return reconcile.Result{}, nil
+	}
+
+	r.log.Debug("Client secret changed", "integration", in.GetName(), "secret", ref.String(), "sha256", sum)
+	patch := client.MergeFrom(in.DeepCopy())
+	meta := in.GetAnnotations()
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	meta[AnnotationSecretRevision] = sum
+	in.SetAnnotations(meta)
+	if err := r.kube.Patch(ctx, in, patch); err != nil {
+		return reconcile.Result{}, fmt.Errorf("cannot record client secret revision: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// referencing enqueues the posture integrations whose client secret is
+// held by the supplied Secret.
+func (r *Reconciler) referencing(ctx context.Context, obj client.Object) []reconcile.Request {
+	l := newIntegrationList()
+	if err := r.kube.List(ctx, l); err != nil {
+		r.log.Info("Cannot list posture integrations", "error", err)
+		return nil
+	}
+	var reqs []reconcile.Request
+	for i := range l.Items {
+		ref, err := ReferenceOf(&l.Items[i])
+		if err != nil {
+			continue
+		}
+		if ref.Namespace == obj.GetNamespace() && ref.Name == obj.GetName() {
+			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&l.Items[i])})
+		}
+	}
+	return reqs
+}
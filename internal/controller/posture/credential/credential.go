@@ -0,0 +1,205 @@
+// Package credential validates the credentials of posture integrations and
+// keeps their client secret in sync with the Secret it is taken from.
+//
+// Which of clientId, tenantId and cloudId an integration needs depends on
+// its posture provider. An Initializer checks them before Tailscale is
+// called, as does the Helm chart's ValidatingAdmissionPolicy at admission.
+//
+// The client secret is read from spec.forProvider.clientSecretSecretRef.
+// Terraform pushes it to Tailscale whenever it differs from the last one
+// applied, so rotating it only takes updating the Secret. A controller
+// watches referenced Secrets and triggers a reconcile of their integrations
+// as soon as they change, and the Initializer records the SHA-256 of the
+// secret and when it last changed in status.atProvider.clientSecretSource
+// and the ClientSecret condition.
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration.
+package credential
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// AnnotationSecretRevision records the hash of the client secret last seen
+// by the watching controller. Changing it triggers a reconcile.
+const AnnotationSecretRevision = "posture.tailscale.upbound.io/client-secret-revision"
+
+// TypeClientSecret reports whether the client secret could be read.
+const TypeClientSecret xpv1.ConditionType = "ClientSecret"
+
+// Reasons of the ClientSecret condition.
+const (
+	ReasonSecretCurrent     xpv1.ConditionReason = "Current"
+	ReasonSecretRotated     xpv1.ConditionReason = "Rotated"
+	ReasonSecretUnavailable xpv1.ConditionReason = "SecretUnavailable"
+)
+
+// Terraform arguments of the posture integration.
+const (
+	paramProvider = "posture_provider"
+	paramClientID = "client_id"
+	paramTenantID = "tenant_id"
+	paramCloudID  = "cloud_id"
+)
+
+// Presence is whether a posture provider uses an argument.
+type Presence int
+
+// Presences of arguments.
+const (
+	Forbidden Presence = iota
+	Optional
+	Required
+)
+
+// Rule describes the arguments a posture provider needs.
+type Rule struct {
+	ClientID Presence
+	TenantID Presence
+	CloudID  Presence
+	// CloudIDs are the allowed cloud IDs. If empty, the cloud ID is the
+	// domain name of the provider's tenant, such as example.kandji.io.
+	CloudIDs []string
+}
+
+// Rules maps posture providers to the arguments they need, see
+// https://tailscale.com/api#tag/devicepostureintegrations. Keep it in sync
+// with the posture-integration ValidatingAdmissionPolicy of the Helm chart.
+var Rules = map[string]Rule{
+	"falcon":      {ClientID: Required, CloudID: Required, CloudIDs: []string{"us-1", "us-2", "eu-1", "us-gov"}},
+	"intune":      {ClientID: Required, TenantID: Required, CloudID: Optional, CloudIDs: []string{"global", "us-gov"}},
+	"jamfpro":     {ClientID: Required, CloudID: Required},
+	"kandji":      {CloudID: Required},
+	"kolide":      {},
+	"sentinelone": {CloudID: Required},
+}
+
+// Validate checks the arguments of a posture integration against the rule
+// of its provider.
+func Validate(provider, clientID, tenantID, cloudID string) error {
+	r, ok := Rules[provider]
+	if !ok {
+		known := make([]string, 0, len(Rules))
+		for p := range Rules {
+			known = append(known, p)
+		}
+		sort.Strings(known)
+		return fmt.Errorf("postureProvider must be one of %s, got %q", strings.Join(known, ", "), provider)
+	}
+	for _, a := range []struct {
+		name     string
+		value    string
+		presence Presence
+	}{
+		{name: "clientId", value: clientID, presence: r.ClientID},
+		{name: "tenantId", value: tenantID, presence: r.TenantID},
+		{name: "cloudId", value: cloudID, presence: r.CloudID},
+	} {
+		switch {
+		case a.presence == Required && a.value == "":
+			return fmt.Errorf("%s is required for posture provider %s", a.name, provider)
+		case a.presence == Forbidden && a.value != "":
+			return fmt.Errorf("%s must not be set for posture provider %s", a.name, provider)
+		}
+	}
+	switch {
+	case cloudID == "":
+	case len(r.CloudIDs) > 0 && !slices.Contains(r.CloudIDs, cloudID):
+		return fmt.Errorf("cloudId must be one of %s for posture provider %s, got %q", strings.Join(r.CloudIDs, ", "), provider, cloudID)
+	case len(r.CloudIDs) == 0 && len(validation.IsDNS1123Subdomain(cloudID)) > 0:
+		return fmt.Errorf("cloudId must be the domain name of the %s tenant, got %q", provider, cloudID)
+	}
+	return nil
+}
+
+// SecretReference is a key of a Secret holding the client secret.
+type SecretReference struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+func (r SecretReference) String() string {
+	return fmt.Sprintf("Secret %s/%s key=%s", r.Namespace, r.Name, r.Key)
+}
+
+// Observed is the client secret last read by the Initializer, as recorded in
+// status.atProvider.clientSecretSource.
+type Observed struct {
+	SHA256    string
+	ChangedAt time.Time
+}
+
+// ObservedOf returns the client secret recorded in the status of the
+// supplied posture integration. It is empty if none was recorded yet.
+func ObservedOf(obj runtime.Object) (Observed, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return Observed{}, fmt.Errorf("cannot convert posture integration: %w", err)
+	}
+	src, _, _ := unstructured.NestedMap(u, "status", "atProvider", "clientSecretSource")
+	o := Observed{}
+	o.SHA256, _ = src["sha256"].(string)
+	if at, ok := src["changedAt"].(string); ok {
+		o.ChangedAt, _ = time.Parse(time.RFC3339, at)
+	}
+	return o, nil
+}
+
+// SetObserved records the supplied client secret in the status of the
+// posture integration.
+func SetObserved(obj runtime.Object, o Observed) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return fmt.Errorf("cannot convert posture integration: %w", err)
+	}
+	observed := map[string]any{"sha256": o.SHA256, "changedAt": o.ChangedAt.UTC().Format(time.RFC3339)}
+	if err := unstructured.SetNestedMap(u, observed, "status", "atProvider", "clientSecretSource"); err != nil {
+		return fmt.Errorf("cannot set status.atProvider.clientSecretSource: %w", err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, obj); err != nil {
+		return fmt.Errorf("cannot convert posture integration: %w", err)
+	}
+	return nil
+}
+
+// Current returns a condition indicating the client secret with the
+// supplied hash is in use. The reason is Rotated if it changed in this
+// reconcile.
+func Current(ref SecretReference, sha256 string, changedAt time.Time, rotated bool) xpv1.Condition {
+	reason := ReasonSecretCurrent
+	if rotated {
+		reason = ReasonSecretRotated
+	}
+	return xpv1.Condition{
+		Type:               TypeClientSecret,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            fmt.Sprintf("%s sha256=%s changedAt=%s", ref, sha256, changedAt.UTC().Format(time.RFC3339)),
+	}
+}
+
+// Unavailable returns a condition indicating the client secret could not
+// be read.
+func Unavailable(err error) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeClientSecret,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonSecretUnavailable,
+		Message:            err.Error(),
+	}
+}
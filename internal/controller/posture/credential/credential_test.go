@@ -0,0 +1,223 @@
+package credential
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	posturev1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/posture/v1alpha1"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func secretGetter(value string) test.MockGetFn {
+	return test.NewMockGetFn(nil, func(obj client.Object) error {
+		obj.(*corev1.Secret).Data = map[string][]byte{"token": []byte(value)}
+		return nil
+	})
+}
+
+func newTestIntegration(annotations map[string]string) *posturev1alpha1.Integration {
+	in := &posturev1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: "kandji", Annotations: annotations}}
+	in.Spec.ForProvider.PostureProvider = ptr.To("kandji")
+	in.Spec.ForProvider.CloudID = ptr.To("example.api.kandji.io")
+	in.Spec.ForProvider.ClientSecretSecretRef = xpv1.SecretKeySelector{
+		SecretReference: xpv1.SecretReference{Namespace: "security", Name: "kandji"},
+		Key:             "token",
+	}
+	return in
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		provider string
+		clientID string
+		tenantID string
+		cloudID  string
+		err      string
+	}{
+		"Intune": {
+			reason:   "Intune needs a client and tenant ID",
+			provider: "intune",
+			clientID: "app",
+			tenantID: "tenant",
+		},
+		"IntuneWithoutTenant": {
+			reason:   "Intune without a tenant ID should be rejected",
+			provider: "intune",
+			clientID: "app",
+			err:      "tenantId is required for posture provider intune",
+		},
+		"FalconCloud": {
+			reason:   "CrowdStrike Falcon clouds should be checked",
+			provider: "falcon",
+			clientID: "client",
+			cloudID:  "eu-2",
+			err:      `cloudId must be one of us-1, us-2, eu-1, us-gov for posture provider falcon, got "eu-2"`,
+		},
+		"KandjiWithClientID": {
+			reason:   "Arguments a provider does not use should be rejected",
+			provider: "kandji",
+			clientID: "client",
+			cloudID:  "example.api.kandji.io",
+			err:      "clientId must not be set for posture provider kandji",
+		},
+		"SentinelOneURL": {
+			reason:   "Cloud IDs that are domain names should not be URLs",
+			provider: "sentinelone",
+			cloudID:  "https://example.sentinelone.net",
+			err:      `cloudId must be the domain name of the sentinelone tenant, got "https://example.sentinelone.net"`,
+		},
+		"Kolide": {
+			reason:   "Kolide only needs the secret",
+			provider: "kolide",
+		},
+		"UnknownProvider": {
+			reason:   "Unknown posture providers should be rejected",
+			provider: "jamf",
+			err:      `postureProvider must be one of falcon, intune, jamfpro, kandji, kolide, sentinelone, got "jamf"`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tc.provider, tc.clientID, tc.tenantID, tc.cloudID)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if diff := cmp.Diff(tc.err, got); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	earlier := "2026-01-01T00:00:00Z"
+
+	type want struct {
+		err       bool
+		reason    xpv1.ConditionReason
+		sha256    string
+		changedAt string
+	}
+	cases := map[string]struct {
+		reason   string
+		observed *posturev1alpha1.ClientSecretSourceObservation
+		deleted  bool
+		secret   string
+		want     want
+	}{
+		"FirstSeen": {
+			reason: "The hash of a new secret should be recorded",
+			secret: "s3cr3t",
+			want:   want{reason: ReasonSecretCurrent, sha256: sha256Hex("s3cr3t"), changedAt: "2026-10-19T12:00:00Z"},
+		},
+		"Unchanged": {
+			reason:   "An unchanged secret should keep the time it last changed",
+			observed: &posturev1alpha1.ClientSecretSourceObservation{Sha256: ptr.To(sha256Hex("s3cr3t")), ChangedAt: ptr.To(earlier)},
+			secret:   "s3cr3t",
+			want:     want{reason: ReasonSecretCurrent, sha256: sha256Hex("s3cr3t"), changedAt: earlier},
+		},
+		"Rotated": {
+			reason:   "A changed secret should be recorded as rotated",
+			observed: &posturev1alpha1.ClientSecretSourceObservation{Sha256: ptr.To(sha256Hex("old")), ChangedAt: ptr.To(earlier)},
+			secret:   "s3cr3t",
+			want:     want{reason: ReasonSecretRotated, sha256: sha256Hex("s3cr3t"), changedAt: "2026-10-19T12:00:00Z"},
+		},
+		"Deleted": {
+			reason:  "Integrations being deleted should not be checked",
+			deleted: true,
+			secret:  "s3cr3t",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kube := &test.MockClient{
+				MockGet:    secretGetter(tc.secret),
+				MockUpdate: test.NewMockUpdateFn(errors.New("the integration must not be updated")),
+			}
+			mg := newTestIntegration(nil)
+			mg.Status.AtProvider.ClientSecretSource = tc.observed
+			if tc.deleted {
+				mg.SetDeletionTimestamp(ptr.To(metav1.Now()))
+			}
+			err := (&Initializer{kube: kube, now: func() time.Time { return now }}).Initialize(context.Background(), mg)
+			got := want{err: err != nil, reason: mg.GetCondition(TypeClientSecret).Reason}
+			if o := mg.Status.AtProvider.ClientSecretSource; o != nil {
+				got.sha256 = ptr.Deref(o.Sha256, "")
+				got.changedAt = ptr.Deref(o.ChangedAt, "")
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		revision string
+		want     string
+	}{
+		"RecordRevision": {
+			reason: "A changed secret should be recorded on the integration to trigger a reconcile",
+			want:   sha256Hex("s3cr3t"),
+		},
+		"UpToDate": {
+			reason:   "An unchanged secret should not be recorded again",
+			revision: sha256Hex("s3cr3t"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var patched string
+			kube := &test.MockClient{
+				MockGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+					if u, ok := obj.(*unstructured.Unstructured); ok {
+						in := newTestIntegration(map[string]string{AnnotationSecretRevision: tc.revision})
+						o, err := runtime.DefaultUnstructuredConverter.ToUnstructured(in)
+						u.Object = o
+						return err
+					}
+					return secretGetter("s3cr3t")(ctx, key, obj)
+				},
+				MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+					patched = obj.GetAnnotations()[AnnotationSecretRevision]
+					return nil
+				},
+			}
+			r := &Reconciler{kube: kube, log: logging.NewNopLogger()}
+			if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Name: "kandji"}}); err != nil {
+				t.Fatalf("\n%s\nReconcile(...): unexpected error: %v", tc.reason, err)
+			}
+			if patched != tc.want {
+				t.Errorf("\n%s\nReconcile(...): revision = %q, want %q", tc.reason, patched, tc.want)
+			}
+		})
+	}
+}
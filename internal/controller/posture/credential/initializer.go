@@ -0,0 +1,115 @@
+package credential
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	ujresource "github.com/crossplane/upjet/v2/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Initializer validates the credentials of a posture integration and
+// records changes of its client secret.
+type Initializer struct {
+	kube client.Client
+	now  func() time.Time
+}
+
+// NewInitializer returns an Initializer using the supplied client. Its
+// signature matches config.NewInitializerFn.
+func NewInitializer(kube client.Client) managed.Initializer {
+	return &Initializer{kube: kube, now: time.Now}
+}
+
+// Initialize returns an error, which keeps the integration from being
+// created or updated, if its arguments do not suit its posture provider or
+// its client secret cannot be read. Otherwise it records the client secret
+// in status.atProvider.clientSecretSource and sets the ClientSecret
+// condition. Integrations being deleted are not checked.
+func (i *Initializer) Initialize(ctx context.Context, mg resource.Managed) error {
+	if meta.WasDeleted(mg) {
+		return nil
+	}
+	tr, ok := mg.(ujresource.Terraformed)
+	if !ok {
+		return errors.New("managed resource is not a Terraformed resource")
+	}
+	params, err := tr.GetMergedParameters(true)
+	if err != nil {
+		return fmt.Errorf("cannot get parameters: %w", err)
+	}
+	str := func(k string) string {
+		s, _ := params[k].(string)
+		return s
+	}
+	if err := Validate(str(paramProvider), str(paramClientID), str(paramTenantID), str(paramCloudID)); err != nil {
+		return fmt.Errorf("spec.forProvider: %w", err)
+	}
+
+	ref, err := ReferenceOf(mg)
+	if err != nil {
+		mg.SetConditions(Unavailable(err))
+		return err
+	}
+	sum, err := Hash(ctx, i.kube, ref)
+	if err != nil {
+		mg.SetConditions(Unavailable(err))
+		return err
+	}
+
+	prev, err := ObservedOf(mg)
+	if err != nil {
+		return err
+	}
+	o := prev
+	if prev.SHA256 != sum {
+		o = Observed{SHA256: sum, ChangedAt: i.now()}
+		if err := SetObserved(mg, o); err != nil {
+			return err
+		}
+	}
+	mg.SetConditions(Current(ref, sum, o.ChangedAt, prev.SHA256 != "" && prev.SHA256 != sum))
+	return nil
+}
+
+// ReferenceOf returns the Secret key the client secret of the supplied
+// posture integration is read from.
+func ReferenceOf(obj runtime.Object) (SecretReference, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return SecretReference{}, fmt.Errorf("cannot convert posture integration: %w", err)
+	}
+	for _, spec := range []string{"forProvider", "initProvider"} {
+		sel, ok, _ := unstructured.NestedStringMap(u, "spec", spec, "clientSecretSecretRef")
+		if !ok || sel["name"] == "" {
+			continue
+		}
+		return SecretReference{Namespace: sel["namespace"], Name: sel["name"], Key: sel["key"]}, nil
+	}
+	return SecretReference{}, errors.New("spec.forProvider.clientSecretSecretRef is required")
+}
+
+// Hash returns the hex encoded SHA-256 of the referenced client secret.
+func Hash(ctx context.Context, kube client.Reader, ref SecretReference) (string, error) {
+	s := &corev1.Secret{}
+	if err := kube.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, s); err != nil {
+		return "", fmt.Errorf("cannot get client secret from %s: %w", ref, err)
+	}
+	b, ok := s.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("client secret %s has no such key", ref)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,181 @@
+package tagowner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	ujresource "github.com/crossplane/upjet/v2/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/aclpolicy"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+const (
+	// paramTags is the Terraform argument holding the tags of keys, OAuth
+	// clients and devices.
+	paramTags = "tags"
+
+	// defaultProviderConfig is used by resources without a reference.
+	defaultProviderConfig = "default"
+)
+
+// aclListGroupVersionKind is the GVK of a list of ACLs.
+var aclListGroupVersionKind = schema.GroupVersionKind{
+	Group:   "acl.tailscale.upbound.io",
+	Version: "v1alpha1",
+	Kind:    "ACLList",
+}
+
+// callerClient is the part of the Tailscale API used by this package.
+type callerClient interface {
+	Caller(ctx context.Context) (*tsapi.User, error)
+}
+
+// newClientFn returns an API client for the tailnet of a managed resource.
+type newClientFn func(ctx context.Context, kube client.Client, mg resource.Managed) (callerClient, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, mg resource.Managed) (callerClient, error) {
+	return tsapi.NewForManaged(ctx, kube, mg)
+}
+
+// Initializer stops resources from requesting tags their creator does not
+// own.
+type Initializer struct {
+	kube      client.Client
+	newClient newClientFn
+}
+
+// NewInitializer returns an Initializer using the supplied client. Its
+// signature matches config.NewInitializerFn.
+func NewInitializer(kube client.Client) managed.Initializer {
+	return &Initializer{kube: kube, newClient: newAPIClient}
+}
+
+// Initialize checks the tags in spec.forProvider that are not applied yet
+// and sets the TagNotOwned condition. It returns an error, which keeps the
+// resource from being created or updated, if any of them may not be applied.
+// Resources being deleted are not checked, so that the check never blocks
+// their deletion.
+func (i *Initializer) Initialize(ctx context.Context, mg resource.Managed) error {
+	if meta.WasDeleted(mg) {
+		return nil
+	}
+	tr, ok := mg.(ujresource.Terraformed)
+	if !ok {
+		return errors.New("managed resource is not a Terraformed resource")
+	}
+	params, err := tr.GetParameters()
+	if err != nil {
+		return fmt.Errorf("cannot get parameters: %w", err)
+	}
+	obs, err := tr.GetObservation()
+	if err != nil {
+		return fmt.Errorf("cannot get observation: %w", err)
+	}
+	applied := toStrings(obs[paramTags])
+	var tags []string
+	for _, t := range toStrings(params[paramTags]) {
+		if !slices.Contains(applied, t) {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) == 0 {
+		reset(mg)
+		return nil
+	}
+
+	acl, p, err := i.policy(ctx, providerConfigName(mg))
+	if err != nil || p == nil {
+		reset(mg)
+		return err
+	}
+
+	// Without an owner the tags can still be checked for existence.
+	var u *aclpolicy.User
+	if c, err := i.newClient(ctx, i.kube, mg); err == nil {
+		if caller, err := c.Caller(ctx); err == nil {
+			u = &aclpolicy.User{Login: caller.LoginName, Role: caller.Role}
+		}
+	}
+
+	if problems := Check(acl, p, tags, u); len(problems) > 0 {
+		c := NotOwned(problems)
+		mg.SetConditions(c)
+		return errors.New(c.Message)
+	}
+	mg.SetConditions(Owned())
+	return nil
+}
+
+// policy returns the name and parsed policy of the ACL managed with the
+// supplied ProviderConfig, if any. ACLs whose policy is missing or invalid
+// are skipped; they report their own error.
+func (i *Initializer) policy(ctx context.Context, pc string) (string, *aclpolicy.Policy, error) {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(aclListGroupVersionKind)
+	if err := i.kube.List(ctx, l); err != nil {
+		return "", nil, fmt.Errorf("cannot list ACLs: %w", err)
+	}
+	for _, a := range l.Items {
+		if a.GetDeletionTimestamp() != nil {
+			continue
+		}
+		ref, _, _ := unstructured.NestedString(a.Object, "spec", "providerConfigRef", "name")
+		if ref == "" {
+			ref = defaultProviderConfig
+		}
+		doc, _, _ := unstructured.NestedString(a.Object, "spec", "forProvider", "acl")
+		if ref != pc || doc == "" {
+			continue
+		}
+		if p, err := aclpolicy.Parse([]byte(doc)); err == nil {
+			return a.GetName(), p, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// reset resets a TagNotOwned condition set by an earlier reconcile.
+func reset(mg resource.Managed) {
+	if mg.GetCondition(TypeTagNotOwned).Status == corev1.ConditionTrue {
+		mg.SetConditions(Owned())
+	}
+}
+
+func providerConfigName(mg resource.Managed) string {
+	var ref string
+	switch m := mg.(type) {
+	case resource.LegacyManaged:
+		if r := m.GetProviderConfigReference(); r != nil {
+			ref = r.Name
+		}
+	case resource.ModernManaged:
+		if r := m.GetProviderConfigReference(); r != nil {
+			ref = r.Name
+		}
+	}
+	if ref == "" {
+		return defaultProviderConfig
+	}
+	return ref
+}
+
+func toStrings(v any) []string {
+	l, _ := v.([]any)
+	out := make([]string, 0, len(l))
+	for _, e := range l {
+		if s, ok := e.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
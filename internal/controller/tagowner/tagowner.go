@@ -0,0 +1,102 @@
+// Package tagowner checks the tags requested by auth keys, OAuth clients and
+// device tags against the tagOwners of the ACL the provider manages, before
+// the Tailscale API is called.
+//
+// The API rejects a tag its caller does not own with a terse 400 that only
+// shows up deep in the Terraform output. When an ACL resource of the same
+// ProviderConfig holds the tailnet policy, an Initializer looks up the user
+// owning the provider's API access token and checks that every tag not yet
+// applied is defined in tagOwners and owned by that user. If not, it sets a
+// TagNotOwned condition naming the tag and its owners and stops the
+// reconcile. Tags are not checked when the provider does not manage the ACL,
+// and owners are not checked when the caller cannot be determined.
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration.
+package tagowner
+
+import (
+	"fmt"
+	"strings"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/aclpolicy"
+)
+
+// TypeTagNotOwned reports whether a resource requests a tag its creator may
+// not apply.
+const TypeTagNotOwned xpv1.ConditionType = "TagNotOwned"
+
+// Reasons of the TagNotOwned condition.
+const (
+	ReasonMissingOwner xpv1.ConditionReason = "MissingTagOwner"
+	ReasonUndefined    xpv1.ConditionReason = "UndefinedTag"
+	ReasonOwned        xpv1.ConditionReason = "TagsOwned"
+)
+
+// Check returns the tags of the policy of the named ACL that u may not
+// apply. A nil u only checks that the tags are defined.
+func Check(acl string, p *aclpolicy.Policy, tags []string, u *aclpolicy.User) []Problem {
+	var out []Problem
+	for _, t := range tags {
+		o := p.TagOwner(t, aclpolicy.User{})
+		if u != nil {
+			o = p.TagOwner(t, *u)
+		}
+		if !o.Defined || (u != nil && !o.Owned()) {
+			out = append(out, Problem{ACL: acl, User: u, TagOwnership: o})
+		}
+	}
+	return out
+}
+
+// A Problem is a tag that may not be applied.
+type Problem struct {
+	aclpolicy.TagOwnership
+
+	// ACL is the name of the ACL resource holding the policy.
+	ACL string
+	// User is the caller, if known.
+	User *aclpolicy.User
+}
+
+func (p Problem) String() string {
+	if !p.Defined {
+		return fmt.Sprintf("%s is not defined in the tagOwners of ACL %s", p.Tag, p.ACL)
+	}
+	return fmt.Sprintf("%s is not owned by %s (role %s), the tagOwners of ACL %s allow %s",
+		p.Tag, p.User.Login, p.User.Role, p.ACL, strings.Join(p.Owners, ", "))
+}
+
+// NotOwned returns a condition indicating the supplied tags may not be
+// applied.
+func NotOwned(p []Problem) xpv1.Condition {
+	reason := ReasonUndefined
+	msgs := make([]string, len(p))
+	for i := range p {
+		msgs[i] = p[i].String()
+		if p[i].Defined {
+			reason = ReasonMissingOwner
+		}
+	}
+	return xpv1.Condition{
+		Type:               TypeTagNotOwned,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            strings.Join(msgs, "; "),
+	}
+}
+
+// Owned returns a condition indicating all requested tags may be applied.
+func Owned() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeTagNotOwned,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonOwned,
+	}
+}
@@ -0,0 +1,145 @@
+package tagowner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tailnetkeyv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/tailnetkey/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+const testPolicy = `{
+	"groups": {"group:ops": ["ops@example.com"]},
+	"tagOwners": {"tag:k8s": ["group:ops"], "tag:ci": ["autogroup:admin"]},
+}`
+
+type fakeCallerClient struct {
+	user *tsapi.User
+	err  error
+}
+
+func (f *fakeCallerClient) Caller(context.Context) (*tsapi.User, error) {
+	return f.user, f.err
+}
+
+func (f *fakeCallerClient) newClient(context.Context, client.Client, resource.Managed) (callerClient, error) {
+	return f, nil
+}
+
+func newKey(tags []string, applied []string) *tailnetkeyv1alpha1.Key {
+	k := &tailnetkeyv1alpha1.Key{ObjectMeta: metav1.ObjectMeta{Name: "key"}}
+	k.Spec.ProviderConfigReference = &xpv1.Reference{Name: "default"}
+	for _, t := range tags {
+		k.Spec.ForProvider.Tags = append(k.Spec.ForProvider.Tags, ptr.To(t))
+	}
+	for _, t := range applied {
+		k.Status.AtProvider.Tags = append(k.Status.AtProvider.Tags, ptr.To(t))
+	}
+	return k
+}
+
+func aclLister(pc, policy string) test.MockListFn {
+	return test.NewMockListFn(nil, func(obj client.ObjectList) error {
+		a := unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{
+				"providerConfigRef": map[string]any{"name": pc},
+				"forProvider":       map[string]any{"acl": policy},
+			},
+		}}
+		a.SetName("tailnet-policy")
+		obj.(*unstructured.UnstructuredList).Items = []unstructured.Unstructured{a}
+		return nil
+	})
+}
+
+func TestInitialize(t *testing.T) {
+	ops := &tsapi.User{LoginName: "ops@example.com", Role: "member"}
+	bob := &tsapi.User{LoginName: "bob@example.com", Role: "member"}
+
+	type want struct {
+		err     bool
+		reason  xpv1.ConditionReason
+		message string
+	}
+	cases := map[string]struct {
+		reason string
+		mg     *tailnetkeyv1alpha1.Key
+		list   test.MockListFn
+		caller *fakeCallerClient
+		want   want
+	}{
+		"Owned": {
+			reason: "Tags owned by the caller should be accepted",
+			mg:     newKey([]string{"tag:k8s"}, nil),
+			list:   aclLister("default", testPolicy),
+			caller: &fakeCallerClient{user: ops},
+			want:   want{reason: ReasonOwned},
+		},
+		"MissingOwner": {
+			reason: "Tags not owned by the caller should be rejected, naming the owners",
+			mg:     newKey([]string{"tag:k8s", "tag:ci"}, nil),
+			list:   aclLister("default", testPolicy),
+			caller: &fakeCallerClient{user: bob},
+			want: want{
+				err:     true,
+				reason:  ReasonMissingOwner,
+				message: "tag:k8s is not owned by bob@example.com (role member), the tagOwners of ACL tailnet-policy allow group:ops; tag:ci is not owned by bob@example.com (role member), the tagOwners of ACL tailnet-policy allow autogroup:admin",
+			},
+		},
+		"UndefinedWithoutCaller": {
+			reason: "Undefined tags should be rejected even if the caller is unknown",
+			mg:     newKey([]string{"tag:k8s", "tag:web"}, nil),
+			list:   aclLister("default", testPolicy),
+			caller: &fakeCallerClient{err: errors.New("boom")},
+			want: want{
+				err:     true,
+				reason:  ReasonUndefined,
+				message: "tag:web is not defined in the tagOwners of ACL tailnet-policy",
+			},
+		},
+		"Applied": {
+			reason: "Tags that are already applied should not be checked again",
+			mg:     newKey([]string{"tag:web"}, []string{"tag:web"}),
+			list:   aclLister("default", testPolicy),
+			caller: &fakeCallerClient{user: bob},
+		},
+		"Deleted": {
+			reason: "Resources being deleted should not be checked",
+			mg: func() *tailnetkeyv1alpha1.Key {
+				k := newKey([]string{"tag:web"}, nil)
+				k.SetDeletionTimestamp(ptr.To(metav1.Now()))
+				return k
+			}(),
+			list:   aclLister("default", testPolicy),
+			caller: &fakeCallerClient{user: bob},
+		},
+		"OtherProviderConfig": {
+			reason: "The ACL of another ProviderConfig should not be used",
+			mg:     newKey([]string{"tag:web"}, nil),
+			list:   aclLister("other", testPolicy),
+			caller: &fakeCallerClient{user: bob},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			i := &Initializer{kube: &test.MockClient{MockList: tc.list}, newClient: tc.caller.newClient}
+			err := i.Initialize(context.Background(), tc.mg)
+			c := tc.mg.GetCondition(TypeTagNotOwned)
+			got := want{err: err != nil, reason: c.Reason, message: c.Message}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
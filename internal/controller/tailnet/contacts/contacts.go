@@ -0,0 +1,152 @@
+// Package contacts reconciles the account, security and support contacts of
+// a tailnet through the Tailscale API.
+//
+// Tailscale only uses a contact once its address has been verified through
+// the email it sends whenever the address changes. The tailscale_contacts
+// Terraform resource does not expose that, so Contacts are reconciled by
+// this package instead of Terraform: the verification of each contact is
+// reported in status.atProvider, and the Ready condition stays False with
+// reason PendingVerification until every contact is verified, so that
+// automation does not assume a new security contact is live.
+//
+// Every tailnet has contacts, so a new resource adopts them: it is reported
+// as not existing until its first Create, which sets the contacts of
+// spec.forProvider and spec.initProvider. Later updates only use
+// spec.forProvider.
+package contacts
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/tailnet/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+// ReasonPendingVerification is the reason of the Ready condition while a
+// contact has not been verified.
+const ReasonPendingVerification xpv1.ConditionReason = "PendingVerification"
+
+// contactTypes are the contact types in the order they are reported.
+var contactTypes = []string{tsapi.ContactAccount, tsapi.ContactSecurity, tsapi.ContactSupport}
+
+// desired returns the email address of each configured contact type.
+func desired(p v1alpha1.ContactsParameters) map[string]string {
+	out := map[string]string{}
+	for t, l := range map[string][]v1alpha1.Contact{
+		tsapi.ContactAccount:  p.Account,
+		tsapi.ContactSecurity: p.Security,
+		tsapi.ContactSupport:  p.Support,
+	} {
+		if len(l) > 0 {
+			out[t] = l[0].Email
+		}
+	}
+	return out
+}
+
+// Initial returns the contacts set when the resource adopts the contacts of
+// the tailnet: those of spec.forProvider, completed with the contact types
+// only spec.initProvider sets.
+func Initial(cr *v1alpha1.Contacts) v1alpha1.ContactsParameters {
+	p := cr.Spec.ForProvider
+	if len(p.Account) == 0 {
+		p.Account = cr.Spec.InitProvider.Account
+	}
+	if len(p.Security) == 0 {
+		p.Security = cr.Spec.InitProvider.Security
+	}
+	if len(p.Support) == 0 {
+		p.Support = cr.Spec.InitProvider.Support
+	}
+	return p
+}
+
+// observed returns the contact of each type.
+func observed(c *tsapi.Contacts) map[string]tsapi.Contact {
+	return map[string]tsapi.Contact{
+		tsapi.ContactAccount:  c.Account,
+		tsapi.ContactSecurity: c.Security,
+		tsapi.ContactSupport:  c.Support,
+	}
+}
+
+// Validate checks the email address of each configured contact.
+func Validate(p v1alpha1.ContactsParameters) error {
+	want := desired(p)
+	for _, t := range contactTypes {
+		email, ok := want[t]
+		if !ok {
+			continue
+		}
+		a, err := mail.ParseAddress(email)
+		if err != nil || a.Address != email {
+			return fmt.Errorf("spec.forProvider.%s: %q is not an email address", t, email)
+		}
+	}
+	return nil
+}
+
+// Observation returns the observed state of the contacts.
+func Observation(tailnet string, c *tsapi.Contacts) v1alpha1.ContactsObservation {
+	obs := func(c tsapi.Contact) []v1alpha1.ContactObservation {
+		return []v1alpha1.ContactObservation{{Email: c.Email, Verified: !c.NeedsVerification}}
+	}
+	return v1alpha1.ContactsObservation{
+		ID:       &tailnet,
+		Account:  obs(c.Account),
+		Security: obs(c.Security),
+		Support:  obs(c.Support),
+	}
+}
+
+// Outdated returns the contact types whose address differs from the spec.
+// Addresses are compared case-insensitively, like the API does.
+func Outdated(p v1alpha1.ContactsParameters, c *tsapi.Contacts) []string {
+	want, got := desired(p), observed(c)
+	var out []string
+	for _, t := range contactTypes {
+		if email, ok := want[t]; ok && !strings.EqualFold(email, got[t].Email) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Unverified returns the contact types whose address has not been verified.
+func Unverified(c *tsapi.Contacts) []string {
+	got := observed(c)
+	var out []string
+	for _, t := range contactTypes {
+		if got[t].NeedsVerification {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Ready returns the Ready condition of the contacts: Available once every
+// contact is verified, and False with reason PendingVerification before.
+func Ready(c *tsapi.Contacts) xpv1.Condition {
+	u := Unverified(c)
+	if len(u) == 0 {
+		return xpv1.Available()
+	}
+	got := observed(c)
+	pending := make([]string, 0, len(u))
+	for _, t := range u {
+		pending = append(pending, fmt.Sprintf("%s contact %s", t, got[t].Email))
+	}
+	return xpv1.Condition{
+		Type:               xpv1.TypeReady,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonPendingVerification,
+		Message:            fmt.Sprintf("waiting for the %s to be verified through the email Tailscale sent", strings.Join(pending, ", ")),
+	}
+}
@@ -0,0 +1,318 @@
+package contacts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/tailnet/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+type fakeClient struct {
+	contacts *tsapi.Contacts
+	err      error
+	updated  map[string]string
+}
+
+func (f *fakeClient) Tailnet() string { return "example.com" }
+
+func (f *fakeClient) GetContacts(_ context.Context) (*tsapi.Contacts, error) {
+	return f.contacts, f.err
+}
+
+func (f *fakeClient) UpdateContact(_ context.Context, contactType, email string) error {
+	if f.updated == nil {
+		f.updated = map[string]string{}
+	}
+	f.updated[contactType] = email
+	return nil
+}
+
+func newContacts(account, security, support string) *v1alpha1.Contacts {
+	cr := &v1alpha1.Contacts{ObjectMeta: metav1.ObjectMeta{Name: "contacts"}}
+	meta.SetExternalName(cr, "example.com")
+	cr.Spec.ForProvider = v1alpha1.ContactsParameters{
+		Account:  []v1alpha1.Contact{{Email: account}},
+		Security: []v1alpha1.Contact{{Email: security}},
+		Support:  []v1alpha1.Contact{{Email: support}},
+	}
+	return cr
+}
+
+func verified(account, security, support string) *tsapi.Contacts {
+	return &tsapi.Contacts{
+		Account:  tsapi.Contact{Email: account},
+		Security: tsapi.Contact{Email: security},
+		Support:  tsapi.Contact{Email: support},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		p      v1alpha1.ContactsParameters
+		err    string
+	}{
+		"Valid": {
+			reason: "Plain email addresses should be accepted",
+			p:      newContacts("billing@example.com", "security@example.com", "ops@example.com").Spec.ForProvider,
+		},
+		"NotAnAddress": {
+			reason: "Strings that are not email addresses should be rejected",
+			p:      v1alpha1.ContactsParameters{Security: []v1alpha1.Contact{{Email: "security"}}},
+			err:    `spec.forProvider.security: "security" is not an email address`,
+		},
+		"DisplayName": {
+			reason: "Addresses with a display name should be rejected, the API only takes the address",
+			p:      v1alpha1.ContactsParameters{Support: []v1alpha1.Contact{{Email: "Ops <ops@example.com>"}}},
+			err:    `spec.forProvider.support: "Ops <ops@example.com>" is not an email address`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tc.p)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if diff := cmp.Diff(tc.err, got); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestObserve(t *testing.T) {
+	pending := verified("billing@example.com", "security@example.com", "ops@example.com")
+	pending.Security.NeedsVerification = true
+
+	type want struct {
+		obs        managed.ExternalObservation
+		ready      xpv1.ConditionReason
+		atProvider v1alpha1.ContactsObservation
+		err        bool
+	}
+	cases := map[string]struct {
+		reason string
+		client *fakeClient
+		cr     *v1alpha1.Contacts
+		want   want
+	}{
+		"Verified": {
+			reason: "Verified contacts matching the spec should be up to date and available",
+			client: &fakeClient{contacts: verified("billing@example.com", "security@example.com", "ops@example.com")},
+			cr:     newContacts("billing@example.com", "Security@example.com", "ops@example.com"),
+			want: want{
+				obs:   managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+				ready: xpv1.ReasonAvailable,
+				atProvider: v1alpha1.ContactsObservation{
+					ID:       ptr.To("example.com"),
+					Account:  []v1alpha1.ContactObservation{{Email: "billing@example.com", Verified: true}},
+					Security: []v1alpha1.ContactObservation{{Email: "security@example.com", Verified: true}},
+					Support:  []v1alpha1.ContactObservation{{Email: "ops@example.com", Verified: true}},
+				},
+			},
+		},
+		"PendingVerification": {
+			reason: "Contacts should not be ready until every address is verified",
+			client: &fakeClient{contacts: pending},
+			cr:     newContacts("billing@example.com", "security@example.com", "ops@example.com"),
+			want: want{
+				obs:   managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+				ready: ReasonPendingVerification,
+				atProvider: v1alpha1.ContactsObservation{
+					ID:       ptr.To("example.com"),
+					Account:  []v1alpha1.ContactObservation{{Email: "billing@example.com", Verified: true}},
+					Security: []v1alpha1.ContactObservation{{Email: "security@example.com"}},
+					Support:  []v1alpha1.ContactObservation{{Email: "ops@example.com", Verified: true}},
+				},
+			},
+		},
+		"Outdated": {
+			reason: "Contacts whose address differs from the spec should be updated",
+			client: &fakeClient{contacts: verified("billing@example.com", "old@example.com", "ops@example.com")},
+			cr:     newContacts("billing@example.com", "security@example.com", "ops@example.com"),
+			want: want{
+				obs:   managed.ExternalObservation{ResourceExists: true},
+				ready: xpv1.ReasonAvailable,
+				atProvider: v1alpha1.ContactsObservation{
+					ID:       ptr.To("example.com"),
+					Account:  []v1alpha1.ContactObservation{{Email: "billing@example.com", Verified: true}},
+					Security: []v1alpha1.ContactObservation{{Email: "old@example.com", Verified: true}},
+					Support:  []v1alpha1.ContactObservation{{Email: "ops@example.com", Verified: true}},
+				},
+			},
+		},
+		"NotAdopted": {
+			reason: "Contacts should be reported missing until Create adopted them",
+			client: &fakeClient{contacts: verified("billing@example.com", "security@example.com", "ops@example.com")},
+			cr: func() *v1alpha1.Contacts {
+				cr := newContacts("billing@example.com", "security@example.com", "ops@example.com")
+				meta.SetExternalName(cr, "")
+				return cr
+			}(),
+			want: want{
+				ready: xpv1.ReasonAvailable,
+				atProvider: v1alpha1.ContactsObservation{
+					ID:       ptr.To("example.com"),
+					Account:  []v1alpha1.ContactObservation{{Email: "billing@example.com", Verified: true}},
+					Security: []v1alpha1.ContactObservation{{Email: "security@example.com", Verified: true}},
+					Support:  []v1alpha1.ContactObservation{{Email: "ops@example.com", Verified: true}},
+				},
+			},
+		},
+		"ObserveOnly": {
+			reason: "Contacts that may not be created should be adopted right away",
+			client: &fakeClient{contacts: verified("billing@example.com", "security@example.com", "ops@example.com")},
+			cr: func() *v1alpha1.Contacts {
+				cr := newContacts("billing@example.com", "security@example.com", "ops@example.com")
+				meta.SetExternalName(cr, "")
+				cr.SetManagementPolicies(xpv1.ManagementPolicies{xpv1.ManagementActionObserve})
+				return cr
+			}(),
+			want: want{
+				obs:   managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ResourceLateInitialized: true},
+				ready: xpv1.ReasonAvailable,
+				atProvider: v1alpha1.ContactsObservation{
+					ID:       ptr.To("example.com"),
+					Account:  []v1alpha1.ContactObservation{{Email: "billing@example.com", Verified: true}},
+					Security: []v1alpha1.ContactObservation{{Email: "security@example.com", Verified: true}},
+					Support:  []v1alpha1.ContactObservation{{Email: "ops@example.com", Verified: true}},
+				},
+			},
+		},
+		"Deleted": {
+			reason: "Deleted contacts should be reported gone, they cannot be removed from the tailnet",
+			client: &fakeClient{err: errors.New("should not be called")},
+			cr: func() *v1alpha1.Contacts {
+				cr := newContacts("billing@example.com", "security@example.com", "ops@example.com")
+				cr.SetDeletionTimestamp(ptr.To(metav1.Now()))
+				return cr
+			}(),
+		},
+		"APIError": {
+			reason: "Errors of the API should be returned",
+			client: &fakeClient{err: errors.New("boom")},
+			cr:     newContacts("billing@example.com", "security@example.com", "ops@example.com"),
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			obs, err := (&external{client: tc.client}).Observe(context.Background(), tc.cr)
+			got := want{
+				obs:        obs,
+				ready:      tc.cr.GetCondition(xpv1.TypeReady).Reason,
+				atProvider: tc.cr.Status.AtProvider,
+				err:        err != nil,
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nObserve(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if err == nil && obs.ResourceExists && meta.GetExternalName(tc.cr) != "example.com" {
+				t.Errorf("\n%s\nObserve(...): external name = %q, want the tailnet", tc.reason, meta.GetExternalName(tc.cr))
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		current *tsapi.Contacts
+		cr      *v1alpha1.Contacts
+		want    map[string]string
+		err     bool
+	}{
+		"InitProvider": {
+			reason:  "Contacts only set in initProvider should be set on adoption",
+			current: verified("old@example.com", "old@example.com", "ops@example.com"),
+			cr: func() *v1alpha1.Contacts {
+				cr := &v1alpha1.Contacts{ObjectMeta: metav1.ObjectMeta{Name: "contacts"}}
+				cr.Spec.ForProvider.Security = []v1alpha1.Contact{{Email: "security@example.com"}}
+				cr.Spec.InitProvider.Account = []v1alpha1.Contact{{Email: "billing@example.com"}}
+				cr.Spec.InitProvider.Security = []v1alpha1.Contact{{Email: "ignored@example.com"}}
+				return cr
+			}(),
+			want: map[string]string{tsapi.ContactAccount: "billing@example.com", tsapi.ContactSecurity: "security@example.com"},
+		},
+		"Invalid": {
+			reason:  "Invalid addresses should not be sent to the API",
+			current: verified("billing@example.com", "old@example.com", "ops@example.com"),
+			cr:      newContacts("billing@example.com", "security", "ops@example.com"),
+			err:     true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &fakeClient{contacts: tc.current}
+			_, err := (&external{client: c}).Create(context.Background(), tc.cr)
+			if (err != nil) != tc.err {
+				t.Errorf("\n%s\nCreate(...): unexpected error state: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, c.updated); diff != "" {
+				t.Errorf("\n%s\nCreate(...): -want updated, +got updated:\n%s", tc.reason, diff)
+			}
+			if name := meta.GetExternalName(tc.cr); !tc.err && name != "example.com" {
+				t.Errorf("\n%s\nCreate(...): external name = %q, want the tailnet", tc.reason, name)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		current *tsapi.Contacts
+		cr      *v1alpha1.Contacts
+		want    map[string]string
+		err     bool
+	}{
+		"OnlyOutdated": {
+			reason:  "Only contacts whose address changed should be updated, to not send verification emails again",
+			current: verified("billing@example.com", "old@example.com", "ops@example.com"),
+			cr:      newContacts("billing@example.com", "security@example.com", "ops@example.com"),
+			want:    map[string]string{tsapi.ContactSecurity: "security@example.com"},
+		},
+		"IgnoresInitProvider": {
+			reason:  "Contacts only set in initProvider should not be updated once adopted",
+			current: verified("billing@example.com", "old@example.com", "ops@example.com"),
+			cr: func() *v1alpha1.Contacts {
+				cr := newContacts("billing@example.com", "old@example.com", "ops@example.com")
+				cr.Spec.ForProvider.Security = nil
+				cr.Spec.InitProvider.Security = []v1alpha1.Contact{{Email: "security@example.com"}}
+				return cr
+			}(),
+		},
+		"Invalid": {
+			reason:  "Invalid addresses should not be sent to the API",
+			current: verified("billing@example.com", "old@example.com", "ops@example.com"),
+			cr:      newContacts("billing@example.com", "security", "ops@example.com"),
+			err:     true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &fakeClient{contacts: tc.current}
+			_, err := (&external{client: c}).Update(context.Background(), tc.cr)
+			if (err != nil) != tc.err {
+				t.Errorf("\n%s\nUpdate(...): unexpected error state: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, c.updated); diff != "" {
+				t.Errorf("\n%s\nUpdate(...): -want updated, +got updated:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
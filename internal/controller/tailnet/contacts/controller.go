@@ -0,0 +1,212 @@
+package contacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	xpfeature "github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/statemetrics"
+	"github.com/crossplane/upjet/v2/pkg/controller"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/tailnet/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/features"
+)
+
+const errNotContacts = "managed resource is not a Contacts"
+
+// contactsClient is the part of the Tailscale API used by this package.
+type contactsClient interface {
+	Tailnet() string
+	GetContacts(ctx context.Context) (*tsapi.Contacts, error)
+	UpdateContact(ctx context.Context, contactType, email string) error
+}
+
+// newClientFn returns an API client for the tailnet of the contacts.
+type newClientFn func(ctx context.Context, kube client.Client, mg resource.Managed) (contactsClient, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, mg resource.Managed) (contactsClient, error) {
+	return tsapi.NewForManaged(ctx, kube, mg)
+}
+
+// Setup adds a controller that reconciles Contacts.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.Contacts_GroupVersionKind.String())
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(&connector{kube: mgr.GetClient(), newClient: newAPIClient}),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))),
+		managed.WithTimeout(3 * time.Minute),
+		// The external name is the tailnet, set on the first observation.
+		managed.WithInitializers(),
+		managed.WithPollInterval(o.PollInterval),
+	}
+	if o.PollJitter != 0 {
+		opts = append(opts, managed.WithPollJitterHook(o.PollJitter))
+	}
+	if o.Features.Enabled(features.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+	if o.MetricOptions != nil {
+		opts = append(opts, managed.WithMetricRecorder(o.MetricOptions.MRMetrics))
+	}
+	if o.MetricOptions != nil && o.MetricOptions.MRStateMetrics != nil {
+		stateMetricsRecorder := statemetrics.NewMRStateRecorder(
+			mgr.GetClient(), o.Logger, o.MetricOptions.MRStateMetrics, &v1alpha1.ContactsList{}, o.MetricOptions.PollStateMetricInterval,
+		)
+		if err := mgr.Add(stateMetricsRecorder); err != nil {
+			return fmt.Errorf("cannot register MR state metrics recorder for kind v1alpha1.ContactsList: %w", err)
+		}
+	}
+	if o.Features.Enabled(xpfeature.EnableAlphaChangeLogs) {
+		opts = append(opts, managed.WithChangeLogger(o.ChangeLogOptions.ChangeLogger))
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.Contacts_GroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.Contacts{}).
+		Complete(ratelimiter.NewReconciler(name, r, o.GlobalRateLimiter))
+}
+
+// SetupGated adds the controller once the Contacts CRD is installed.
+func SetupGated(mgr ctrl.Manager, o controller.Options) error {
+	o.Options.Gate.Register(func() {
+		if err := Setup(mgr, o); err != nil {
+			mgr.GetLogger().Error(err, "unable to setup reconciler", "gvk", v1alpha1.Contacts_GroupVersionKind.String())
+		}
+	}, v1alpha1.Contacts_GroupVersionKind)
+	return nil
+}
+
+type connector struct {
+	kube      client.Client
+	newClient newClientFn
+}
+
+// Connect returns an external client using the credentials of the
+// ProviderConfig of the contacts.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.Contacts); !ok {
+		return nil, errors.New(errNotContacts)
+	}
+	ts, err := c.newClient(ctx, c.kube, mg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Tailscale API client: %w", err)
+	}
+	return &external{client: ts}, nil
+}
+
+type external struct {
+	client contactsClient
+}
+
+// Observe reports the contacts as existing once the resource adopted them,
+// and as up to date when their addresses match the spec. Until then they
+// are reported as not existing, so that Create applies spec.initProvider,
+// unless the management policies do not allow creating. The Ready
+// condition reflects their verification.
+func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.Contacts)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotContacts)
+	}
+	// Contacts cannot be removed from a tailnet, they are left as they are.
+	if meta.WasDeleted(cr) {
+		return managed.ExternalObservation{}, nil
+	}
+	c, err := e.client.GetContacts(ctx)
+	if err != nil {
+		return managed.ExternalObservation{}, fmt.Errorf("cannot get contacts: %w", err)
+	}
+	cr.Status.AtProvider = Observation(e.client.Tailnet(), c)
+	cr.SetConditions(Ready(c))
+
+	named := false
+	if meta.GetExternalName(cr) == "" {
+		if creatable(cr) {
+			return managed.ExternalObservation{}, nil
+		}
+		meta.SetExternalName(cr, e.client.Tailnet())
+		named = true
+	}
+	return managed.ExternalObservation{
+		ResourceExists:          true,
+		ResourceUpToDate:        len(Outdated(cr.Spec.ForProvider, c)) == 0,
+		ResourceLateInitialized: named,
+	}, nil
+}
+
+// Create adopts the contacts of the tailnet, setting those of
+// spec.forProvider and spec.initProvider.
+func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.Contacts)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotContacts)
+	}
+	if err := e.apply(ctx, Initial(cr)); err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	meta.SetExternalName(cr, e.client.Tailnet())
+	return managed.ExternalCreation{}, nil
+}
+
+// Update changes the addresses of the contacts that differ from
+// spec.forProvider.
+func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.Contacts)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotContacts)
+	}
+	return managed.ExternalUpdate{}, e.apply(ctx, cr.Spec.ForProvider)
+}
+
+// apply changes the addresses of the contacts that differ from the supplied
+// ones. Tailscale sends a verification email to each new address.
+func (e *external) apply(ctx context.Context, p v1alpha1.ContactsParameters) error {
+	if err := Validate(p); err != nil {
+		return err
+	}
+	c, err := e.client.GetContacts(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get contacts: %w", err)
+	}
+	want := desired(p)
+	for _, t := range Outdated(p, c) {
+		if err := e.client.UpdateContact(ctx, t, want[t]); err != nil {
+			return fmt.Errorf("cannot update %s contact: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// Delete does nothing, contacts cannot be removed from a tailnet.
+func (e *external) Delete(_ context.Context, _ resource.Managed) (managed.ExternalDelete, error) {
+	return managed.ExternalDelete{}, nil
+}
+
+// creatable reports whether the management policies of the contacts allow
+// Create to adopt them.
+func creatable(cr *v1alpha1.Contacts) bool {
+	p := cr.GetManagementPolicies()
+	return len(p) == 0 || slices.Contains(p, xpv1.ManagementActionAll) || slices.Contains(p, xpv1.ManagementActionCreate)
+}
+
+// Disconnect does nothing, the API client holds no connection.
+func (e *external) Disconnect(_ context.Context) error {
+	return nil
+}
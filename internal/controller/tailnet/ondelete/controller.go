@@ -0,0 +1,133 @@
+package ondelete
+
+import (
+	"context"
+	"fmt"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/upjet/v2/pkg/controller"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/acl/lock"
+)
+
+const controllerName = "on-delete.settings.tailnet.tailscale.upbound.io"
+
+// newSettings returns an empty Settings resource. The type is looked up in
+// the scheme to avoid importing the generated API package.
+func newSettings(s *runtime.Scheme) (resource.Managed, error) {
+	o, err := s.New(settingsGroupVersionKind)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create %s: %w", settingsGroupVersionKind, err)
+	}
+	mg, ok := o.(resource.Managed)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a managed resource", settingsGroupVersionKind)
+	}
+	return mg, nil
+}
+
+// Setup adds a controller that restores tailnet settings when a Settings
+// resource holding the on-delete finalizer is deleted.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	obj, err := newSettings(mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+	r := &Reconciler{
+		kube:      mgr.GetClient(),
+		scheme:    mgr.GetScheme(),
+		log:       o.Logger.WithValues("controller", controllerName),
+		record:    event.NewAPIRecorder(mgr.GetEventRecorderFor(controllerName)),
+		newClient: newAPIClient,
+	}
+	hasFinalizer := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return meta.FinalizerExists(obj, Finalizer)
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(o.ForControllerRuntime()).
+		For(obj, builder.WithPredicates(hasFinalizer)).
+		Complete(r)
+}
+
+// SetupGated adds the controller; the Settings CRD is part of the package.
+func SetupGated(mgr ctrl.Manager, o controller.Options) error {
+	return Setup(mgr, o)
+}
+
+// Reconciler restores tailnet settings for deleted Settings resources.
+type Reconciler struct {
+	kube      client.Client
+	scheme    *runtime.Scheme
+	log       logging.Logger
+	record    event.Recorder
+	newClient newClientFn
+}
+
+// Reconcile a Settings resource.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	mg, err := newSettings(r.scheme)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.kube.Get(ctx, req.NamespacedName, mg); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if meta.WasDeleted(mg) && meta.FinalizerExists(mg, Finalizer) {
+		if err := r.restore(ctx, mg); err != nil {
+			r.record.Event(mg, event.Warning(reasonCannotRestore, err))
+			return reconcile.Result{}, err
+		}
+		meta.RemoveFinalizer(mg, Finalizer)
+		return reconcile.Result{}, r.kube.Update(ctx, mg)
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *Reconciler) restore(ctx context.Context, mg resource.Managed) error {
+	p, err := PolicyOf(mg)
+	if err != nil {
+		return err
+	}
+	if lm, ok := mg.(resource.LegacyManaged); ok && lm.GetDeletionPolicy() == xpv1.DeletionOrphan {
+		r.log.Debug("Not restoring settings of orphaned resource", "name", mg.GetName())
+		return nil
+	}
+	target, err := restoreTarget(p, mg)
+	if err != nil {
+		// Don't block deletion forever on a snapshot that will never appear.
+		r.record.Event(mg, event.Warning(reasonCannotRestore, err))
+		return nil
+	}
+	if target == nil {
+		return nil
+	}
+	// An ACL holding the console lock owns these, don't unlock the console
+	// underneath it.
+	held, err := lock.Held(ctx, r.kube)
+	if err != nil {
+		return err
+	}
+	if held {
+		target.ACLsExternallyManagedOn, target.ACLsExternalLink = nil, nil
+	}
+	c, err := r.newClient(ctx, r.kube, mg)
+	if err != nil {
+		return err
+	}
+	if err := c.UpdateSettings(ctx, *target); err != nil {
+		return fmt.Errorf("cannot restore tailnet settings: %w", err)
+	}
+	r.record.Event(mg, event.Normal(reasonRestored, fmt.Sprintf("Restored tailnet settings (%s)", p)))
+	return nil
+}
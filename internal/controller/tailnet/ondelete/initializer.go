@@ -0,0 +1,86 @@
+package ondelete
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+// settingsClient is the part of the Tailscale API used by this package.
+type settingsClient interface {
+	GetSettings(ctx context.Context) (*tsapi.Settings, error)
+	UpdateSettings(ctx context.Context, s tsapi.Settings) error
+}
+
+// newClientFn returns an API client for the tailnet of a managed resource.
+type newClientFn func(ctx context.Context, kube client.Client, mg resource.Managed) (settingsClient, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, mg resource.Managed) (settingsClient, error) {
+	return tsapi.NewForManaged(ctx, kube, mg)
+}
+
+// Initializer adds the on-delete finalizer to Settings resources with a
+// restore policy and captures the current settings for RestoreAdopted.
+type Initializer struct {
+	kube      client.Client
+	newClient newClientFn
+}
+
+// NewInitializer returns an Initializer using the supplied client. Its
+// signature matches config.NewInitializerFn.
+func NewInitializer(kube client.Client) managed.Initializer {
+	return &Initializer{kube: kube, newClient: newAPIClient}
+}
+
+// Initialize runs before the Settings are observed, so for a new resource
+// the captured values are the ones the tailnet had before adoption.
+// Resources being deleted are skipped; the finalizer must not be added back
+// once the controller has removed it.
+func (i *Initializer) Initialize(ctx context.Context, mg resource.Managed) error {
+	if meta.WasDeleted(mg) {
+		return nil
+	}
+	p, err := PolicyOf(mg)
+	if err != nil {
+		return err
+	}
+	if p == PolicyRetain {
+		return nil
+	}
+
+	changed := false
+	if !meta.FinalizerExists(mg, Finalizer) {
+		meta.AddFinalizer(mg, Finalizer)
+		changed = true
+	}
+	if _, ok := mg.GetAnnotations()[AnnotationAdopted]; p == PolicyRestoreAdopted && !ok {
+		c, err := i.newClient(ctx, i.kube, mg)
+		if err != nil {
+			return err
+		}
+		s, err := c.GetSettings(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot capture tailnet settings: %w", err)
+		}
+		b, err := json.Marshal(s)
+		if err != nil {
+			return fmt.Errorf("cannot encode tailnet settings: %w", err)
+		}
+		meta.AddAnnotations(mg, map[string]string{AnnotationAdopted: string(b)})
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	if err := i.kube.Update(ctx, mg); err != nil {
+		return fmt.Errorf("cannot record on-delete policy state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Package ondelete decides what happens to the tailnet settings when a
+// Settings resource is deleted. Deleting the Terraform resource leaves the
+// settings as they are, which is rarely what is wanted when the resource is
+// removed to hand the tailnet back to someone else.
+//
+// The behaviour is selected with spec.forProvider.onDelete, one of Retain,
+// RestoreDefaults or RestoreAdopted, which the CRD validates.
+//
+// Retain, the default, leaves the settings as they are. RestoreDefaults
+// resets them to the values of a new tailnet. RestoreAdopted restores the
+// values the tailnet had when the resource first reconciled them; an
+// Initializer captures those values into an annotation before anything is
+// applied. For both restore policies a finalizer holds the resource until a
+// companion controller has restored the settings.
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration.
+package ondelete
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+// Policy is an on-delete policy.
+type Policy string
+
+// On-delete policies.
+const (
+	PolicyRetain          Policy = "Retain"
+	PolicyRestoreDefaults Policy = "RestoreDefaults"
+	PolicyRestoreAdopted  Policy = "RestoreAdopted"
+)
+
+const (
+	// AnnotationAdopted holds the settings captured at adoption, as JSON.
+	AnnotationAdopted = "tailnet.tailscale.upbound.io/adopted-settings"
+
+	// Finalizer holds a Settings resource until its settings are restored.
+	Finalizer = "tailnet.tailscale.upbound.io/on-delete"
+
+	reasonRestored      = "RestoredSettings"
+	reasonCannotRestore = "CannotRestoreSettings"
+)
+
+// settingsGroupVersionKind is the GVK of the Settings managed resource.
+var settingsGroupVersionKind = schema.GroupVersionKind{
+	Group:   "tailnet.tailscale.upbound.io",
+	Version: "v1alpha1",
+	Kind:    "Settings",
+}
+
+// PolicyOf returns the on-delete policy of the supplied resource.
+func PolicyOf(mg runtime.Object) (Policy, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mg)
+	if err != nil {
+		return "", fmt.Errorf("cannot convert Settings: %w", err)
+	}
+	p, _, _ := unstructured.NestedString(u, "spec", "forProvider", "onDelete")
+	switch Policy(p) {
+	case "":
+		return PolicyRetain, nil
+	case PolicyRetain, PolicyRestoreDefaults, PolicyRestoreAdopted:
+		return Policy(p), nil
+	}
+	return "", fmt.Errorf("spec.forProvider.onDelete must be one of %s, %s or %s, got %q", PolicyRetain, PolicyRestoreDefaults, PolicyRestoreAdopted, p)
+}
+
+// restoreTarget returns the settings to restore for the supplied policy.
+func restoreTarget(p Policy, mg resource.Object) (*tsapi.Settings, error) {
+	switch p {
+	case PolicyRestoreDefaults:
+		s := tsapi.DefaultSettings()
+		return &s, nil
+	case PolicyRestoreAdopted:
+		raw, ok := mg.GetAnnotations()[AnnotationAdopted]
+		if !ok {
+			return nil, fmt.Errorf("no settings were captured at adoption (%s is missing)", AnnotationAdopted)
+		}
+		s := &tsapi.Settings{}
+		if err := json.Unmarshal([]byte(raw), s); err != nil {
+			return nil, fmt.Errorf("cannot decode %s: %w", AnnotationAdopted, err)
+		}
+		return s, nil
+	default:
+		return nil, nil
+	}
+}
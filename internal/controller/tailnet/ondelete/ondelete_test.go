@@ -0,0 +1,246 @@
+package ondelete
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tailnetv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/tailnet/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/acl/lock"
+)
+
+type fakeSettingsClient struct {
+	current *tsapi.Settings
+	updated *tsapi.Settings
+	err     error
+}
+
+func (f *fakeSettingsClient) GetSettings(context.Context) (*tsapi.Settings, error) {
+	return f.current, f.err
+}
+
+func (f *fakeSettingsClient) UpdateSettings(_ context.Context, s tsapi.Settings) error {
+	f.updated = &s
+	return f.err
+}
+
+func (f *fakeSettingsClient) newClient(context.Context, client.Client, resource.Managed) (settingsClient, error) {
+	return f, nil
+}
+
+func newSettingsResource(p Policy, annotations map[string]string, finalizers ...string) *tailnetv1alpha1.Settings {
+	mg := &tailnetv1alpha1.Settings{ObjectMeta: metav1.ObjectMeta{
+		Name:        "settings",
+		Annotations: annotations,
+		Finalizers:  finalizers,
+	}}
+	if p != "" {
+		mg.Spec.ForProvider.OnDelete = ptr.To(string(p))
+	}
+	return mg
+}
+
+func TestPolicyOf(t *testing.T) {
+	cases := map[string]struct {
+		onDelete Policy
+		want     Policy
+		err      bool
+	}{
+		"Default":  {want: PolicyRetain},
+		"Restore":  {onDelete: "RestoreDefaults", want: PolicyRestoreDefaults},
+		"Invalid":  {onDelete: "Delete", err: true},
+		"Explicit": {onDelete: "Retain", want: PolicyRetain},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := PolicyOf(newSettingsResource(tc.onDelete, nil))
+			if (err != nil) != tc.err {
+				t.Errorf("PolicyOf(...): unexpected error state: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("PolicyOf(...) = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	type want struct {
+		finalizer bool
+		adopted   string
+		updated   bool
+	}
+	cases := map[string]struct {
+		reason string
+		mg     *tailnetv1alpha1.Settings
+		want   want
+	}{
+		"Retain": {
+			reason: "Resources without a restore policy should be left alone",
+			mg:     newSettingsResource("", nil),
+		},
+		"RestoreDefaults": {
+			reason: "Restoring defaults only needs the finalizer",
+			mg:     newSettingsResource(PolicyRestoreDefaults, nil),
+			want:   want{finalizer: true, updated: true},
+		},
+		"RestoreAdopted": {
+			reason: "The current settings should be captured before they are changed",
+			mg:     newSettingsResource(PolicyRestoreAdopted, nil),
+			want:   want{finalizer: true, adopted: `{"devicesKeyDurationDays":90}`, updated: true},
+		},
+		"Deleted": {
+			reason: "Resources being deleted should not get the finalizer back",
+			mg: func() *tailnetv1alpha1.Settings {
+				mg := newSettingsResource(PolicyRestoreAdopted, nil)
+				mg.SetDeletionTimestamp(ptr.To(metav1.Now()))
+				return mg
+			}(),
+		},
+		"AlreadyCaptured": {
+			reason: "Captured settings must never be overwritten",
+			mg:     newSettingsResource(PolicyRestoreAdopted, map[string]string{AnnotationAdopted: `{}`}, Finalizer),
+			want:   want{finalizer: true, adopted: `{}`},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			updated := false
+			kube := &test.MockClient{MockUpdate: func(context.Context, client.Object, ...client.UpdateOption) error {
+				updated = true
+				return nil
+			}}
+			api := &fakeSettingsClient{current: &tsapi.Settings{DevicesKeyDurationDays: ptr.To(90)}}
+			i := &Initializer{kube: kube, newClient: api.newClient}
+			if err := i.Initialize(context.Background(), tc.mg); err != nil {
+				t.Fatalf("\n%s\nInitialize(...): unexpected error: %v", tc.reason, err)
+			}
+			got := want{
+				finalizer: meta.FinalizerExists(tc.mg, Finalizer),
+				adopted:   tc.mg.GetAnnotations()[AnnotationAdopted],
+				updated:   updated,
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	deleted := func(mg *tailnetv1alpha1.Settings) *tailnetv1alpha1.Settings {
+		mg.SetDeletionTimestamp(ptr.To(metav1.Now()))
+		return mg
+	}
+	orphan := deleted(newSettingsResource(PolicyRestoreDefaults, nil, Finalizer))
+	orphan.SetDeletionPolicy(xpv1.DeletionOrphan)
+
+	type want struct {
+		restored         *tsapi.Settings
+		finalizerRemoved bool
+		err              bool
+	}
+	cases := map[string]struct {
+		reason string
+		mg     *tailnetv1alpha1.Settings
+		apiErr error
+		locked bool
+		want   want
+	}{
+		"NotDeleted": {
+			reason: "Nothing should happen until the resource is deleted",
+			mg:     newSettingsResource(PolicyRestoreDefaults, nil, Finalizer),
+		},
+		"RestoreDefaults": {
+			reason: "The defaults should be restored and the finalizer removed",
+			mg:     deleted(newSettingsResource(PolicyRestoreDefaults, nil, Finalizer)),
+			want:   want{restored: ptr.To(tsapi.DefaultSettings()), finalizerRemoved: true},
+		},
+		"RestoreAdopted": {
+			reason: "The captured settings should be restored",
+			mg: deleted(newSettingsResource(PolicyRestoreAdopted, map[string]string{
+				AnnotationAdopted: `{"devicesApprovalOn":true}`,
+			}, Finalizer)),
+			want: want{restored: &tsapi.Settings{DevicesApprovalOn: ptr.To(true)}, finalizerRemoved: true},
+		},
+		"MissingSnapshot": {
+			reason: "A missing snapshot should not block deletion",
+			mg:     deleted(newSettingsResource(PolicyRestoreAdopted, nil, Finalizer)),
+			want:   want{finalizerRemoved: true},
+		},
+		"ConsoleLocked": {
+			reason: "The console lock of an ACL should not be restored",
+			mg:     deleted(newSettingsResource(PolicyRestoreDefaults, nil, Finalizer)),
+			locked: true,
+			want: want{restored: func() *tsapi.Settings {
+				s := tsapi.DefaultSettings()
+				s.ACLsExternallyManagedOn = nil
+				return &s
+			}(), finalizerRemoved: true},
+		},
+		"Orphan": {
+			reason: "Orphaned resources should leave the tailnet alone",
+			mg:     orphan,
+			want:   want{finalizerRemoved: true},
+		},
+		"APIError": {
+			reason: "API errors should be retried and keep the finalizer",
+			mg:     deleted(newSettingsResource(PolicyRestoreDefaults, nil, Finalizer)),
+			apiErr: errors.New("boom"),
+			want:   want{restored: ptr.To(tsapi.DefaultSettings()), err: true},
+		},
+	}
+
+	s := runtime.NewScheme()
+	if err := tailnetv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			removed := false
+			kube := &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					tc.mg.DeepCopyInto(obj.(*tailnetv1alpha1.Settings))
+					return nil
+				}),
+				MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+					if l, ok := obj.(*metav1.PartialObjectMetadataList); ok && tc.locked {
+						l.Items = []metav1.PartialObjectMetadata{{ObjectMeta: metav1.ObjectMeta{Name: "acl", Finalizers: []string{lock.Finalizer}}}}
+					}
+					return nil
+				}),
+				MockUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+					removed = !meta.FinalizerExists(obj, Finalizer)
+					return nil
+				},
+			}
+			api := &fakeSettingsClient{err: tc.apiErr}
+			r := &Reconciler{kube: kube, scheme: s, log: logging.NewNopLogger(), record: event.NewNopRecorder(), newClient: api.newClient}
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Name: "settings"}})
+			if (err != nil) != tc.want.err {
+				t.Errorf("\n%s\nReconcile(...): unexpected error state: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.restored, api.updated); diff != "" {
+				t.Errorf("\n%s\nReconcile(...): -want restored, +got restored:\n%s", tc.reason, diff)
+			}
+			if removed != tc.want.finalizerRemoved {
+				t.Errorf("\n%s\nReconcile(...): finalizer removed = %t, want %t", tc.reason, removed, tc.want.finalizerRemoved)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Package endpoint validates the subscriptions, provider type and endpoint
+// URL of webhooks.
+//
+// The Terraform provider accepts any subscription, and the API only rejects
+// an unknown one, such as nodeCreate instead of nodeCreated, when the
+// webhook is created or updated. An Initializer checks subscriptions
+// against the events Tailscale documents, that the provider type is known,
+// and that the endpoint URL points at the host of that provider, and the
+// Helm chart's ValidatingAdmissionPolicy rejects such webhooks at
+// admission.
+//
+// Events introduced by Tailscale after this catalogue was written can be
+// allowed by annotating the webhook with
+// webhook.tailscale.upbound.io/allow-unknown-events: "true".
+//
+// This package must not import the generated API packages because it is
+// referenced from the provider configuration.
+package endpoint
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// AnnotationAllowUnknown skips the check of subscriptions against the
+// catalogue when "true".
+const AnnotationAllowUnknown = "webhook.tailscale.upbound.io/allow-unknown-events"
+
+// Events lists the events a webhook can subscribe to, see
+// https://tailscale.com/kb/1213/webhooks#events. Keep it in sync with the
+// webhook ValidatingAdmissionPolicy of the Helm chart.
+var Events = []string{
+	// Tailnet management
+	"nodeCreated", "nodeNeedsApproval", "nodeApproved",
+	"nodeKeyExpiringInOneDay", "nodeKeyExpired", "nodeDeleted",
+	"nodeSigned", "nodeNeedsSignature",
+	"policyUpdate",
+	"userCreated", "userNeedsApproval", "userSuspended", "userRestored",
+	"userDeleted", "userApproved", "userRoleUpdated",
+	"webhookUpdated", "webhookDeleted",
+	// Device misconfiguration
+	"subnetIPForwardingNotEnabled", "exitNodeIPForwardingNotEnabled",
+}
+
+// ProviderGeneric formats payloads as JSON, like an unset provider type.
+// The Terraform provider does not know it, so it is never sent to the API.
+const ProviderGeneric = "generic"
+
+// Destination describes the endpoints of a provider type.
+type Destination struct {
+	// Hosts the endpoint must be on, either exactly or as a subdomain. Any
+	// host is allowed if empty, as for self-hosted chat servers.
+	Hosts []string
+
+	// PathPrefix the endpoint path must start with, if any.
+	PathPrefix string
+}
+
+// Providers maps the provider types to their destinations.
+var Providers = map[string]Destination{
+	"slack":         {Hosts: []string{"hooks.slack.com"}},
+	"mattermost":    {PathPrefix: "/hooks/"},
+	"googlechat":    {Hosts: []string{"chat.googleapis.com"}, PathPrefix: "/v1/spaces/"},
+	"discord":       {Hosts: []string{"discord.com", "discordapp.com"}, PathPrefix: "/api/webhooks/"},
+	ProviderGeneric: {},
+}
+
+// Validate checks the subscriptions, provider type and endpoint of a
+// webhook. Unknown subscriptions are only allowed if allowUnknown is set.
+func Validate(subscriptions []string, providerType, endpoint string, allowUnknown bool) error {
+	if err := ValidateSubscriptions(subscriptions, allowUnknown); err != nil {
+		return err
+	}
+	return ValidateEndpoint(providerType, endpoint)
+}
+
+// ValidateSubscriptions checks subscriptions against the catalogue.
+func ValidateSubscriptions(subscriptions []string, allowUnknown bool) error {
+	if len(subscriptions) == 0 {
+		return fmt.Errorf("subscriptions: at least one event is required")
+	}
+	if allowUnknown {
+		return nil
+	}
+	var unknown []string
+	for _, s := range subscriptions {
+		if !slices.Contains(Events, s) {
+			unknown = append(unknown, s)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("subscriptions: unknown events %s, see https://tailscale.com/kb/1213/webhooks#events or annotate the webhook with %s: \"true\"",
+			strings.Join(unknown, ", "), AnnotationAllowUnknown)
+	}
+	return nil
+}
+
+// ValidateEndpoint checks that the provider type is known and that the
+// endpoint URL is one of its endpoints. An empty provider type is generic.
+func ValidateEndpoint(providerType, endpoint string) error {
+	if providerType == "" {
+		providerType = ProviderGeneric
+	}
+	d, ok := Providers[providerType]
+	if !ok {
+		return fmt.Errorf("providerType: unknown provider type %q, must be one of %s", providerType, strings.Join(ProviderTypes(), ", "))
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("endpointUrl: %q is not an absolute HTTP(S) URL", endpoint)
+	}
+	if len(d.Hosts) > 0 {
+		if u.Scheme != "https" {
+			return fmt.Errorf("endpointUrl: %s endpoints must use https", providerType)
+		}
+		if !onHost(u.Hostname(), d.Hosts) {
+			return fmt.Errorf("endpointUrl: %s endpoints must be on %s, not %s", providerType, strings.Join(d.Hosts, " or "), u.Hostname())
+		}
+	}
+	if d.PathPrefix != "" && !strings.HasPrefix(u.Path, d.PathPrefix) {
+		return fmt.Errorf("endpointUrl: the path of %s endpoints must start with %s", providerType, d.PathPrefix)
+	}
+	return nil
+}
+
+// ProviderTypes returns the known provider types, sorted.
+func ProviderTypes() []string {
+	out := make([]string, 0, len(Providers))
+	for t := range Providers {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func onHost(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package endpoint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	webhookv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/webhook/v1alpha1"
+)
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		reason        string
+		subscriptions []string
+		providerType  string
+		endpoint      string
+		allowUnknown  bool
+		err           string
+	}{
+		"Generic": {
+			reason:        "Known events sent to any URL should be accepted without a provider type",
+			subscriptions: []string{"nodeCreated", "policyUpdate"},
+			endpoint:      "https://hooks.example.com/tailscale",
+		},
+		"Typo": {
+			reason:        "Events missing from the catalogue should be rejected",
+			subscriptions: []string{"nodeCreate", "nodeDeleted"},
+			endpoint:      "https://hooks.example.com/tailscale",
+			err:           `subscriptions: unknown events nodeCreate, see https://tailscale.com/kb/1213/webhooks#events or annotate the webhook with webhook.tailscale.upbound.io/allow-unknown-events: "true"`,
+		},
+		"AllowUnknown": {
+			reason:        "Unknown events should be accepted when explicitly allowed",
+			subscriptions: []string{"nodeRenamed"},
+			endpoint:      "https://hooks.example.com/tailscale",
+			allowUnknown:  true,
+		},
+		"NoSubscriptions": {
+			reason:   "At least one event should be required",
+			endpoint: "https://hooks.example.com/tailscale",
+			err:      "subscriptions: at least one event is required",
+		},
+		"UnknownProviderType": {
+			reason:        "Provider types Tailscale does not format payloads for should be rejected",
+			subscriptions: []string{"nodeCreated"},
+			providerType:  "teams",
+			endpoint:      "https://hooks.example.com/tailscale",
+			err:           `providerType: unknown provider type "teams", must be one of discord, generic, googlechat, mattermost, slack`,
+		},
+		"Slack": {
+			reason:        "Slack endpoints should be accepted",
+			subscriptions: []string{"nodeCreated"},
+			providerType:  "slack",
+			endpoint:      "https://hooks.slack.com/services/T000/B000/XXXX",
+		},
+		"SlackWrongHost": {
+			reason:        "Slack payloads sent elsewhere should be rejected",
+			subscriptions: []string{"nodeCreated"},
+			providerType:  "slack",
+			endpoint:      "https://discord.com/api/webhooks/1/abc",
+			err:           "endpointUrl: slack endpoints must be on hooks.slack.com, not discord.com",
+		},
+		"DiscordSubdomain": {
+			reason:        "Subdomains of the provider's host should be accepted",
+			subscriptions: []string{"nodeCreated"},
+			providerType:  "discord",
+			endpoint:      "https://canary.discord.com/api/webhooks/1/abc",
+		},
+		"DiscordPath": {
+			reason:        "Endpoints on the provider's host that are not webhooks should be rejected",
+			subscriptions: []string{"nodeCreated"},
+			providerType:  "discord",
+			endpoint:      "https://discord.com/channels/1",
+			err:           "endpointUrl: the path of discord endpoints must start with /api/webhooks/",
+		},
+		"GoogleChatPlainHTTP": {
+			reason:        "Endpoints of hosted providers should use https",
+			subscriptions: []string{"nodeCreated"},
+			providerType:  "googlechat",
+			endpoint:      "http://chat.googleapis.com/v1/spaces/AAA/messages",
+			err:           "endpointUrl: googlechat endpoints must use https",
+		},
+		"MattermostSelfHosted": {
+			reason:        "Mattermost endpoints should be accepted on any host",
+			subscriptions: []string{"nodeCreated"},
+			providerType:  "mattermost",
+			endpoint:      "https://chat.example.com/hooks/xxx",
+		},
+		"RelativeURL": {
+			reason:        "Endpoints should be absolute URLs",
+			subscriptions: []string{"nodeCreated"},
+			endpoint:      "/webhooks/events",
+			err:           `endpointUrl: "/webhooks/events" is not an absolute HTTP(S) URL`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tc.subscriptions, tc.providerType, tc.endpoint, tc.allowUnknown)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if diff := cmp.Diff(tc.err, got); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	newWebhook := func(providerType, endpoint string, subscriptions ...string) *webhookv1alpha1.Webhook {
+		w := &webhookv1alpha1.Webhook{ObjectMeta: metav1.ObjectMeta{Name: "events"}}
+		w.Spec.ForProvider.EndpointURL = ptr.To(endpoint)
+		if providerType != "" {
+			w.Spec.ForProvider.ProviderType = ptr.To(providerType)
+		}
+		for _, s := range subscriptions {
+			w.Spec.InitProvider.Subscriptions = append(w.Spec.InitProvider.Subscriptions, ptr.To(s))
+		}
+		return w
+	}
+
+	type want struct {
+		err          bool
+		providerType *string
+	}
+	cases := map[string]struct {
+		reason string
+		mg     *webhookv1alpha1.Webhook
+		want   want
+	}{
+		"Valid": {
+			reason: "Valid webhooks should be left alone",
+			mg:     newWebhook("slack", "https://hooks.slack.com/services/T/B/X", "nodeCreated"),
+			want:   want{providerType: ptr.To("slack")},
+		},
+		"Generic": {
+			reason: "A generic provider type should be removed before it reaches Terraform",
+			mg:     newWebhook("generic", "https://hooks.example.com/tailscale", "nodeCreated"),
+		},
+		"Invalid": {
+			reason: "Webhooks with unknown events should not be reconciled",
+			mg:     newWebhook("", "https://hooks.example.com/tailscale", "nodeCreate"),
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := (&Initializer{}).Initialize(context.Background(), tc.mg)
+			got := want{err: err != nil, providerType: tc.mg.Spec.ForProvider.ProviderType}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nInitialize(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	ujresource "github.com/crossplane/upjet/v2/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Terraform arguments of the webhook.
+const (
+	paramEndpointURL   = "endpoint_url"
+	paramProviderType  = "provider_type"
+	paramSubscriptions = "subscriptions"
+)
+
+// Initializer validates the subscriptions and endpoint of a webhook.
+type Initializer struct{}
+
+// NewInitializer returns an Initializer. Its signature matches
+// config.NewInitializerFn.
+func NewInitializer(_ client.Client) managed.Initializer {
+	return &Initializer{}
+}
+
+// Initialize returns an error, which keeps the webhook from being created
+// or updated, if its subscriptions or endpoint are invalid. A generic
+// provider type is removed from the resource in memory, so that Terraform
+// sees it unset.
+func (i *Initializer) Initialize(_ context.Context, mg resource.Managed) error {
+	tr, ok := mg.(ujresource.Terraformed)
+	if !ok {
+		return errors.New("managed resource is not a Terraformed resource")
+	}
+	// Arguments are only required on creation, they may be in initProvider.
+	params, err := tr.GetMergedParameters(true)
+	if err != nil {
+		return fmt.Errorf("cannot get parameters: %w", err)
+	}
+	pt, _ := params[paramProviderType].(string)
+	endpoint, _ := params[paramEndpointURL].(string)
+	if err := Validate(toStrings(params[paramSubscriptions]), pt, endpoint, mg.GetAnnotations()[AnnotationAllowUnknown] == "true"); err != nil {
+		return fmt.Errorf("spec.forProvider.%w", err)
+	}
+	if pt != ProviderGeneric {
+		return nil
+	}
+	return unsetGeneric(mg)
+}
+
+// unsetGeneric removes a generic provider type from forProvider and
+// initProvider.
+func unsetGeneric(mg resource.Managed) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mg)
+	if err != nil {
+		return fmt.Errorf("cannot convert webhook: %w", err)
+	}
+	for _, spec := range []string{"forProvider", "initProvider"} {
+		if v, _, _ := unstructured.NestedString(u, "spec", spec, "providerType"); v == ProviderGeneric {
+			unstructured.RemoveNestedField(u, "spec", spec, "providerType")
+		}
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, mg); err != nil {
+		return fmt.Errorf("cannot convert webhook: %w", err)
+	}
+	return nil
+}
+
+func toStrings(v any) []string {
+	l, _ := v.([]any)
+	out := make([]string, 0, len(l))
+	for _, e := range l {
+		if s, ok := e.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -22,6 +22,9 @@ import (
 	"github.com/crossplane/upjet/v2/pkg/controller"
 
 	acl "github.com/millstonehq/provider-upjet-tailscale/internal/controller/acl/acl"
+	lock "github.com/millstonehq/provider-upjet-tailscale/internal/controller/acl/lock"
+	source "github.com/millstonehq/provider-upjet-tailscale/internal/controller/acl/source"
+	approval "github.com/millstonehq/provider-upjet-tailscale/internal/controller/approval"
 	externalid "github.com/millstonehq/provider-upjet-tailscale/internal/controller/aws/externalid"
 	authorization "github.com/millstonehq/provider-upjet-tailscale/internal/controller/device/authorization"
 	key "github.com/millstonehq/provider-upjet-tailscale/internal/controller/device/key"
@@ -31,11 +34,15 @@ import (
 	preferences "github.com/millstonehq/provider-upjet-tailscale/internal/controller/dns/preferences"
 	searchpaths "github.com/millstonehq/provider-upjet-tailscale/internal/controller/dns/searchpaths"
 	splitnameservers "github.com/millstonehq/provider-upjet-tailscale/internal/controller/dns/splitnameservers"
+	fleet "github.com/millstonehq/provider-upjet-tailscale/internal/controller/fleet"
 	configuration "github.com/millstonehq/provider-upjet-tailscale/internal/controller/logstream/configuration"
 	client "github.com/millstonehq/provider-upjet-tailscale/internal/controller/oauth/client"
+	podauthkey "github.com/millstonehq/provider-upjet-tailscale/internal/controller/podauthkey"
+	credential "github.com/millstonehq/provider-upjet-tailscale/internal/controller/posture/credential"
 	integration "github.com/millstonehq/provider-upjet-tailscale/internal/controller/posture/integration"
 	providerconfig "github.com/millstonehq/provider-upjet-tailscale/internal/controller/providerconfig"
 	contacts "github.com/millstonehq/provider-upjet-tailscale/internal/controller/tailnet/contacts"
+	ondelete "github.com/millstonehq/provider-upjet-tailscale/internal/controller/tailnet/ondelete"
 	settings "github.com/millstonehq/provider-upjet-tailscale/internal/controller/tailnet/settings"
 	keytailnetkey "github.com/millstonehq/provider-upjet-tailscale/internal/controller/tailnetkey/key"
 	webhook "github.com/millstonehq/provider-upjet-tailscale/internal/controller/webhook/webhook"
@@ -46,6 +53,9 @@ import (
 func Setup(mgr ctrl.Manager, o controller.Options) error {
 	for _, setup := range []func(ctrl.Manager, controller.Options) error{
 		acl.Setup,
+		lock.Setup,
+		source.Setup,
+		approval.Setup,
 		externalid.Setup,
 		authorization.Setup,
 		key.Setup,
@@ -55,11 +65,15 @@ func Setup(mgr ctrl.Manager, o controller.Options) error {
 		preferences.Setup,
 		searchpaths.Setup,
 		splitnameservers.Setup,
+		fleet.Setup,
 		configuration.Setup,
 		client.Setup,
+		podauthkey.Setup,
+		credential.Setup,
 		integration.Setup,
 		providerconfig.Setup,
 		contacts.Setup,
+		ondelete.Setup,
 		settings.Setup,
 		keytailnetkey.Setup,
 		webhook.Setup,
@@ -76,6 +90,9 @@ func Setup(mgr ctrl.Manager, o controller.Options) error {
 func SetupGated(mgr ctrl.Manager, o controller.Options) error {
 	for _, setup := range []func(ctrl.Manager, controller.Options) error{
 		acl.SetupGated,
+		lock.SetupGated,
+		source.SetupGated,
+		approval.SetupGated,
 		externalid.SetupGated,
 		authorization.SetupGated,
 		key.SetupGated,
@@ -85,11 +102,15 @@ func SetupGated(mgr ctrl.Manager, o controller.Options) error {
 		preferences.SetupGated,
 		searchpaths.SetupGated,
 		splitnameservers.SetupGated,
+		fleet.SetupGated,
 		configuration.SetupGated,
 		client.SetupGated,
+		podauthkey.SetupGated,
+		credential.SetupGated,
 		integration.SetupGated,
 		providerconfig.SetupGated,
 		contacts.SetupGated,
+		ondelete.SetupGated,
 		settings.SetupGated,
 		keytailnetkey.SetupGated,
 		webhook.SetupGated,
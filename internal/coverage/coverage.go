@@ -0,0 +1,145 @@
+// Package coverage reports which resources and data sources of the
+// Terraform provider schema the provider generates:
+//
+//   - resources matching the include list are generated
+//   - resources matching the exclude list are deliberately not generated
+//   - resources matching neither list appeared in the Terraform provider
+//     since the lists were last reviewed, and are left out without anyone
+//     deciding to
+//   - data sources are never generated, and those missing from the list of
+//     known data sources appeared since it was last reviewed
+//
+// The generator writes the report, and fails in strict mode if a resource
+// matches neither list, or both.
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// A Report lists the resources and data sources of the provider schema.
+type Report struct {
+	// Included are the resources matching the include list.
+	Included []string
+	// Excluded are the resources matching the exclude list.
+	Excluded []string
+	// Unlisted are the resources matching neither list.
+	Unlisted []string
+	// Conflicting are the resources matching both lists. upjet skips them.
+	Conflicting []string
+
+	// DataSources are the known data sources of the schema.
+	DataSources []string
+	// NewDataSources are the data sources of the schema that are not
+	// known.
+	NewDataSources []string
+}
+
+// OK reports whether every resource matches exactly one of the lists.
+func (r *Report) OK() bool {
+	return len(r.Unlisted) == 0 && len(r.Conflicting) == 0
+}
+
+// Write writes the report in a human readable form.
+func (r *Report) Write(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("Terraform provider schema coverage:\n")
+	section := func(title string, items []string) {
+		fmt.Fprintf(&b, "\n%s (%d):\n", title, len(items))
+		for _, i := range items {
+			fmt.Fprintf(&b, "  - %s\n", i)
+		}
+	}
+	section("Included resources", r.Included)
+	section("Excluded resources", r.Excluded)
+	section("Resources neither included nor excluded", r.Unlisted)
+	section("Resources both included and excluded", r.Conflicting)
+	section("Data sources, not generated", r.DataSources)
+	section("New data sources, not generated", r.NewDataSources)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// providerSchemas mirrors the parts of the output of terraform providers
+// schema -json used by this package.
+type providerSchemas struct {
+	Schemas map[string]struct {
+		Resources   map[string]json.RawMessage `json:"resource_schemas"`
+		DataSources map[string]json.RawMessage `json:"data_source_schemas"`
+	} `json:"provider_schemas"`
+}
+
+// Run checks the resources of the supplied provider schema against the
+// regular expressions of the include and exclude lists, and its data
+// sources against the known ones.
+func Run(schema []byte, includes, excludes, dataSources []string) (*Report, error) {
+	ps := &providerSchemas{}
+	if err := json.Unmarshal(schema, ps); err != nil {
+		return nil, fmt.Errorf("cannot parse provider schema: %w", err)
+	}
+	if len(ps.Schemas) != 1 {
+		return nil, fmt.Errorf("provider schema must have exactly 1 provider, got %d", len(ps.Schemas))
+	}
+	inc, err := compile(includes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include list: %w", err)
+	}
+	exc, err := compile(excludes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude list: %w", err)
+	}
+
+	r := &Report{}
+	for _, s := range ps.Schemas {
+		for name := range s.Resources {
+			included, excluded := matches(inc, name), matches(exc, name)
+			switch {
+			case included && excluded:
+				r.Conflicting = append(r.Conflicting, name)
+			case included:
+				r.Included = append(r.Included, name)
+			case excluded:
+				r.Excluded = append(r.Excluded, name)
+			default:
+				r.Unlisted = append(r.Unlisted, name)
+			}
+		}
+		for name := range s.DataSources {
+			if slices.Contains(dataSources, name) {
+				r.DataSources = append(r.DataSources, name)
+			} else {
+				r.NewDataSources = append(r.NewDataSources, name)
+			}
+		}
+	}
+	for _, l := range [][]string{r.Included, r.Excluded, r.Unlisted, r.Conflicting, r.DataSources, r.NewDataSources} {
+		slices.Sort(l)
+	}
+	return r, nil
+}
+
+func compile(exprs []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(exprs))
+	for _, e := range exprs {
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+func matches(res []*regexp.Regexp, name string) bool {
+	for _, re := range res {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/millstonehq/provider-upjet-tailscale/config"
+)
+
+const testSchema = `{
+	"format_version": "1.0",
+	"provider_schemas": {
+		"registry.opentofu.org/tailscale/tailscale": {
+			"resource_schemas": {"tailscale_acl": {}, "tailscale_contacts": {}, "tailscale_dns_configuration": {}, "tailscale_webhook": {}},
+			"data_source_schemas": {"tailscale_device": {}, "tailscale_users": {}}
+		}
+	}
+}`
+
+func TestRun(t *testing.T) {
+	type want struct {
+		report *Report
+		err    bool
+	}
+	cases := map[string]struct {
+		reason      string
+		schema      string
+		includes    []string
+		excludes    []string
+		dataSources []string
+		want        want
+	}{
+		"Covered": {
+			reason:      "Resources matching exactly one list should be included or excluded",
+			schema:      testSchema,
+			includes:    []string{"tailscale_acl$", "tailscale_webhook$"},
+			excludes:    []string{"tailscale_contacts$", "tailscale_dns_configuration$"},
+			dataSources: []string{"tailscale_device", "tailscale_users"},
+			want: want{report: &Report{
+				Included:    []string{"tailscale_acl", "tailscale_webhook"},
+				Excluded:    []string{"tailscale_contacts", "tailscale_dns_configuration"},
+				DataSources: []string{"tailscale_device", "tailscale_users"},
+			}},
+		},
+		"Drift": {
+			reason:      "Resources matching no list or both, and unknown data sources, should be reported",
+			schema:      testSchema,
+			includes:    []string{"tailscale_acl$", "tailscale_contacts$"},
+			excludes:    []string{"tailscale_contacts$"},
+			dataSources: []string{"tailscale_device"},
+			want: want{report: &Report{
+				Included:       []string{"tailscale_acl"},
+				Unlisted:       []string{"tailscale_dns_configuration", "tailscale_webhook"},
+				Conflicting:    []string{"tailscale_contacts"},
+				DataSources:    []string{"tailscale_device"},
+				NewDataSources: []string{"tailscale_users"},
+			}},
+		},
+		"InvalidExpression": {
+			reason:   "Invalid regular expressions should be an error",
+			schema:   testSchema,
+			includes: []string{"tailscale_(acl$"},
+			want:     want{err: true},
+		},
+		"InvalidSchema": {
+			reason: "A schema without a provider should be an error",
+			schema: `{"provider_schemas": {}}`,
+			want:   want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, err := Run([]byte(tc.schema), tc.includes, tc.excludes, tc.dataSources)
+			if diff := cmp.Diff(tc.want, want{report: r, err: err != nil}, cmp.AllowUnexported(want{}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestProviderCoverage(t *testing.T) {
+	r, err := Run(config.ProviderSchema(), config.IncludeList, config.ExcludeList, config.DataSources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() || len(r.NewDataSources) > 0 {
+		t.Errorf("Run(...): the lists of config/provider.go do not cover the provider schema:\n%s", b.String())
+	}
+	if got, want := len(r.Included), len(config.GetProvider().Resources); got != want {
+		t.Errorf("Run(...): got %d included resources, upjet generates %d", got, want)
+	}
+}
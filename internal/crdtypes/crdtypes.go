@@ -0,0 +1,116 @@
+// Package crdtypes adjusts the CRD types generated by upjet where the
+// Terraform schema cannot express what the CRD needs.
+//
+// Validation markers are carried in the descriptions of the Terraform
+// schema, so upjet copies them to the observation as well. The generator
+// removes them from the observation types, where they would only stop
+// status updates with values Tailscale accepted, and adds the rules that
+// span several fields to the parameters type.
+//
+// Fields that exist only in Kubernetes are added to the Terraform schema by
+// the resource configuration, marked to be left out of the Terraform
+// configuration. upjet still mirrors spec fields into the observation and
+// reads status fields from the Terraform state. The generator therefore
+// removes spec fields from the observation and tags status fields so that
+// they are never read from or written to the state.
+package crdtypes
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// A Kind lists the adjustments of the types generated for a kind.
+type Kind struct {
+	// Name of the kind, such as ACL.
+	Name string
+	// SpecOnly are the Terraform names of the fields that exist only in
+	// spec.forProvider.
+	SpecOnly []string
+	// StatusOnly are the Terraform names of the fields that exist only in
+	// status.atProvider.
+	StatusOnly []string
+	// Rules are the kubebuilder markers of the parameters type, such as
+	// XValidation rules that span several fields.
+	Rules []string
+}
+
+var typeDecl = regexp.MustCompile(`^type (\w+) struct \{$`)
+
+// AdjustFile rewrites the types file of a kind generated by upjet.
+func AdjustFile(path string, k Kind) error {
+	src, err := os.ReadFile(path) //nolint:gosec // the path is supplied by the generator
+	if err != nil {
+		return fmt.Errorf("cannot read types of %s: %w", k.Name, err)
+	}
+	out, err := Adjust(src, k)
+	if err != nil {
+		return fmt.Errorf("cannot adjust types of %s: %w", k.Name, err)
+	}
+	return os.WriteFile(path, out, 0o644) //nolint:gosec // generated sources are world readable
+}
+
+// Adjust returns the supplied types of a kind with the adjustments applied.
+func Adjust(src []byte, k Kind) ([]byte, error) {
+	lines := strings.Split(string(src), "\n")
+	out := make([]string, 0, len(lines))
+	var typ string
+	for _, l := range lines {
+		if m := typeDecl.FindStringSubmatch(l); m != nil {
+			typ = m[1]
+			if typ == k.Name+"Parameters" {
+				for _, r := range k.Rules {
+					out = append(out, "// "+r)
+				}
+			}
+		}
+		if l == "}" {
+			typ = ""
+		}
+		if !strings.HasSuffix(typ, "Observation") {
+			out = append(out, l)
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(l), "// +kubebuilder:validation:") {
+			continue
+		}
+		if typ == k.Name+"Observation" {
+			switch tf := tfName(l); {
+			case slices.Contains(k.SpecOnly, tf):
+				out = dropField(out)
+				continue
+			case slices.Contains(k.StatusOnly, tf):
+				l = strings.Replace(l, fmt.Sprintf(`tf:"%s,omitempty"`, tf), `tf:"-"`, 1)
+			}
+		}
+		out = append(out, l)
+	}
+	return format.Source([]byte(strings.Join(out, "\n")))
+}
+
+var tfTag = regexp.MustCompile(`tf:"([^",]+)`)
+
+// tfName returns the Terraform name in the tag of a field declaration.
+func tfName(l string) string {
+	m := tfTag.FindStringSubmatch(l)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// dropField removes the doc comment and the blank line before a field that
+// is about to be skipped.
+func dropField(out []string) []string {
+	for len(out) > 0 && strings.HasPrefix(strings.TrimSpace(out[len(out)-1]), "//") {
+		out = out[:len(out)-1]
+	}
+	if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
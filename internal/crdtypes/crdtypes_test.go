@@ -0,0 +1,143 @@
+package crdtypes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// src returns Go source with backquotes written as single quotes.
+func src(s string) string {
+	return strings.ReplaceAll(s, "'", "`")
+}
+
+var generated = src(`package v1alpha1
+
+type ACLObservation struct {
+
+	// The policy.
+	ACL *string 'json:"acl,omitempty" tf:"acl,omitempty"'
+
+	// Take the policy from a ConfigMap.
+	// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef)",message="required"
+	// +upjet:crd:field:TFTag=-
+	ACLFrom *ACLFromObservation 'json:"aclFrom,omitempty" tf:"acl_from,omitempty"'
+
+	// The source the policy was copied from.
+	ACLSource *ACLSourceObservation 'json:"aclSource,omitempty" tf:"acl_source,omitempty"'
+
+	// Overwrite the policy.
+	// +kubebuilder:validation:Enum=true
+	Overwrite *bool 'json:"overwrite,omitempty" tf:"overwrite,omitempty"'
+}
+
+type ACLParameters struct {
+
+	// Take the policy from a ConfigMap.
+	// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef)",message="required"
+	// +upjet:crd:field:TFTag=-
+	// +kubebuilder:validation:Optional
+	ACLFrom *ACLFromParameters 'json:"aclFrom,omitempty" tf:"-"'
+
+	// Overwrite the policy.
+	// +kubebuilder:validation:Enum=true
+	// +kubebuilder:validation:Optional
+	Overwrite *bool 'json:"overwrite,omitempty" tf:"overwrite,omitempty"'
+}
+`)
+
+func TestAdjust(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		kind   Kind
+		want   string
+	}{
+		"ObservationMarkers": {
+			reason: "Validation markers should be removed from the observation only",
+			kind:   Kind{Name: "ACL"},
+			want: src(`package v1alpha1
+
+type ACLObservation struct {
+
+	// The policy.
+	ACL *string 'json:"acl,omitempty" tf:"acl,omitempty"'
+
+	// Take the policy from a ConfigMap.
+	// +upjet:crd:field:TFTag=-
+	ACLFrom *ACLFromObservation 'json:"aclFrom,omitempty" tf:"acl_from,omitempty"'
+
+	// The source the policy was copied from.
+	ACLSource *ACLSourceObservation 'json:"aclSource,omitempty" tf:"acl_source,omitempty"'
+
+	// Overwrite the policy.
+	Overwrite *bool 'json:"overwrite,omitempty" tf:"overwrite,omitempty"'
+}
+
+type ACLParameters struct {
+
+	// Take the policy from a ConfigMap.
+	// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef)",message="required"
+	// +upjet:crd:field:TFTag=-
+	// +kubebuilder:validation:Optional
+	ACLFrom *ACLFromParameters 'json:"aclFrom,omitempty" tf:"-"'
+
+	// Overwrite the policy.
+	// +kubebuilder:validation:Enum=true
+	// +kubebuilder:validation:Optional
+	Overwrite *bool 'json:"overwrite,omitempty" tf:"overwrite,omitempty"'
+}
+`),
+		},
+		"KubernetesOnly": {
+			reason: "Spec fields should be removed from the observation, status fields kept out of the state and rules added to the parameters",
+			kind: Kind{
+				Name:       "ACL",
+				SpecOnly:   []string{"acl_from"},
+				StatusOnly: []string{"acl_source"},
+				Rules:      []string{`+kubebuilder:validation:XValidation:rule="has(self.aclFrom)",message="aclFrom is required"`},
+			},
+			want: src(`package v1alpha1
+
+type ACLObservation struct {
+
+	// The policy.
+	ACL *string 'json:"acl,omitempty" tf:"acl,omitempty"'
+
+	// The source the policy was copied from.
+	ACLSource *ACLSourceObservation 'json:"aclSource,omitempty" tf:"-"'
+
+	// Overwrite the policy.
+	Overwrite *bool 'json:"overwrite,omitempty" tf:"overwrite,omitempty"'
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.aclFrom)",message="aclFrom is required"
+type ACLParameters struct {
+
+	// Take the policy from a ConfigMap.
+	// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef)",message="required"
+	// +upjet:crd:field:TFTag=-
+	// +kubebuilder:validation:Optional
+	ACLFrom *ACLFromParameters 'json:"aclFrom,omitempty" tf:"-"'
+
+	// Overwrite the policy.
+	// +kubebuilder:validation:Enum=true
+	// +kubebuilder:validation:Optional
+	Overwrite *bool 'json:"overwrite,omitempty" tf:"overwrite,omitempty"'
+}
+`),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Adjust([]byte(generated), tc.kind)
+			if err != nil {
+				t.Fatalf("\n%s\nAdjust(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("\n%s\nAdjust(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Package health contains the readiness checks of the provider, served on
+// /readyz next to the /healthz liveness check:
+//
+//   - the informer caches have synced, so that controllers see every
+//     resource
+//   - the webhook server certificate and key, when mounted, can be loaded
+//   - the Terraform CLI is on the PATH and the workspace directory is
+//     writable, as every generated controller needs both
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const cacheSyncTimeout = time.Second
+
+// A Syncer reports whether its caches have synced, like cache.Cache.
+type Syncer interface {
+	WaitForCacheSync(ctx context.Context) bool
+}
+
+// CacheSynced returns a checker failing until the caches have synced.
+func CacheSynced(s Syncer) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), cacheSyncTimeout)
+		defer cancel()
+		if !s.WaitForCacheSync(ctx) {
+			return errors.New("informer caches have not synced")
+		}
+		return nil
+	}
+}
+
+// WebhookCerts returns a checker failing when the tls.crt and tls.key of
+// the webhook server in dir cannot be loaded. It passes when dir does not
+// exist, as the webhook server is then not used.
+func WebhookCerts(dir string) healthz.Checker {
+	return func(_ *http.Request) error {
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if _, err := tls.LoadX509KeyPair(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")); err != nil {
+			return fmt.Errorf("cannot load webhook certificate: %w", err)
+		}
+		return nil
+	}
+}
+
+// Terraform returns a checker failing when the binary is not on the PATH
+// or no file can be written in the workspace directory.
+func Terraform(binary, workspaceDir string) healthz.Checker {
+	return func(_ *http.Request) error {
+		if _, err := exec.LookPath(binary); err != nil {
+			return fmt.Errorf("cannot find terraform binary: %w", err)
+		}
+		f, err := os.CreateTemp(workspaceDir, ".readyz-*")
+		if err != nil {
+			return fmt.Errorf("cannot write to workspace directory: %w", err)
+		}
+		_ = f.Close()
+		return os.Remove(f.Name())
+	}
+}
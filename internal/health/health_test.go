@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type syncer bool
+
+func (s syncer) WaitForCacheSync(_ context.Context) bool {
+	return bool(s)
+}
+
+func writeCert(t *testing.T, dir string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "provider-tailscale"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tls.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tls.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: k}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckers(t *testing.T) {
+	certs := t.TempDir()
+	writeCert(t, certs)
+	empty := t.TempDir()
+
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "terraform"), []byte("#!/bin/sh\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	cases := map[string]struct {
+		reason string
+		check  func() error
+		err    bool
+	}{
+		"CacheSynced": {
+			reason: "Synced caches should be ready",
+			check:  func() error { return CacheSynced(syncer(true))(httptest.NewRequest("GET", "/readyz", nil)) },
+		},
+		"CacheNotSynced": {
+			reason: "Caches that have not synced should not be ready",
+			check:  func() error { return CacheSynced(syncer(false))(httptest.NewRequest("GET", "/readyz", nil)) },
+			err:    true,
+		},
+		"WebhookCerts": {
+			reason: "A valid certificate and key should be ready",
+			check:  func() error { return WebhookCerts(certs)(nil) },
+		},
+		"WebhookCertsMissing": {
+			reason: "A mounted directory without a certificate should not be ready",
+			check:  func() error { return WebhookCerts(empty)(nil) },
+			err:    true,
+		},
+		"WebhookCertsNotMounted": {
+			reason: "Webhooks without a certificate directory are not used",
+			check:  func() error { return WebhookCerts(filepath.Join(empty, "certs"))(nil) },
+		},
+		"Terraform": {
+			reason: "A terraform binary and a writable workspace directory should be ready",
+			check:  func() error { return Terraform("terraform", empty)(nil) },
+		},
+		"TerraformMissing": {
+			reason: "A missing binary should not be ready",
+			check:  func() error { return Terraform("tofu", empty)(nil) },
+			err:    true,
+		},
+		"WorkspaceMissing": {
+			reason: "A missing workspace directory should not be ready",
+			check:  func() error { return Terraform("terraform", filepath.Join(empty, "workspaces"))(nil) },
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.check()
+			if (err != nil) != tc.err {
+				t.Errorf("\n%s\ncheck(...): unexpected error state: %v", tc.reason, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1beta1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+// Keys of the fields of reconcile log lines.
+const (
+	KeyGVK            = "gvk"
+	KeyName           = "name"
+	KeyProviderConfig = "providerConfig"
+	KeyTailnet        = "tailnet"
+)
+
+// Keys of the fields the loggers of the managed reconciler and upjet
+// identify a resource with.
+const (
+	keyController = "controller"
+	keyRequest    = "request"
+	keyUID        = "uid"
+	keyWorkspace  = "workspace"
+)
+
+// lookupTimeout bounds the reads of a resource and its ProviderConfig.
+const lookupTimeout = 5 * time.Second
+
+// A Correlator adds the GroupVersionKind, name, ProviderConfig and tailnet of
+// a managed resource to the log lines of its reconciles:
+//
+//   - the managed reconciler logs the request of the controller, whose name
+//     is derived from the GroupVersionKind, see managed.ControllerName
+//   - the external clients of upjet log the UID of the resource
+//   - the Terraform workspaces log their directory, named after the UID
+//
+// The fields of a resource are looked up once per reconcile, when the
+// managed reconciler starts logging the request, and remembered by UID for
+// the other two.
+type Correlator struct {
+	kube   client.Reader
+	scheme *runtime.Scheme
+	kinds  map[string]schema.GroupVersionKind
+
+	mu        sync.RWMutex
+	resources map[types.UID][]any
+	uids      map[string]types.UID
+}
+
+// NewCorrelator returns a Correlator reading managed resources and
+// ProviderConfigs with the supplied reader, which should be backed by the
+// cache of the manager. Resources must be of a kind of the scheme.
+func NewCorrelator(kube client.Reader, s *runtime.Scheme) *Correlator {
+	c := &Correlator{
+		kube:      kube,
+		scheme:    s,
+		kinds:     map[string]schema.GroupVersionKind{},
+		resources: map[types.UID][]any{},
+		uids:      map[string]types.UID{},
+	}
+	for gvk := range s.AllKnownTypes() {
+		c.kinds[managed.ControllerName(gvk.String())] = gvk
+	}
+	return c
+}
+
+// Logger returns a logger that adds the fields of the resource to the lines
+// of the supplied logger, once it is known, and logs the output of the
+// Terraform CLI as structured lines.
+func (c *Correlator) Logger(log logging.Logger) logging.Logger {
+	return &logger{log: log, c: c}
+}
+
+// request returns the fields of the resource reconciled by the named
+// controller. Only the GroupVersionKind and name are returned if the
+// resource cannot be read, for example because it was deleted.
+func (c *Correlator) request(controller string, req reconcile.Request) []any {
+	gvk, ok := c.kinds[controller]
+	if !ok {
+		return nil
+	}
+	fields := []any{KeyGVK, gvk.String(), KeyName, req.Name}
+	key := controller + "/" + req.String()
+
+	obj, err := c.newManaged(gvk)
+	if err != nil {
+		return fields
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	if err := c.kube.Get(ctx, req.NamespacedName, obj); err != nil {
+		if kerrors.IsNotFound(err) {
+			c.forget(key)
+		}
+		return fields
+	}
+	if pc := providerConfigName(obj); pc != "" {
+		fields = append(fields, KeyProviderConfig, pc, KeyTailnet, c.tailnet(ctx, pc))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resources[obj.GetUID()] = fields
+	c.uids[key] = obj.GetUID()
+	return fields
+}
+
+// resource returns the fields of the resource with the supplied UID, if its
+// request was logged.
+func (c *Correlator) resource(uid types.UID) []any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.resources[uid]
+}
+
+// forget forgets the fields of a deleted resource.
+func (c *Correlator) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.resources, c.uids[key])
+	delete(c.uids, key)
+}
+
+func (c *Correlator) newManaged(gvk schema.GroupVersionKind) (resource.Managed, error) {
+	obj, err := c.scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	mg, ok := obj.(resource.Managed)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a managed resource", gvk.Kind)
+	}
+	return mg, nil
+}
+
+// tailnet returns the tailnet of the named ProviderConfig, which is the
+// tailnet of its credentials unless it is set.
+func (c *Correlator) tailnet(ctx context.Context, name string) string {
+	pc := &v1beta1.ProviderConfig{}
+	if err := c.kube.Get(ctx, types.NamespacedName{Name: name}, pc); err != nil || pc.Spec.Tailnet == "" {
+		return tsapi.DefaultTailnet
+	}
+	return pc.Spec.Tailnet
+}
+
+func providerConfigName(mg resource.Managed) string {
+	switch m := mg.(type) {
+	case resource.LegacyManaged:
+		if ref := m.GetProviderConfigReference(); ref != nil {
+			return ref.Name
+		}
+	case resource.ModernManaged:
+		if ref := m.GetProviderConfigReference(); ref != nil {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// logger adds the fields of a resource to its lines once one of the keys
+// identifying the resource is logged.
+type logger struct {
+	log        logging.Logger
+	c          *Correlator
+	controller string
+	correlated bool
+}
+
+func (l *logger) Info(msg string, keysAndValues ...any) {
+	l.log.Info(msg, terraformOutput(keysAndValues)...)
+}
+
+func (l *logger) Debug(msg string, keysAndValues ...any) {
+	l.log.Debug(msg, terraformOutput(keysAndValues)...)
+}
+
+func (l *logger) WithValues(keysAndValues ...any) logging.Logger {
+	n := &logger{c: l.c, controller: l.controller, correlated: l.correlated}
+	var fields []any
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		k, _ := keysAndValues[i].(string)
+		v := keysAndValues[i+1]
+		switch {
+		case k == keyController:
+			n.controller, _ = v.(string)
+		case n.correlated:
+		case k == keyRequest:
+			if req, ok := v.(reconcile.Request); ok {
+				fields = l.c.request(n.controller, req)
+			}
+		case k == keyUID:
+			if uid, ok := v.(types.UID); ok {
+				fields = l.c.resource(uid)
+			}
+		case k == keyWorkspace:
+			if dir, ok := v.(string); ok {
+				fields = l.c.resource(types.UID(filepath.Base(dir)))
+			}
+		}
+		if fields != nil {
+			n.correlated = true
+		}
+	}
+	n.log = l.log.WithValues(merge(keysAndValues, fields)...)
+	return n
+}
+
+// merge returns the keys and values with the supplied fields, which replace
+// those of the same key.
+func merge(keysAndValues, fields []any) []any {
+	if len(fields) == 0 {
+		return keysAndValues
+	}
+	replaced := map[any]bool{}
+	for i := 0; i < len(fields); i += 2 {
+		replaced[fields[i]] = true
+	}
+	out := make([]any, 0, len(keysAndValues)+len(fields))
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if !replaced[keysAndValues[i]] {
+			out = append(out, keysAndValues[i], keysAndValues[i+1])
+		}
+	}
+	return append(out, fields...)
+}
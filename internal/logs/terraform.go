@@ -0,0 +1,78 @@
+package logs
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// KeyTerraform is the key of the structured output of the Terraform CLI.
+const KeyTerraform = "terraform"
+
+// keyOutput is the key upjet logs the output of the Terraform CLI with.
+const keyOutput = "out"
+
+// A terraformLine is a line of the JSON output of the Terraform CLI, with
+// credentials redacted.
+type terraformLine struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Summary string `json:"summary,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// terraformLog mirrors the fields of a line of the JSON output of the
+// Terraform CLI, see https://developer.hashicorp.com/terraform/internals/machine-readable-ui.
+type terraformLog struct {
+	Level      string `json:"@level"`
+	Message    string `json:"@message"`
+	Diagnostic struct {
+		Summary string `json:"summary"`
+		Detail  string `json:"detail"`
+	} `json:"diagnostic"`
+}
+
+// parseTerraformOutput returns the lines of the JSON output of the
+// Terraform CLI, which includes its stderr, or false if the output is not
+// JSON, such as the output of terraform init.
+func parseTerraformOutput(out string) ([]terraformLine, bool) {
+	var lines []terraformLine
+	for _, l := range strings.Split(out, "\n") {
+		if l = strings.TrimSpace(l); l == "" {
+			continue
+		}
+		tl := &terraformLog{}
+		if err := json.Unmarshal([]byte(l), tl); err != nil || tl.Level == "" {
+			return nil, false
+		}
+		lines = append(lines, terraformLine{
+			Level:   tl.Level,
+			Message: Redact(tl.Message),
+			Summary: Redact(tl.Diagnostic.Summary),
+			Detail:  Redact(tl.Diagnostic.Detail),
+		})
+	}
+	return lines, len(lines) > 0
+}
+
+// terraformOutput returns the keys and values with the output of the
+// Terraform CLI replaced by its lines, if it is JSON.
+func terraformOutput(keysAndValues []any) []any {
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if keysAndValues[i] != keyOutput {
+			continue
+		}
+		out, ok := keysAndValues[i+1].(string)
+		if !ok {
+			continue
+		}
+		lines, ok := parseTerraformOutput(out)
+		if !ok {
+			continue
+		}
+		kv := make([]any, 0, len(keysAndValues))
+		kv = append(kv, keysAndValues[:i]...)
+		kv = append(kv, KeyTerraform, lines)
+		return append(kv, keysAndValues[i+2:]...)
+	}
+	return keysAndValues
+}
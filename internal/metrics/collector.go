@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+const defaultProviderConfig = "default"
+
+var (
+	providerConfigKind = schema.GroupVersionKind{Group: "tailscale.upbound.io", Version: "v1beta1", Kind: "ProviderConfig"}
+	authKeyKind        = schema.GroupVersionKind{Group: "tailnetkey.tailscale.upbound.io", Version: "v1alpha1", Kind: "Key"}
+
+	// singletonKinds are the kinds of which a tailnet has a single
+	// instance, so that resources of the same ProviderConfig conflict.
+	singletonKinds = []schema.GroupVersionKind{
+		{Group: "acl.tailscale.upbound.io", Version: "v1alpha1", Kind: "ACL"},
+		{Group: "aws.tailscale.upbound.io", Version: "v1alpha1", Kind: "ExternalID"},
+		{Group: "dns.tailscale.upbound.io", Version: "v1alpha1", Kind: "Nameservers"},
+		{Group: "dns.tailscale.upbound.io", Version: "v1alpha1", Kind: "Preferences"},
+		{Group: "dns.tailscale.upbound.io", Version: "v1alpha1", Kind: "SearchPaths"},
+		{Group: "tailnet.tailscale.upbound.io", Version: "v1alpha1", Kind: "Settings"},
+		{Group: "tailnet.tailscale.upbound.io", Version: "v1alpha1", Kind: "Contacts"},
+	}
+)
+
+// apiClient is the part of the Tailscale API used by this package.
+type apiClient interface {
+	KeyID() (string, error)
+	GetKey(ctx context.Context, id string) (*tsapi.Key, error)
+	ListDevices(ctx context.Context) ([]tsapi.Device, error)
+}
+
+// newClientFn returns an API client for the tailnet of a ProviderConfig.
+type newClientFn func(ctx context.Context, kube client.Client, providerConfig string) (apiClient, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, providerConfig string) (apiClient, error) {
+	return tsapi.NewForProviderConfig(ctx, kube, providerConfig)
+}
+
+// Collector periodically refreshes the gauges of each ProviderConfig and
+// the singleton conflicts. It implements manager.Runnable.
+type Collector struct {
+	kube      client.Client
+	log       logging.Logger
+	interval  time.Duration
+	newClient newClientFn
+}
+
+// NewCollector returns a Collector refreshing the gauges at the supplied
+// interval.
+func NewCollector(kube client.Client, log logging.Logger, interval time.Duration) *Collector {
+	return &Collector{kube: kube, log: log, interval: interval, newClient: newAPIClient}
+}
+
+// Start collects until the context is done. Only the leader collects, to
+// not multiply the API calls by the number of replicas.
+func (c *Collector) Start(ctx context.Context) error {
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+	for {
+		c.Collect(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// Collect refreshes the gauges once. Errors are logged, the gauges of a
+// ProviderConfig whose tailnet cannot be read are removed.
+func (c *Collector) Collect(ctx context.Context) {
+	if err := c.collectSingletons(ctx); err != nil {
+		c.log.Info("Cannot collect singleton conflicts", "error", err)
+	}
+	if err := c.collectAuthKeys(ctx); err != nil {
+		c.log.Info("Cannot collect auth key expiries", "error", err)
+	}
+	if err := c.collectProviderConfigs(ctx); err != nil {
+		c.log.Info("Cannot collect ProviderConfig metrics", "error", err)
+	}
+}
+
+func (c *Collector) collectSingletons(ctx context.Context) error {
+	type key struct {
+		gk schema.GroupKind
+		pc string
+	}
+	counts := map[key]int{}
+	for _, gvk := range singletonKinds {
+		l, err := c.list(ctx, gvk)
+		if err != nil {
+			return err
+		}
+		for i := range l {
+			if meta.WasDeleted(&l[i]) {
+				continue
+			}
+			counts[key{gk: gvk.GroupKind(), pc: providerConfigOf(&l[i])}]++
+		}
+	}
+	SingletonConflicts.Reset()
+	for k, n := range counts {
+		SingletonConflicts.WithLabelValues(k.gk.Group, k.gk.Kind, k.pc).Set(float64(n - 1))
+	}
+	return nil
+}
+
+func (c *Collector) collectAuthKeys(ctx context.Context) error {
+	l, err := c.list(ctx, authKeyKind)
+	if err != nil {
+		return err
+	}
+	first := map[string]time.Time{}
+	for i := range l {
+		s, _, _ := unstructured.NestedString(l[i].Object, "status", "atProvider", "expiresAt")
+		exp, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			continue
+		}
+		pc := providerConfigOf(&l[i])
+		if t, ok := first[pc]; !ok || exp.Before(t) {
+			first[pc] = exp
+		}
+	}
+	AuthKeyExpiry.Reset()
+	for pc, t := range first {
+		AuthKeyExpiry.WithLabelValues(pc).Set(float64(t.Unix()))
+	}
+	return nil
+}
+
+func (c *Collector) collectProviderConfigs(ctx context.Context) error {
+	pcs, err := c.list(ctx, providerConfigKind)
+	if err != nil {
+		return err
+	}
+	expiry, devices := map[string]time.Time{}, map[string]int{}
+	for _, pc := range pcs {
+		name := pc.GetName()
+		ts, err := c.newClient(ctx, c.kube, name)
+		if err != nil {
+			c.log.Debug("Cannot create Tailscale API client", "providerConfig", name, "error", err)
+			continue
+		}
+		// Only API access tokens expire, OAuth clients do not.
+		if id, err := ts.KeyID(); err == nil {
+			k, err := ts.GetKey(ctx, id)
+			if err != nil {
+				c.log.Info("Cannot get API key", "providerConfig", name, "error", err)
+			} else if !k.Expires.IsZero() {
+				expiry[name] = k.Expires
+			}
+		}
+		d, err := ts.ListDevices(ctx)
+		if err != nil {
+			c.log.Info("Cannot list devices", "providerConfig", name, "error", err)
+			continue
+		}
+		devices[name] = len(d)
+	}
+	APIKeyExpiry.Reset()
+	for pc, t := range expiry {
+		APIKeyExpiry.WithLabelValues(pc).Set(float64(t.Unix()))
+	}
+	Devices.Reset()
+	for pc, n := range devices {
+		Devices.WithLabelValues(pc).Set(float64(n))
+	}
+	return nil
+}
+
+func (c *Collector) list(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := c.kube.List(ctx, l); err != nil {
+		return nil, fmt.Errorf("cannot list %s: %w", gvk.Kind, err)
+	}
+	return l.Items, nil
+}
+
+// providerConfigOf returns the ProviderConfig a resource uses.
+func providerConfigOf(u *unstructured.Unstructured) string {
+	if name, _, _ := unstructured.NestedString(u.Object, "spec", "providerConfigRef", "name"); name != "" {
+		return name
+	}
+	return defaultProviderConfig
+}
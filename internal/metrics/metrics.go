@@ -0,0 +1,114 @@
+// Package metrics exports Prometheus metrics about the provider on the
+// controller-runtime metrics endpoint, in addition to the metrics of
+// crossplane-runtime and upjet:
+//
+//   - the latency of the Terraform CLI invocations of each resource kind and
+//     operation, measured by the Scheduler given to upjet
+//   - the errors returned by the Tailscale API to the provider, by status
+//   - the number of Terraform workspaces on disk
+//   - the number of singleton resources managed more than once
+//   - the expiry of the API key and auth keys, and the number of devices,
+//     of each ProviderConfig, refreshed by the Collector
+//   - the number of keys, OAuth clients and webhooks the provider created
+//     but did not record, counted by the orphan Scanner
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+const namespace = "provider_tailscale"
+
+var (
+	// TerraformCLIDuration is the latency of Terraform CLI invocations by
+	// resource group, kind and operation. Its _count series counts the
+	// invocations.
+	TerraformCLIDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "terraform",
+		Name:      "cli_duration_seconds",
+		Help:      "Latency of Terraform CLI invocations by resource group, kind and operation.",
+		Buckets:   []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"group", "kind", "operation"})
+
+	// SingletonConflicts is the number of resources of a singleton kind
+	// beyond the first one using the same ProviderConfig. They overwrite
+	// each other's settings.
+	SingletonConflicts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "singleton_conflicts",
+		Help:      "Resources of a singleton kind beyond the first one using the same ProviderConfig.",
+	}, []string{"group", "kind", "provider_config"})
+
+	// APIKeyExpiry is when the API key of a ProviderConfig expires.
+	APIKeyExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "api_key_expiry_timestamp_seconds",
+		Help:      "Unix time at which the API key of a ProviderConfig expires.",
+	}, []string{"provider_config"})
+
+	// AuthKeyExpiry is when the first of the auth keys managed through a
+	// ProviderConfig expires.
+	AuthKeyExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "auth_key_expiry_timestamp_seconds",
+		Help:      "Unix time at which the first auth key managed through a ProviderConfig expires.",
+	}, []string{"provider_config"})
+
+	// Devices is the number of devices of the tailnet of a ProviderConfig.
+	Devices = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "devices",
+		Help:      "Number of devices of the tailnet of a ProviderConfig.",
+	}, []string{"provider_config"})
+
+	// Orphans is the number of keys, OAuth clients and webhooks in the
+	// tailnet of a ProviderConfig that were created by the provider but are
+	// not the external name of any resource, by kind and state, leaked or
+	// unowned.
+	Orphans = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "orphans",
+		Help:      "Keys, OAuth clients and webhooks created by the provider that no resource records, by kind and state.",
+	}, []string{"provider_config", "kind", "state"})
+
+	// Workspaces is the number of Terraform workspaces on disk, one for
+	// each resource reconciled through Terraform.
+	Workspaces = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "terraform",
+		Name:      "workspaces",
+		Help:      "Number of Terraform workspaces on disk.",
+	}, func() float64 {
+		return float64(countWorkspaces(os.TempDir()))
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		TerraformCLIDuration,
+		SingletonConflicts,
+		APIKeyExpiry,
+		AuthKeyExpiry,
+		Devices,
+		Orphans,
+		Workspaces,
+		tsapi.Errors,
+	)
+}
+
+// countWorkspaces returns the number of workspaces upjet keeps in dir, a
+// directory named after the UID of each resource holding a main.tf.json.
+func countWorkspaces(dir string) int {
+	m, err := filepath.Glob(filepath.Join(dir, "*", "main.tf.json"))
+	if err != nil {
+		return 0
+	}
+	return len(m)
+}
@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+func TestOperation(t *testing.T) {
+	pending := time.Now()
+	cases := map[string]struct {
+		reason string
+		obj    func(o *unstructured.Unstructured)
+		want   string
+	}{
+		"Update": {
+			reason: "A resource that was created should be updated",
+			obj: func(o *unstructured.Unstructured) {
+				meta.SetExternalCreatePending(o, pending)
+				meta.SetExternalCreateSucceeded(o, pending.Add(time.Second))
+			},
+			want: OperationUpdate,
+		},
+		"Create": {
+			reason: "A resource whose creation is pending should be created",
+			obj: func(o *unstructured.Unstructured) {
+				meta.SetExternalCreatePending(o, pending)
+			},
+			want: OperationCreate,
+		},
+		"Delete": {
+			reason: "A deleted resource should be deleted",
+			obj: func(o *unstructured.Unstructured) {
+				o.SetDeletionTimestamp(&metav1.Time{Time: pending})
+			},
+			want: OperationDelete,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := &unstructured.Unstructured{}
+			tc.obj(o)
+			if diff := cmp.Diff(tc.want, operation(o)); diff != "" {
+				t.Errorf("\n%s\noperation(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestInUse(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	var got []float64
+	u := &inUse{
+		inner:    noopInUse{},
+		observer: prometheus.ObserverFunc(func(v float64) { got = append(got, v) }),
+		now:      func() time.Time { return now },
+	}
+
+	// An asynchronous apply outlives the observation started after it.
+	u.Increment()
+	now = now.Add(time.Second)
+	u.Increment()
+	now = now.Add(2 * time.Second)
+	u.Decrement()
+	u.Decrement()
+	// Unbalanced calls are ignored.
+	u.Decrement()
+
+	if diff := cmp.Diff([]float64{3, 2}, got); diff != "" {
+		t.Errorf("inUse: -want observations, +got observations:\n%s", diff)
+	}
+}
+
+type noopInUse struct{}
+
+func (noopInUse) Increment() {}
+func (noopInUse) Decrement() {}
+
+func TestCountWorkspaces(t *testing.T) {
+	dir := t.TempDir()
+	for _, uid := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(dir, uid), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, uid, "main.tf.json"), []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "other"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if got := countWorkspaces(dir); got != 2 {
+		t.Errorf("countWorkspaces(...): got %d, want 2", got)
+	}
+}
+
+type fakeClient struct {
+	keyID   string
+	expires time.Time
+	devices int
+	err     error
+}
+
+func (f *fakeClient) KeyID() (string, error) {
+	if f.keyID == "" {
+		return "", errors.New("credentials are not a tailscale API access token")
+	}
+	return f.keyID, nil
+}
+
+func (f *fakeClient) GetKey(_ context.Context, id string) (*tsapi.Key, error) {
+	return &tsapi.Key{ID: id, Expires: f.expires}, f.err
+}
+
+func (f *fakeClient) ListDevices(_ context.Context) ([]tsapi.Device, error) {
+	return make([]tsapi.Device, f.devices), f.err
+}
+
+func newResource(name, pc string, status map[string]any) unstructured.Unstructured {
+	u := unstructured.Unstructured{Object: map[string]any{}}
+	if pc != "" {
+		u.Object["spec"] = map[string]any{"providerConfigRef": map[string]any{"name": pc}}
+	}
+	if status != nil {
+		u.Object["status"] = map[string]any{"atProvider": status}
+	}
+	u.SetName(name)
+	return u
+}
+
+// gauges returns the value of each series of v by its labels.
+func gauges(t *testing.T, v *prometheus.GaugeVec) map[string]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 16)
+	v.Collect(ch)
+	close(ch)
+	out := map[string]float64{}
+	for m := range ch {
+		d := &dto.Metric{}
+		if err := m.Write(d); err != nil {
+			t.Fatal(err)
+		}
+		key := ""
+		for _, l := range d.GetLabel() {
+			key += l.GetValue() + "/"
+		}
+		out[key] = d.GetGauge().GetValue()
+	}
+	return out
+}
+
+func TestCollect(t *testing.T) {
+	expires := time.Unix(1770000000, 0)
+	clients := map[string]*fakeClient{
+		"default": {keyID: "kABC", expires: expires, devices: 3},
+		"oauth":   {devices: 5},
+		"broken":  {keyID: "kDEF", err: errors.New("boom")},
+	}
+	kube := &test.MockClient{
+		MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+			l := obj.(*unstructured.UnstructuredList)
+			switch l.GetKind() {
+			case "ProviderConfigList":
+				l.Items = []unstructured.Unstructured{newResource("default", "", nil), newResource("oauth", "", nil), newResource("broken", "", nil)}
+			case "ACLList":
+				l.Items = []unstructured.Unstructured{newResource("policy", "", nil), newResource("other", "default", nil), newResource("oauth", "oauth", nil)}
+			case "KeyList":
+				l.Items = []unstructured.Unstructured{
+					newResource("later", "", map[string]any{"expiresAt": "2026-12-01T00:00:00Z"}),
+					newResource("first", "", map[string]any{"expiresAt": "2026-11-01T00:00:00Z"}),
+					newResource("pending", "", nil),
+				}
+			}
+			return nil
+		},
+	}
+	c := &Collector{
+		kube: kube,
+		log:  logging.NewNopLogger(),
+		newClient: func(_ context.Context, _ client.Client, pc string) (apiClient, error) {
+			return clients[pc], nil
+		},
+	}
+	c.Collect(context.Background())
+
+	cases := map[string]struct {
+		reason string
+		vec    *prometheus.GaugeVec
+		want   map[string]float64
+	}{
+		"SingletonConflicts": {
+			reason: "Two ACLs of the default ProviderConfig should be reported as one conflict",
+			vec:    SingletonConflicts,
+			want: map[string]float64{
+				"acl.tailscale.upbound.io/ACL/default/": 1,
+				"acl.tailscale.upbound.io/ACL/oauth/":   0,
+			},
+		},
+		"AuthKeyExpiry": {
+			reason: "The first auth key to expire should be reported for each ProviderConfig",
+			vec:    AuthKeyExpiry,
+			want:   map[string]float64{"default/": float64(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC).Unix())},
+		},
+		"APIKeyExpiry": {
+			reason: "Only ProviderConfigs using an API access token should report its expiry",
+			vec:    APIKeyExpiry,
+			want:   map[string]float64{"default/": float64(expires.Unix())},
+		},
+		"Devices": {
+			reason: "ProviderConfigs whose devices cannot be listed should not be reported",
+			vec:    Devices,
+			want:   map[string]float64{"default/": 3, "oauth/": 5},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, gauges(t, tc.vec)); diff != "" {
+				t.Errorf("\n%s\nCollect(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/upjet/v2/pkg/terraform"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Operations of the external client of a resource.
+const (
+	OperationObserve = "observe"
+	OperationCreate  = "create"
+	OperationUpdate  = "update"
+	OperationDelete  = "delete"
+)
+
+// InstrumentSetup returns a terraform.SetupFn that sets a Scheduler
+// measuring the Terraform CLI invocations of the resource in the setup
+// returned by fn.
+func InstrumentSetup(fn terraform.SetupFn) terraform.SetupFn {
+	return func(ctx context.Context, kube client.Client, mg resource.Managed) (terraform.Setup, error) {
+		ps, err := fn(ctx, kube, mg)
+		if err != nil {
+			return ps, err
+		}
+		gvk, err := apiutil.GVKForObject(mg, kube.Scheme())
+		if err != nil {
+			return ps, fmt.Errorf("cannot get resource kind: %w", err)
+		}
+		ps.Scheduler = NewScheduler(gvk.GroupKind(), mg, ps.Scheduler)
+		return ps, nil
+	}
+}
+
+// Scheduler is a terraform.ProviderScheduler measuring the Terraform CLI
+// invocations of one resource. It delegates the provider processes to
+// another scheduler, or lets Terraform start them like
+// terraform.NoOpProviderScheduler if there is none.
+//
+// upjet starts the scheduler at the beginning of each operation of the
+// external client it connects for a reconcile, and marks the returned InUse
+// around each Terraform CLI invocation. The first operation of a reconcile
+// is always an observation; the operation that follows is inferred from the
+// resource.
+type Scheduler struct {
+	gk    schema.GroupKind
+	obj   client.Object
+	inner terraform.ProviderScheduler
+
+	mu       sync.Mutex
+	observed bool
+	now      func() time.Time
+}
+
+// NewScheduler returns a Scheduler for the supplied resource, of the
+// supplied group and kind, delegating to the supplied scheduler if not nil.
+func NewScheduler(gk schema.GroupKind, obj client.Object, inner terraform.ProviderScheduler) *Scheduler {
+	if inner == nil {
+		inner = terraform.NewNoOpProviderScheduler()
+	}
+	return &Scheduler{gk: gk, obj: obj, inner: inner, now: time.Now}
+}
+
+// Start returns an InUse measuring the invocations of the current
+// operation, along with the attachment configuration of the inner
+// scheduler.
+func (s *Scheduler) Start(h terraform.ProviderHandle) (terraform.InUse, string, error) {
+	u, attachment, err := s.inner.Start(h)
+	if err != nil {
+		return nil, "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op := OperationObserve
+	if s.observed {
+		op = operation(s.obj)
+	}
+	s.observed = true
+	return &inUse{inner: u, observer: TerraformCLIDuration.WithLabelValues(s.gk.Group, s.gk.Kind, op), now: s.now}, attachment, nil
+}
+
+// Stop stops the inner scheduler.
+func (s *Scheduler) Stop(h terraform.ProviderHandle) error {
+	return s.inner.Stop(h)
+}
+
+// operation returns the operation the managed reconciler performs after
+// observing the resource.
+func operation(obj client.Object) string {
+	switch {
+	case meta.WasDeleted(obj):
+		return OperationDelete
+	// The reconciler records that a creation is pending before creating.
+	case meta.ExternalCreateIncomplete(obj):
+		return OperationCreate
+	default:
+		return OperationUpdate
+	}
+}
+
+// inUse times each invocation between Increment and Decrement. Apply and
+// destroy may run asynchronously and outlive the operation, so invocations
+// are ended in the order they started.
+type inUse struct {
+	inner    terraform.InUse
+	observer prometheus.Observer
+	now      func() time.Time
+
+	mu      sync.Mutex
+	started []time.Time
+}
+
+func (u *inUse) Increment() {
+	u.inner.Increment()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.started = append(u.started, u.now())
+}
+
+func (u *inUse) Decrement() {
+	u.inner.Decrement()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.started) == 0 {
+		return
+	}
+	u.observer.Observe(u.now().Sub(u.started[0]).Seconds())
+	u.started = u.started[1:]
+}
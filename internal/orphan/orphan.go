@@ -0,0 +1,319 @@
+// Package orphan finds the auth keys, OAuth clients and webhooks the
+// provider created but did not record, and optionally revokes them.
+//
+// The external name of these resources is the ID the API returns when they
+// are created. If the external-name annotation cannot be written afterwards,
+// no managed resource points at the object, which lives on in the tailnet: an
+// auth key can still join devices, an OAuth client can still mint tokens. The
+// Scanner periodically lists the keys and webhooks of the tailnet of each
+// ProviderConfig and compares them with the external names of the resources:
+//
+//   - a key or OAuth client is leaked if its description carries the marker
+//     of an existing resource, see package marker, and no resource has its
+//     ID as external name
+//   - a webhook is suspected if its endpoint URL is that of an existing
+//     resource, and no resource has its ID as external name. Webhooks
+//     carry no marker, so it may as well have been created by hand.
+//   - a key or OAuth client is unowned if its description carries a marker
+//     but no resource of that UID exists. It may have leaked before its
+//     resource was deleted, or been kept on purpose by a deletion policy of
+//     Orphan.
+//
+// Orphans are logged and counted by the provider_tailscale_orphans gauge,
+// and a leaked or suspected one is reported by an event on its resource.
+// Leaked orphans older than the revocation grace period are revoked if one
+// is set. Unowned and suspected orphans are never revoked. Objects created in
+// the last few minutes are skipped, since a reconcile may still be recording
+// them.
+package orphan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/marker"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/metrics"
+)
+
+// States of an orphan.
+const (
+	StateLeaked    = "leaked"
+	StateUnowned   = "unowned"
+	StateSuspected = "suspected"
+)
+
+const (
+	reasonLeaked    event.Reason = "LeakedExternalResource"
+	reasonSuspected event.Reason = "SuspectedLeakedExternalResource"
+	reasonRevoked   event.Reason = "RevokedLeakedExternalResource"
+
+	// settlePeriod is how old an object must be to be an orphan. A
+	// reconcile may still be recording younger ones.
+	settlePeriod = 5 * time.Minute
+)
+
+var (
+	providerConfigKind = schema.GroupVersionKind{Group: "tailscale.upbound.io", Version: "v1beta1", Kind: "ProviderConfig"}
+	keyKind            = schema.GroupVersionKind{Group: "tailnetkey.tailscale.upbound.io", Version: "v1alpha1", Kind: "Key"}
+	clientKind         = schema.GroupVersionKind{Group: "oauth.tailscale.upbound.io", Version: "v1alpha1", Kind: "Client"}
+	webhookKind        = schema.GroupVersionKind{Group: "webhook.tailscale.upbound.io", Version: "v1alpha1", Kind: "Webhook"}
+
+	// nouns name the objects of each kind in messages.
+	nouns = map[string]string{
+		keyKind.Kind:     "auth key",
+		clientKind.Kind:  "OAuth client",
+		webhookKind.Kind: "webhook",
+	}
+)
+
+// apiClient is the part of the Tailscale API used by this package.
+type apiClient interface {
+	ListKeys(ctx context.Context) ([]tsapi.Key, error)
+	DeleteKey(ctx context.Context, id string) error
+	ListWebhooks(ctx context.Context) ([]tsapi.Webhook, error)
+}
+
+// newClientFn returns an API client for the tailnet of a ProviderConfig.
+type newClientFn func(ctx context.Context, kube client.Client, providerConfig string) (apiClient, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, providerConfig string) (apiClient, error) {
+	return tsapi.NewForProviderConfig(ctx, kube, providerConfig)
+}
+
+// An Orphan is a key, OAuth client or webhook created by the provider that
+// is not the external name of any resource. Webhooks can only be suspected
+// to have been created by the provider.
+type Orphan struct {
+	// Kind of the resource managing such objects, such as Key.
+	Kind           string
+	ID             string
+	ProviderConfig string
+	Created        time.Time
+	State          string
+
+	// Resource the object was created for, nil if it is unowned.
+	Resource *unstructured.Unstructured
+}
+
+// Scanner periodically looks for orphans in the tailnet of each
+// ProviderConfig. It implements manager.Runnable.
+type Scanner struct {
+	kube        client.Client
+	rec         event.Recorder
+	log         logging.Logger
+	interval    time.Duration
+	revokeAfter time.Duration
+	newClient   newClientFn
+	now         func() time.Time
+}
+
+// NewScanner returns a Scanner scanning at the supplied interval. Leaked
+// orphans are revoked once they are older than revokeAfter, or never if it
+// is 0.
+func NewScanner(kube client.Client, rec event.Recorder, log logging.Logger, interval, revokeAfter time.Duration) *Scanner {
+	return &Scanner{
+		kube:        kube,
+		rec:         rec,
+		log:         log,
+		interval:    interval,
+		revokeAfter: revokeAfter,
+		newClient:   newAPIClient,
+		now:         time.Now,
+	}
+}
+
+// Start scans until the context is done. Only the leader scans, so that
+// replicas do not revoke the same orphans.
+func (s *Scanner) Start(ctx context.Context) error {
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		if err := s.Scan(ctx); err != nil {
+			s.log.Info("Cannot scan for orphaned keys and webhooks", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// Scan finds the orphans of the tailnet of each ProviderConfig, reports
+// them and revokes the leaked ones older than the grace period. Nothing is
+// scanned if the resources cannot be listed, which would make every object
+// look orphaned. Tailnets whose objects cannot be listed are logged and
+// skipped.
+func (s *Scanner) Scan(ctx context.Context) error {
+	rs, err := s.resources(ctx)
+	if err != nil {
+		return err
+	}
+	pcs, err := s.list(ctx, providerConfigKind)
+	if err != nil {
+		return err
+	}
+	type key struct{ pc, kind, state string }
+	counts := map[key]int{}
+	seen := map[string]bool{}
+	for _, pc := range pcs {
+		name := pc.GetName()
+		c, err := s.newClient(ctx, s.kube, name)
+		if err != nil {
+			s.log.Debug("Cannot create Tailscale API client", "providerConfig", name, "error", err)
+			continue
+		}
+		found, err := rs.find(ctx, c, name, s.now())
+		if err != nil {
+			s.log.Info("Cannot list keys and webhooks", "providerConfig", name, "error", err)
+		}
+		for _, o := range found {
+			// ProviderConfigs may share a tailnet.
+			if seen[o.ID] {
+				continue
+			}
+			seen[o.ID] = true
+			counts[key{pc: name, kind: o.Kind, state: o.State}]++
+			s.handle(ctx, c, o)
+		}
+	}
+	metrics.Orphans.Reset()
+	for k, n := range counts {
+		metrics.Orphans.WithLabelValues(k.pc, k.kind, k.state).Set(float64(n))
+	}
+	return nil
+}
+
+// handle reports an orphan, and revokes it if it leaked longer than the
+// grace period ago.
+func (s *Scanner) handle(ctx context.Context, c apiClient, o Orphan) {
+	noun := nouns[o.Kind]
+	log := s.log.WithValues("providerConfig", o.ProviderConfig, "kind", o.Kind, "id", o.ID, "created", o.Created)
+	if o.Resource == nil {
+		log.Info(fmt.Sprintf("Found an unowned %s: it was created by the provider for a resource that no longer exists, revoke it unless it was kept on purpose", noun))
+		return
+	}
+	log = log.WithValues("resource", o.Resource.GetName())
+	if o.State == StateSuspected {
+		log.Info(fmt.Sprintf("Found a %s with the endpoint URL of a resource that does not record it: it may have leaked or been created outside the provider", noun))
+		s.rec.Event(o.Resource, event.Warning(reasonSuspected, fmt.Errorf("%s %s has the endpoint URL of this resource but is not its external name, set the %s annotation to %s to adopt it, or delete it if it leaked", noun, o.ID, meta.AnnotationKeyExternalName, o.ID)))
+		return
+	}
+	if s.revokeAfter <= 0 || s.now().Sub(o.Created) < s.revokeAfter {
+		log.Info(fmt.Sprintf("Found a leaked %s: it was created for a resource that does not record it", noun))
+		s.rec.Event(o.Resource, event.Warning(reasonLeaked, fmt.Errorf("%s %s was created for this resource but is not its external name, set the %s annotation to %s to adopt it or delete it", noun, o.ID, meta.AnnotationKeyExternalName, o.ID)))
+		return
+	}
+	if err := revoke(ctx, c, o); err != nil {
+		log.Info(fmt.Sprintf("Cannot revoke leaked %s", noun), "error", err)
+		return
+	}
+	log.Info(fmt.Sprintf("Revoked leaked %s", noun))
+	s.rec.Event(o.Resource, event.Normal(reasonRevoked, fmt.Sprintf("Revoked %s %s, which was created for this resource but was not its external name for %s", noun, o.ID, s.revokeAfter)))
+}
+
+// revoke revokes a key or OAuth client.
+func revoke(ctx context.Context, c apiClient, o Orphan) error {
+	if err := c.DeleteKey(ctx, o.ID); err != nil && !tsapi.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// resources indexes the resources of the kinds whose objects may leak.
+type resources struct {
+	// externalNames of every resource.
+	externalNames map[string]bool
+	// byMarker are the keys and OAuth clients by their marker.
+	byMarker map[string]*unstructured.Unstructured
+	// byEndpoint are the webhooks by their endpoint URL.
+	byEndpoint map[string]*unstructured.Unstructured
+}
+
+func (s *Scanner) resources(ctx context.Context) (*resources, error) {
+	rs := &resources{
+		externalNames: map[string]bool{},
+		byMarker:      map[string]*unstructured.Unstructured{},
+		byEndpoint:    map[string]*unstructured.Unstructured{},
+	}
+	for _, gvk := range []schema.GroupVersionKind{keyKind, clientKind, webhookKind} {
+		l, err := s.list(ctx, gvk)
+		if err != nil {
+			return nil, err
+		}
+		for i := range l {
+			u := &l[i]
+			if n := meta.GetExternalName(u); n != "" {
+				rs.externalNames[n] = true
+			}
+			if gvk != webhookKind {
+				rs.byMarker[marker.Of(u.GetUID())] = u
+				continue
+			}
+			for _, spec := range []string{"forProvider", "initProvider"} {
+				if url, _, _ := unstructured.NestedString(u.Object, "spec", spec, "endpointUrl"); url != "" {
+					rs.byEndpoint[url] = u
+				}
+			}
+		}
+	}
+	return rs, nil
+}
+
+// find returns the orphans of a tailnet.
+func (rs *resources) find(ctx context.Context, c apiClient, pc string, now time.Time) ([]Orphan, error) {
+	var orphans []Orphan
+	var errs []error
+	keys, err := c.ListKeys(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("cannot list keys: %w", err))
+	}
+	for _, k := range keys {
+		if k.KeyType == tsapi.KeyTypeAPI || !k.Revoked.IsZero() || k.Invalid || rs.externalNames[k.ID] || now.Sub(k.Created) < settlePeriod {
+			continue
+		}
+		m, ok := marker.Parse(k.Description)
+		if !ok {
+			continue
+		}
+		o := Orphan{Kind: keyKind.Kind, ID: k.ID, ProviderConfig: pc, Created: k.Created, State: StateUnowned}
+		if k.KeyType == tsapi.KeyTypeClient {
+			o.Kind = clientKind.Kind
+		}
+		if mg, ok := rs.byMarker[m]; ok {
+			o.State, o.Resource = StateLeaked, mg
+		}
+		orphans = append(orphans, o)
+	}
+	hooks, err := c.ListWebhooks(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("cannot list webhooks: %w", err))
+	}
+	for _, w := range hooks {
+		mg, ok := rs.byEndpoint[w.EndpointURL]
+		if !ok || rs.externalNames[w.EndpointID] || now.Sub(w.Created) < settlePeriod {
+			continue
+		}
+		orphans = append(orphans, Orphan{Kind: webhookKind.Kind, ID: w.EndpointID, ProviderConfig: pc, Created: w.Created, State: StateSuspected, Resource: mg})
+	}
+	return orphans, errors.Join(errs...)
+}
+
+func (s *Scanner) list(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := s.kube.List(ctx, l); err != nil {
+		return nil, fmt.Errorf("cannot list %s: %w", gvk.Kind, err)
+	}
+	return l.Items, nil
+}
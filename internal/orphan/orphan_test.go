@@ -0,0 +1,209 @@
+package orphan
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/marker"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/metrics"
+)
+
+var now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// recorder records the reasons of the events of each object.
+type recorder struct {
+	events map[string][]string
+}
+
+func (r *recorder) Event(obj runtime.Object, e event.Event) {
+	name := obj.(client.Object).GetName()
+	r.events[name] = append(r.events[name], string(e.Reason))
+}
+
+func (r *recorder) WithAnnotations(_ ...string) event.Recorder { return r }
+
+type fakeClient struct {
+	keys    []tsapi.Key
+	hooks   []tsapi.Webhook
+	deleted []string
+}
+
+func (f *fakeClient) ListKeys(context.Context) ([]tsapi.Key, error) { return f.keys, nil }
+
+func (f *fakeClient) DeleteKey(_ context.Context, id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fakeClient) ListWebhooks(context.Context) ([]tsapi.Webhook, error) { return f.hooks, nil }
+
+func newResource(name string, uid types.UID, externalName string, forProvider map[string]any) unstructured.Unstructured {
+	u := unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"forProvider": forProvider}}}
+	u.SetName(name)
+	u.SetUID(uid)
+	if externalName != "" {
+		meta.SetExternalName(&u, externalName)
+	}
+	return u
+}
+
+// newTailnet returns the objects of a tailnet, created for the resources
+// listed by kube.
+func newTailnet() *fakeClient {
+	ci, old := marker.Of("1a2b3c4d-0000"), marker.Of("5e6f7081-0000")
+	created := now.Add(-2 * time.Hour)
+	return &fakeClient{
+		keys: []tsapi.Key{
+			{ID: "k-old", KeyType: tsapi.KeyTypeAuth, Description: "old " + old, Created: created},
+			{ID: "k-leaked", KeyType: tsapi.KeyTypeAuth, Description: "ci " + ci, Created: created},
+			{ID: "k-young", KeyType: tsapi.KeyTypeAuth, Description: "ci " + ci, Created: now.Add(-time.Minute)},
+			{ID: "k-revoked", KeyType: tsapi.KeyTypeAuth, Description: "ci " + ci, Created: created, Revoked: created},
+			{ID: "k-unowned", KeyType: tsapi.KeyTypeAuth, Description: marker.Of("ffffffff-0000"), Created: created},
+			{ID: "k-manual", KeyType: tsapi.KeyTypeAuth, Description: "laptop", Created: created},
+			{ID: "c-unowned", KeyType: tsapi.KeyTypeClient, Description: marker.Of("eeeeeeee-0000"), Created: created},
+		},
+		hooks: []tsapi.Webhook{
+			{EndpointID: "w-alerts", EndpointURL: "https://example.com/hook", Created: created},
+			{EndpointID: "w-suspected", EndpointURL: "https://example.com/hook", Created: created},
+			{EndpointID: "w-manual", EndpointURL: "https://example.com/other", Created: created},
+		},
+	}
+}
+
+func newKube(err error) *test.MockClient {
+	return &test.MockClient{
+		MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+			l := obj.(*unstructured.UnstructuredList)
+			switch l.GetKind() {
+			case "ProviderConfigList":
+				l.Items = []unstructured.Unstructured{newResource("default", "", "", nil), newResource("same-tailnet", "", "", nil)}
+			case "KeyList":
+				if err != nil {
+					return err
+				}
+				l.Items = []unstructured.Unstructured{
+					newResource("ci", "1a2b3c4d-0000", "", nil),
+					newResource("old", "5e6f7081-0000", "k-old", nil),
+				}
+			case "WebhookList":
+				l.Items = []unstructured.Unstructured{newResource("alerts", "", "w-alerts", map[string]any{"endpointUrl": "https://example.com/hook"})}
+			}
+			return nil
+		},
+	}
+}
+
+// gauges returns the value of each series of v by its labels.
+func gauges(t *testing.T, v *prometheus.GaugeVec) map[string]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 16)
+	v.Collect(ch)
+	close(ch)
+	out := map[string]float64{}
+	for m := range ch {
+		d := &dto.Metric{}
+		if err := m.Write(d); err != nil {
+			t.Fatal(err)
+		}
+		key := ""
+		for _, l := range d.GetLabel() {
+			key += l.GetValue() + "/"
+		}
+		out[key] = d.GetGauge().GetValue()
+	}
+	return out
+}
+
+func TestScan(t *testing.T) {
+	found := map[string]float64{
+		"Client/default/unowned/":    1,
+		"Key/default/leaked/":        1,
+		"Key/default/unowned/":       1,
+		"Webhook/default/suspected/": 1,
+	}
+	type want struct {
+		err     bool
+		deleted []string
+		events  map[string][]string
+		orphans map[string]float64
+	}
+	cases := map[string]struct {
+		reason      string
+		kube        client.Client
+		revokeAfter time.Duration
+		want        want
+	}{
+		"Report": {
+			reason: "Leaked and unowned objects should be reported, but not revoked without a grace period",
+			kube:   newKube(nil),
+			want: want{
+				events:  map[string][]string{"ci": {string(reasonLeaked)}, "alerts": {string(reasonSuspected)}},
+				orphans: found,
+			},
+		},
+		"GracePeriod": {
+			reason:      "Leaked objects younger than the grace period should not be revoked",
+			kube:        newKube(nil),
+			revokeAfter: 3 * time.Hour,
+			want: want{
+				events:  map[string][]string{"ci": {string(reasonLeaked)}, "alerts": {string(reasonSuspected)}},
+				orphans: found,
+			},
+		},
+		"Revoke": {
+			reason:      "Leaked objects older than the grace period should be revoked, unowned and suspected ones never",
+			kube:        newKube(nil),
+			revokeAfter: time.Hour,
+			want: want{
+				deleted: []string{"k-leaked"},
+				events:  map[string][]string{"ci": {string(reasonRevoked)}, "alerts": {string(reasonSuspected)}},
+				orphans: found,
+			},
+		},
+		"ListError": {
+			reason:      "Nothing should be scanned if the resources cannot be listed",
+			kube:        newKube(errors.New("boom")),
+			revokeAfter: time.Hour,
+			want:        want{err: true, events: map[string][]string{}, orphans: map[string]float64{}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			metrics.Orphans.Reset()
+			tailnet := newTailnet()
+			rec := &recorder{events: map[string][]string{}}
+			s := &Scanner{
+				kube:        tc.kube,
+				rec:         rec,
+				log:         logging.NewNopLogger(),
+				revokeAfter: tc.revokeAfter,
+				newClient: func(context.Context, client.Client, string) (apiClient, error) {
+					// Both ProviderConfigs use the same tailnet.
+					return tailnet, nil
+				},
+				now: func() time.Time { return now },
+			}
+			err := s.Scan(context.Background())
+			got := want{err: err != nil, deleted: tailnet.deleted, events: rec.events, orphans: gauges(t, metrics.Orphans)}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nScan(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
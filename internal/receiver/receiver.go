@@ -0,0 +1,107 @@
+// Package receiver turns Tailscale webhook events into Kubernetes events.
+//
+// Tailscale posts events to the endpoint of a Webhook resource, here
+// /webhooks/<name of the Webhook resource>. The receiver verifies the
+// Tailscale-Webhook-Signature header with the signing secret the Webhook
+// resource publishes in its connection secret, then records each event on
+// the resources it concerns and annotates them, which makes their
+// controllers reconcile them right away instead of at the next poll:
+//
+//   - node events, such as nodeCreated, nodeNeedsApproval and
+//     nodeKeyExpiringInOneDay, go to the device resources of the node, and
+//     to Fleets and DeviceApprovalPolicies where they affect them
+//   - policyUpdate goes to the ACLs
+//   - any other event is recorded on the Webhook resource itself
+//
+// Only resources using the same ProviderConfig as the Webhook resource are
+// considered, as other ProviderConfigs may manage other tailnets.
+package receiver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderSignature is the header carrying the payload signature.
+	HeaderSignature = "Tailscale-Webhook-Signature"
+
+	// AnnotationEvent records the last webhook event received for a
+	// resource, as <type>@<timestamp>. Changing it triggers a reconcile.
+	AnnotationEvent = "tailscale.upbound.io/webhook-event"
+
+	// SecretKey is the key of the signing secret in the connection secret
+	// of a Webhook resource.
+	SecretKey = "secret"
+
+	// MaxAge is how old a signature may be before it is rejected as a
+	// replay.
+	MaxAge = 5 * time.Minute
+)
+
+// Event is a Tailscale webhook event, see
+// https://tailscale.com/kb/1213/webhooks#events.
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Version   int       `json:"version"`
+	Type      string    `json:"type"`
+	Tailnet   string    `json:"tailnet"`
+	Message   string    `json:"message"`
+	Data      EventData `json:"data"`
+}
+
+// EventData holds the fields of an event's data used by the receiver.
+type EventData struct {
+	NodeID     string `json:"nodeID,omitempty"`
+	DeviceName string `json:"deviceName,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+// Verify checks the signature header of a payload. The signature has the
+// form t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<payload>">.
+func Verify(secret []byte, header string, body []byte, now time.Time) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	if ts == "" || len(sigs) == 0 {
+		return fmt.Errorf("malformed %s header", HeaderSignature)
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed %s timestamp: %w", HeaderSignature, err)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > MaxAge || age < -MaxAge {
+		return fmt.Errorf("signature timestamp is %s off", age.Round(time.Second))
+	}
+	want := Sign(secret, ts, body)
+	for _, s := range sigs {
+		if hmac.Equal([]byte(s), []byte(want)) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match")
+}
+
+// Sign returns the hex encoded v1 signature of a payload sent at the
+// supplied unix time.
+func Sign(secret []byte, ts string, body []byte) string {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(ts + "."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
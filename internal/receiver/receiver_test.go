@@ -0,0 +1,249 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+var (
+	testSecret = []byte("tskey-webhook-s3cr3t")
+	testNow    = time.Unix(1760000000, 0)
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`[{"type":"nodeCreated"}]`)
+	ts := strconv.FormatInt(testNow.Unix(), 10)
+	valid := "t=" + ts + ",v1=" + Sign(testSecret, ts, body)
+
+	cases := map[string]struct {
+		reason string
+		header string
+		now    time.Time
+		err    string
+	}{
+		"Valid": {
+			reason: "A payload signed with the secret should be accepted",
+			header: valid,
+			now:    testNow.Add(time.Minute),
+		},
+		"WrongSignature": {
+			reason: "A payload signed with another secret should be rejected",
+			header: "t=" + ts + ",v1=" + Sign([]byte("other"), ts, body),
+			now:    testNow,
+			err:    "signature does not match",
+		},
+		"Replayed": {
+			reason: "An old signature should be rejected",
+			header: valid,
+			now:    testNow.Add(time.Hour),
+			err:    "signature timestamp is 1h0m0s off",
+		},
+		"Malformed": {
+			reason: "A header without a signature should be rejected",
+			header: "t=" + ts,
+			now:    testNow,
+			err:    "malformed Tailscale-Webhook-Signature header",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Verify(testSecret, tc.header, body, tc.now)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if diff := cmp.Diff(tc.err, got); diff != "" {
+				t.Errorf("\n%s\nVerify(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+type recorded struct {
+	kind   string
+	name   string
+	reason event.Reason
+	typ    event.Type
+}
+
+type fakeRecorder struct {
+	events []recorded
+}
+
+func (f *fakeRecorder) Event(obj runtime.Object, e event.Event) {
+	u := obj.(*unstructured.Unstructured)
+	f.events = append(f.events, recorded{kind: u.GetKind(), name: u.GetName(), reason: e.Reason, typ: e.Type})
+}
+
+func (f *fakeRecorder) WithAnnotations(...string) event.Recorder {
+	return f
+}
+
+type fakeDeviceClient struct{}
+
+func (fakeDeviceClient) GetDevice(_ context.Context, id string) (*tsapi.Device, error) {
+	return &tsapi.Device{ID: "12345", NodeID: id}, nil
+}
+
+func newResource(kind, name, externalName, pc string) unstructured.Unstructured {
+	u := unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"providerConfigRef": map[string]any{"name": pc}},
+	}}
+	u.SetKind(kind)
+	u.SetName(name)
+	if externalName != "" {
+		u.SetAnnotations(map[string]string{"crossplane.io/external-name": externalName})
+	}
+	return u
+}
+
+func TestServeHTTP(t *testing.T) {
+	type want struct {
+		status  int
+		events  []recorded
+		touched []string
+	}
+	cases := map[string]struct {
+		reason  string
+		payload string
+		secret  []byte
+		want    want
+	}{
+		"NodeNeedsApproval": {
+			reason:  "Node events should be recorded on the node's device resources and approval policies",
+			payload: `[{"timestamp":"2025-10-09T08:53:20Z","type":"nodeNeedsApproval","message":"Node laptop needs approval","data":{"nodeID":"nABC"}}]`,
+			secret:  testSecret,
+			want: want{
+				status: http.StatusOK,
+				events: []recorded{
+					{kind: "Tags", name: "laptop-tags", reason: "NodeNeedsApproval", typ: event.TypeNormal},
+					{kind: "DeviceApprovalPolicy", name: "laptops", reason: "NodeNeedsApproval", typ: event.TypeNormal},
+				},
+				touched: []string{"laptop-tags", "laptops"},
+			},
+		},
+		"KeyExpiring": {
+			reason:  "Expiring keys should be recorded as warnings",
+			payload: `[{"timestamp":"2025-10-09T08:53:20Z","type":"nodeKeyExpiringInOneDay","message":"Key expires","data":{"nodeID":"nABC"}}]`,
+			secret:  testSecret,
+			want: want{
+				status:  http.StatusOK,
+				events:  []recorded{{kind: "Tags", name: "laptop-tags", reason: "NodeKeyExpiringInOneDay", typ: event.TypeWarning}},
+				touched: []string{"laptop-tags"},
+			},
+		},
+		"PolicyUpdate": {
+			reason:  "Policy updates should be recorded on the ACLs of the same ProviderConfig",
+			payload: `[{"timestamp":"2025-10-09T08:53:20Z","type":"policyUpdate","message":"Policy updated"}]`,
+			secret:  testSecret,
+			want: want{
+				status:  http.StatusOK,
+				events:  []recorded{{kind: "ACL", name: "policy", reason: "PolicyUpdate", typ: event.TypeNormal}},
+				touched: []string{"policy"},
+			},
+		},
+		"Other": {
+			reason:  "Other events should be recorded on the Webhook",
+			payload: `[{"timestamp":"2025-10-09T08:53:20Z","type":"userCreated","message":"User created"}]`,
+			secret:  testSecret,
+			want: want{
+				status: http.StatusOK,
+				events: []recorded{{kind: "Webhook", name: "events", reason: "UserCreated", typ: event.TypeNormal}},
+			},
+		},
+		"BadSignature": {
+			reason:  "Payloads not signed with the Webhook's secret should be rejected",
+			payload: `[{"type":"policyUpdate"}]`,
+			secret:  []byte("other"),
+			want:    want{status: http.StatusUnauthorized},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var touched []string
+			kube := &test.MockClient{
+				MockGet: func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+					switch o := obj.(type) {
+					case *unstructured.Unstructured:
+						wh := newResource("Webhook", "events", "", "default")
+						_ = unstructured.SetNestedStringMap(wh.Object, map[string]string{"name": "events-webhook", "namespace": "crossplane-system"}, "spec", "writeConnectionSecretToRef")
+						o.Object = wh.Object
+					case *corev1.Secret:
+						// The external secret store client needs the
+						// Webhook as controller to find the secret.
+						if c := metav1.GetControllerOf(o); c == nil || c.Kind != "Webhook" || c.Name != "events" {
+							return errors.New("connection secret read without its Webhook as controller")
+						}
+						o.Data = map[string][]byte{SecretKey: testSecret}
+					}
+					return nil
+				},
+				MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+					l := obj.(*unstructured.UnstructuredList)
+					switch strings.TrimSuffix(l.GetKind(), "List") {
+					case "Tags":
+						l.Items = []unstructured.Unstructured{
+							newResource("Tags", "laptop-tags", "12345", "default"),
+							newResource("Tags", "server-tags", "67890", "default"),
+						}
+					case "Key":
+						l.Items = []unstructured.Unstructured{newResource("Key", "other-tailnet", "12345", "other")}
+					case "ACL":
+						l.Items = []unstructured.Unstructured{newResource("ACL", "policy", "", "default")}
+					case "DeviceApprovalPolicy":
+						l.Items = []unstructured.Unstructured{newResource("DeviceApprovalPolicy", "laptops", "", "")}
+					}
+					return nil
+				},
+				MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+					if obj.GetAnnotations()[AnnotationEvent] != "" {
+						touched = append(touched, obj.GetName())
+					}
+					return nil
+				},
+			}
+			rec := &fakeRecorder{}
+			s := &Server{
+				kube:   kube,
+				record: rec,
+				log:    logging.NewNopLogger(),
+				now:    func() time.Time { return testNow },
+				newClient: func(context.Context, client.Client, string) (deviceClient, error) {
+					return fakeDeviceClient{}, nil
+				},
+			}
+
+			ts := strconv.FormatInt(testNow.Unix(), 10)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/events", strings.NewReader(tc.payload))
+			req.SetPathValue("name", "events")
+			req.Header.Set(HeaderSignature, "t="+ts+",v1="+Sign(tc.secret, ts, []byte(tc.payload)))
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+
+			got := want{status: w.Code, events: rec.events, touched: touched}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{}, recorded{})); diff != "" {
+				t.Errorf("\n%s\nServeHTTP(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/internal/clients/tsapi"
+)
+
+const (
+	maxBody         = 1 << 20
+	shutdownTimeout = 10 * time.Second
+
+	defaultProviderConfig = "default"
+)
+
+var (
+	webhookGroupVersionKind = schema.GroupVersionKind{Group: "webhook.tailscale.upbound.io", Version: "v1alpha1", Kind: "Webhook"}
+
+	deviceKinds = []schema.GroupVersionKind{
+		{Group: "device.tailscale.upbound.io", Version: "v1alpha1", Kind: "Tags"},
+		{Group: "device.tailscale.upbound.io", Version: "v1alpha1", Kind: "Authorization"},
+		{Group: "device.tailscale.upbound.io", Version: "v1alpha1", Kind: "Key"},
+		{Group: "device.tailscale.upbound.io", Version: "v1alpha1", Kind: "SubnetRoutes"},
+	}
+	aclKind      = schema.GroupVersionKind{Group: "acl.tailscale.upbound.io", Version: "v1alpha1", Kind: "ACL"}
+	fleetKind    = schema.GroupVersionKind{Group: "tailscale.upbound.io", Version: "v1alpha1", Kind: "Fleet"}
+	approvalKind = schema.GroupVersionKind{Group: "tailscale.upbound.io", Version: "v1alpha1", Kind: "DeviceApprovalPolicy"}
+)
+
+// nodeEvents are the events about a node, with whether they warn about it.
+var nodeEvents = map[string]bool{
+	"nodeCreated":             false,
+	"nodeNeedsApproval":       false,
+	"nodeApproved":            false,
+	"nodeDeleted":             false,
+	"nodeKeyExpiringInOneDay": true,
+	"nodeKeyExpired":          true,
+}
+
+// deviceClient is the part of the Tailscale API used by this package.
+type deviceClient interface {
+	GetDevice(ctx context.Context, id string) (*tsapi.Device, error)
+}
+
+// newClientFn returns an API client for the tailnet of a ProviderConfig.
+type newClientFn func(ctx context.Context, kube client.Client, providerConfig string) (deviceClient, error)
+
+func newAPIClient(ctx context.Context, kube client.Client, providerConfig string) (deviceClient, error) {
+	return tsapi.NewForProviderConfig(ctx, kube, providerConfig)
+}
+
+// Server receives Tailscale webhook events. It implements
+// manager.Runnable.
+type Server struct {
+	addr      string
+	kube      client.Client
+	record    event.Recorder
+	log       logging.Logger
+	newClient newClientFn
+	now       func() time.Time
+}
+
+// New returns a Server listening on the supplied address.
+func New(addr string, kube client.Client, record event.Recorder, log logging.Logger) *Server {
+	return &Server{addr: addr, kube: kube, record: record, log: log, newClient: newAPIClient, now: time.Now}
+}
+
+// NeedLeaderElection is false so that every replica of the provider can
+// receive events.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("POST /webhooks/{name}", s)
+	srv := &http.Server{Addr: s.addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errs := make(chan error, 1)
+	go func() {
+		s.log.Info("Starting webhook receiver", "address", s.addr)
+		errs <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return fmt.Errorf("webhook receiver failed: %w", err)
+	case <-ctx.Done():
+	}
+	sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(sctx)
+}
+
+// ServeHTTP handles the events posted for one Webhook resource.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := r.PathValue("name")
+	log := s.log.WithValues("webhook", name)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		http.Error(w, "cannot read payload", http.StatusBadRequest)
+		return
+	}
+	wh := &unstructured.Unstructured{}
+	wh.SetGroupVersionKind(webhookGroupVersionKind)
+	if err := s.kube.Get(ctx, types.NamespacedName{Name: name}, wh); err != nil {
+		if kerrors.IsNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+		log.Info("Cannot get webhook", "error", err)
+		http.Error(w, "cannot get webhook", http.StatusInternalServerError)
+		return
+	}
+	secret, err := s.secret(ctx, wh)
+	if err != nil {
+		log.Info("Cannot get webhook signing secret", "error", err)
+		http.Error(w, "webhook signing secret is not available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := Verify(secret, r.Header.Get(HeaderSignature), body, s.now()); err != nil {
+		log.Debug("Rejected webhook payload", "error", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var events []Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		http.Error(w, "payload is not a list of events", http.StatusBadRequest)
+		return
+	}
+	var failed bool
+	for _, e := range events {
+		if err := s.dispatch(ctx, wh, e); err != nil {
+			log.Info("Cannot handle webhook event", "type", e.Type, "error", err)
+			failed = true
+		}
+	}
+	if failed {
+		// Tailscale retries failed deliveries.
+		http.Error(w, "cannot handle all events", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// secret returns the signing secret from the connection secret of the
+// supplied Webhook resource. The Webhook is set as the controller of the
+// Secret so that the client reads it from an external secret store if the
+// Webhook's ProviderConfig uses one, see package secretstore.
+func (s *Server) secret(ctx context.Context, wh *unstructured.Unstructured) ([]byte, error) {
+	ref, _, _ := unstructured.NestedStringMap(wh.Object, "spec", "writeConnectionSecretToRef")
+	if ref["name"] == "" {
+		return nil, errors.New("spec.writeConnectionSecretToRef is not set")
+	}
+	sec := &corev1.Secret{}
+	sec.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(wh, wh.GroupVersionKind())})
+	if err := s.kube.Get(ctx, types.NamespacedName{Namespace: ref["namespace"], Name: ref["name"]}, sec); err != nil {
+		return nil, fmt.Errorf("cannot get connection secret: %w", err)
+	}
+	v := sec.Data[SecretKey]
+	if len(v) == 0 {
+		return nil, fmt.Errorf("connection secret has no %s key", SecretKey)
+	}
+	return v, nil
+}
+
+// dispatch records an event on the resources it concerns and triggers
+// their reconcile.
+func (s *Server) dispatch(ctx context.Context, wh *unstructured.Unstructured, e Event) error {
+	pc := providerConfigOf(wh)
+	var targets []*unstructured.Unstructured
+	var err error
+	warning, isNode := nodeEvents[e.Type]
+	switch {
+	case isNode && e.Data.NodeID != "":
+		targets, err = s.nodeTargets(ctx, pc, e)
+	case e.Type == "policyUpdate":
+		targets, err = s.list(ctx, pc, aclKind, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		targets = []*unstructured.Unstructured{wh}
+	}
+
+	ev := event.Normal(reason(e.Type), e.Message)
+	if warning {
+		ev = event.Warning(reason(e.Type), errors.New(e.Message))
+	}
+	for _, t := range targets {
+		s.record.Event(t, ev)
+		if t == wh {
+			continue
+		}
+		if err := s.touch(ctx, t, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodeTargets returns the device resources of the node an event is about,
+// and the Fleets and DeviceApprovalPolicies it affects.
+func (s *Server) nodeTargets(ctx context.Context, pc string, e Event) ([]*unstructured.Unstructured, error) {
+	// Device resources may refer to the node by node ID or by legacy ID.
+	ids := []string{e.Data.NodeID}
+	if e.Type != "nodeDeleted" {
+		if c, err := s.newClient(ctx, s.kube, pc); err == nil {
+			if d, err := c.GetDevice(ctx, e.Data.NodeID); err == nil && d.ID != "" {
+				ids = append(ids, d.ID)
+			}
+		}
+	}
+	var out []*unstructured.Unstructured
+	for _, gvk := range deviceKinds {
+		l, err := s.list(ctx, pc, gvk, func(u *unstructured.Unstructured) bool {
+			return slices.Contains(ids, meta.GetExternalName(u))
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l...)
+	}
+	var extra []schema.GroupVersionKind
+	switch e.Type {
+	case "nodeCreated", "nodeDeleted":
+		extra = append(extra, fleetKind)
+	case "nodeNeedsApproval":
+		extra = append(extra, approvalKind)
+	}
+	for _, gvk := range extra {
+		l, err := s.list(ctx, pc, gvk, nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l...)
+	}
+	return out, nil
+}
+
+// list returns the resources of a kind that use the supplied
+// ProviderConfig, are not being deleted and match the optional filter.
+func (s *Server) list(ctx context.Context, pc string, gvk schema.GroupVersionKind, match func(*unstructured.Unstructured) bool) ([]*unstructured.Unstructured, error) {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := s.kube.List(ctx, l); err != nil {
+		return nil, fmt.Errorf("cannot list %s: %w", gvk.Kind, err)
+	}
+	var out []*unstructured.Unstructured
+	for i := range l.Items {
+		u := &l.Items[i]
+		if u.GetDeletionTimestamp() != nil || providerConfigOf(u) != pc {
+			continue
+		}
+		if match == nil || match(u) {
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+// touch annotates a resource with the event, which triggers its reconcile.
+func (s *Server) touch(ctx context.Context, u *unstructured.Unstructured, e Event) error {
+	patch := client.MergeFrom(u.DeepCopy())
+	meta.AddAnnotations(u, map[string]string{AnnotationEvent: e.Type + "@" + e.Timestamp.UTC().Format(time.RFC3339)})
+	if err := s.kube.Patch(ctx, u, patch); err != nil {
+		return fmt.Errorf("cannot annotate %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+	return nil
+}
+
+func providerConfigOf(u *unstructured.Unstructured) string {
+	if name, _, _ := unstructured.NestedString(u.Object, "spec", "providerConfigRef", "name"); name != "" {
+		return name
+	}
+	return defaultProviderConfig
+}
+
+// reason returns the event reason for a Tailscale event type, such as
+// NodeCreated for nodeCreated.
+func reason(t string) event.Reason {
+	if t == "" {
+		return "WebhookEvent"
+	}
+	return event.Reason(strings.ToUpper(t[:1]) + t[1:])
+}
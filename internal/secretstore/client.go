@@ -0,0 +1,235 @@
+package secretstore
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1beta1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/podauthkey"
+)
+
+// Kinds are the kinds whose connection details are written to the store.
+var Kinds = []schema.GroupKind{
+	{Group: "tailnetkey.tailscale.upbound.io", Kind: "Key"},
+	{Group: "oauth.tailscale.upbound.io", Kind: "Client"},
+	{Group: "webhook.tailscale.upbound.io", Kind: "Webhook"},
+}
+
+// NewManager returns a manager whose client writes the connection Secrets
+// of Kinds to the store of their ProviderConfig, if any. Plugins are
+// connected to with the supplied TLS configuration, or without TLS if nil.
+//
+// Code reading connection Secrets outside the managed reconciler must use
+// this client and set the controller reference of the Secret it gets, like
+// the webhook receiver does for the signing secret of a Webhook. The Keys of
+// a PodAuthKey are excluded: their pods mount the Secret, so it is always
+// written to Kubernetes.
+func NewManager(mgr ctrl.Manager, t *tls.Config, log logging.Logger) ctrl.Manager {
+	return &manager{Manager: mgr, client: newClient(mgr.GetClient(), newPlugins(t).store, log)}
+}
+
+type manager struct {
+	ctrl.Manager
+	client client.Client
+}
+
+func (m *manager) GetClient() client.Client {
+	return m.client
+}
+
+// storeFn returns the store of a StoreConfig.
+type storeFn func(sc *v1alpha1.StoreConfig) (Store, error)
+
+// storeClient diverts the connection Secrets of Kinds to a store.
+type storeClient struct {
+	client.Client
+
+	store storeFn
+	log   logging.Logger
+}
+
+func newClient(c client.Client, store storeFn, log logging.Logger) *storeClient {
+	return &storeClient{Client: c, store: store, log: log}
+}
+
+// Get reads a connection Secret from the store, and any other object from
+// the API server. A Secret is a connection Secret if its controller
+// reference is set to a resource of Kinds, as it is for the Secret the
+// managed reconciler is about to publish.
+func (c *storeClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, o ...client.GetOption) error {
+	s, ok := obj.(*corev1.Secret)
+	if !ok {
+		return c.Client.Get(ctx, key, obj, o...)
+	}
+	st, name, err := c.secretStore(ctx, s)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		return c.Client.Get(ctx, key, obj, o...)
+	}
+	data, err := st.Read(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return kerrors.NewNotFound(corev1.Resource("secrets"), key.Name)
+	}
+	s.Data = data
+	return nil
+}
+
+// Create writes a connection Secret to the store, and creates any other
+// object.
+func (c *storeClient) Create(ctx context.Context, obj client.Object, o ...client.CreateOption) error {
+	s, ok := obj.(*corev1.Secret)
+	if !ok {
+		return c.Client.Create(ctx, obj, o...)
+	}
+	st, name, err := c.secretStore(ctx, s)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		return c.Client.Create(ctx, obj, o...)
+	}
+	return st.Write(ctx, name, s.Data)
+}
+
+// Patch writes the desired connection Secret of the patch to the store, and
+// patches any other object.
+func (c *storeClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, o ...client.PatchOption) error {
+	s, ok := obj.(*corev1.Secret)
+	if !ok {
+		return c.Client.Patch(ctx, obj, patch, o...)
+	}
+	st, name, err := c.secretStore(ctx, s)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		return c.Client.Patch(ctx, obj, patch, o...)
+	}
+	raw, err := patch.Data(obj)
+	if err != nil {
+		return fmt.Errorf("cannot read connection secret patch: %w", err)
+	}
+	desired := &corev1.Secret{}
+	if err := json.Unmarshal(raw, desired); err != nil {
+		return fmt.Errorf("cannot read connection secret patch: %w", err)
+	}
+	return st.Write(ctx, name, desired.Data)
+}
+
+// Update deletes the connection details of a resource of Kinds from the
+// store when its managed finalizer is removed, before updating it.
+func (c *storeClient) Update(ctx context.Context, obj client.Object, o ...client.UpdateOption) error {
+	mg, ok := obj.(resource.LegacyManaged)
+	if !ok || !meta.WasDeleted(obj) || meta.FinalizerExists(obj, managed.FinalizerName) || !c.diverted(obj) {
+		return c.Client.Update(ctx, obj, o...)
+	}
+	if ref := mg.GetWriteConnectionSecretToReference(); ref != nil {
+		st, name, err := c.storeOf(ctx, mg, *ref)
+		if err != nil {
+			return err
+		}
+		if st != nil {
+			if err := st.Delete(ctx, name); err != nil {
+				return err
+			}
+			c.log.Debug("Deleted connection details from external secret store", "name", name)
+		}
+	}
+	return c.Client.Update(ctx, obj, o...)
+}
+
+// diverted reports whether obj is one of Kinds.
+func (c *storeClient) diverted(obj client.Object) bool {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return false
+	}
+	return isKind(gvk.GroupKind())
+}
+
+func isKind(gk schema.GroupKind) bool {
+	for _, k := range Kinds {
+		if k == gk {
+			return true
+		}
+	}
+	return false
+}
+
+// secretStore returns the store and scoped name of a connection Secret
+// controlled by a resource of Kinds, or a nil store if it is written to the
+// API server.
+func (c *storeClient) secretStore(ctx context.Context, s *corev1.Secret) (Store, string, error) {
+	owner := metav1.GetControllerOf(s)
+	if owner == nil {
+		return nil, "", nil
+	}
+	gvk := schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind)
+	if !isKind(gvk.GroupKind()) {
+		return nil, "", nil
+	}
+	obj, err := c.Scheme().New(gvk)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot create %s: %w", gvk.Kind, err)
+	}
+	mg, ok := obj.(resource.LegacyManaged)
+	if !ok {
+		return nil, "", nil
+	}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: owner.Name}, mg); err != nil {
+		return nil, "", fmt.Errorf("cannot get owner of connection secret: %w", err)
+	}
+	return c.storeOf(ctx, mg, xpv1.SecretReference{Name: s.GetName(), Namespace: s.GetNamespace()})
+}
+
+// storeOf returns the store of the ProviderConfig of mg and the scoped name
+// of its connection Secret, or a nil store if the ProviderConfig does not
+// reference a StoreConfig or mg is the Key of a PodAuthKey.
+func (c *storeClient) storeOf(ctx context.Context, mg resource.LegacyManaged, ref xpv1.SecretReference) (Store, string, error) {
+	if _, ok := mg.GetLabels()[podauthkey.LabelOwner]; ok {
+		return nil, "", nil
+	}
+	pcName := "default"
+	if r := mg.GetProviderConfigReference(); r != nil {
+		pcName = r.Name
+	}
+	pc := &v1beta1.ProviderConfig{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: pcName}, pc); err != nil {
+		return nil, "", fmt.Errorf("cannot get provider config: %w", err)
+	}
+	if pc.Spec.StoreConfigReference == nil {
+		return nil, "", nil
+	}
+	sc := &v1alpha1.StoreConfig{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: pc.Spec.StoreConfigReference.Name}, sc); err != nil {
+		return nil, "", fmt.Errorf("cannot get store config: %w", err)
+	}
+	st, err := c.store(sc)
+	if err != nil {
+		return nil, "", err
+	}
+	return st, path.Join(sc.Spec.DefaultScope, ref.Namespace, ref.Name), nil
+}
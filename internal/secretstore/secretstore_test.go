@@ -0,0 +1,171 @@
+package secretstore
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/v2/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/tailnetkey/v1alpha1"
+	rootv1alpha1 "github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1beta1"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller/podauthkey"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/secretstore/stub"
+)
+
+// newKey returns a Key named ci, or the Key of a PodAuthKey named pod, using
+// the vault ProviderConfig, or another Key using the default ProviderConfig.
+func newKey(name string) *v1alpha1.Key {
+	pc := "default"
+	if name == "ci" || name == "pod" {
+		pc = "vault"
+	}
+	k := &v1alpha1.Key{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)}}
+	if name == "pod" {
+		k.SetLabels(map[string]string{podauthkey.LabelOwner: "pak"})
+	}
+	k.SetProviderConfigReference(&xpv1.Reference{Name: pc})
+	k.SetWriteConnectionSecretToReference(&xpv1.SecretReference{Name: name + "-key", Namespace: "ci"})
+	return k
+}
+
+// fakeKube serves the Key, ProviderConfigs and StoreConfig of the tests,
+// and records the Secrets written to the API server.
+func fakeKube(t *testing.T, s *runtime.Scheme, endpoint string, written *[]string) *test.MockClient {
+	t.Helper()
+	return &test.MockClient{
+		MockScheme: test.NewMockSchemeFn(s),
+		MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			switch o := obj.(type) {
+			case *v1alpha1.Key:
+				newKey(key.Name).DeepCopyInto(o)
+			case *v1beta1.ProviderConfig:
+				o.SetName(key.Name)
+				if key.Name == "vault" {
+					o.Spec.StoreConfigReference = &xpv1.Reference{Name: "vault"}
+				}
+			case *corev1.Secret:
+				return kerrors.NewNotFound(corev1.Resource("secrets"), key.Name)
+			case *rootv1alpha1.StoreConfig:
+				o.Spec = rootv1alpha1.StoreConfigSpec{DefaultScope: "crossplane", Plugin: rootv1alpha1.PluginStoreConfig{Endpoint: endpoint}}
+			default:
+				t.Errorf("unexpected get of %T", obj)
+			}
+			return nil
+		},
+		MockCreate: func(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+			*written = append(*written, obj.GetName())
+			return nil
+		},
+		MockUpdate: test.NewMockUpdateFn(nil),
+	}
+}
+
+func TestPublishConnection(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugin := stub.New()
+	go plugin.Serve(lis) //nolint:errcheck // Stops when the listener is closed.
+	t.Cleanup(func() { _ = lis.Close() })
+
+	var written []string
+	kube := newClient(fakeKube(t, s, lis.Addr().String(), &written), newPlugins(nil).store, logging.NewNopLogger())
+	pub := managed.NewAPISecretPublisher(kube, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key := newKey("ci")
+	if _, err := pub.PublishConnection(ctx, key, managed.ConnectionDetails{"attribute.key": []byte("tskey-auth-1")}); err != nil {
+		t.Fatalf("PublishConnection(...): %v", err)
+	}
+	if _, err := pub.PublishConnection(ctx, key, managed.ConnectionDetails{"attribute.key": []byte("tskey-auth-2"), "attribute.id": []byte("k1")}); err != nil {
+		t.Fatalf("PublishConnection(...): %v", err)
+	}
+	want := map[string][]byte{"attribute.key": []byte("tskey-auth-2"), "attribute.id": []byte("k1")}
+	if diff := cmp.Diff(want, plugin.Secret("crossplane/ci/ci-key")); diff != "" {
+		t.Errorf("PublishConnection(...): -want secret in store, +got:\n%s", diff)
+	}
+
+	// Resources of a ProviderConfig without a StoreConfig still get
+	// Kubernetes Secrets.
+	if _, err := pub.PublishConnection(ctx, newKey("other"), managed.ConnectionDetails{"attribute.key": []byte("tskey-auth-3")}); err != nil {
+		t.Fatalf("PublishConnection(...): %v", err)
+	}
+	// Pods mount the Secrets of the Keys of a PodAuthKey, so they are never
+	// written to the store.
+	if _, err := pub.PublishConnection(ctx, newKey("pod"), managed.ConnectionDetails{"attribute.key": []byte("tskey-auth-4")}); err != nil {
+		t.Fatalf("PublishConnection(...): %v", err)
+	}
+	if got := plugin.Secret("crossplane/ci/pod-key"); got != nil {
+		t.Errorf("PublishConnection(...): want no secret in store for PodAuthKey Keys, got %v", got)
+	}
+	if diff := cmp.Diff([]string{"other-key", "pod-key"}, written); diff != "" {
+		t.Errorf("PublishConnection(...): -want Kubernetes Secrets, +got:\n%s", diff)
+	}
+
+	// Removing the managed finalizer of a deleted resource deletes its
+	// connection details.
+	now := metav1.Now()
+	key.SetDeletionTimestamp(&now)
+	key.SetFinalizers([]string{managed.FinalizerName})
+	if err := resource.NewAPIFinalizer(kube, managed.FinalizerName).RemoveFinalizer(ctx, key); err != nil {
+		t.Fatalf("RemoveFinalizer(...): %v", err)
+	}
+	if got := plugin.Secret("crossplane/ci/ci-key"); got != nil {
+		t.Errorf("RemoveFinalizer(...): want secret deleted from store, got %v", got)
+	}
+}
+
+func TestSecretStoreOtherOwners(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(&test.MockClient{MockScheme: test.NewMockSchemeFn(s)}, nil, logging.NewNopLogger())
+
+	cases := map[string]struct {
+		reason string
+		owner  *metav1.OwnerReference
+	}{
+		"NoOwner": {
+			reason: "Secrets without a controller are not connection secrets",
+		},
+		"OtherKind": {
+			reason: "Connection secrets of other kinds are written to the API server",
+			owner:  &metav1.OwnerReference{APIVersion: "acl.tailscale.upbound.io/v1alpha1", Kind: "ACL", Name: "acl", Controller: ptr.To(true)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s", Namespace: "ns"}}
+			if tc.owner != nil {
+				sec.OwnerReferences = []metav1.OwnerReference{*tc.owner}
+			}
+			st, _, err := c.secretStore(context.Background(), sec)
+			if err != nil || st != nil {
+				t.Errorf("\n%s\nsecretStore(...): want no store, got %v, %v", tc.reason, st, err)
+			}
+		})
+	}
+}
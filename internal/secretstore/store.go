@@ -0,0 +1,124 @@
+// Package secretstore writes the connection details of auth keys, OAuth
+// clients and webhooks to an External Secret Store plugin, such as the
+// Vault plugin, instead of Kubernetes Secrets.
+//
+// crossplane-runtime v2 no longer lets controllers replace the connection
+// publisher of the managed reconciler, which writes connection Secrets with
+// the client of the manager. NewManager therefore returns a manager whose
+// client diverts these Secrets to the store configured by the StoreConfig
+// their ProviderConfig references, and deletes them from the store when the
+// resource is deleted. Every other request reaches the API server.
+package secretstore
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	essproto "github.com/crossplane/crossplane-runtime/v2/apis/proto/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/v1alpha1"
+)
+
+// A Store reads and writes secrets by their scoped name.
+type Store interface {
+	// Read returns the data of a secret, which is empty if there is none.
+	Read(ctx context.Context, name string) (map[string][]byte, error)
+	// Write writes the data of a secret, keeping keys that are not
+	// supplied.
+	Write(ctx context.Context, name string, data map[string][]byte) error
+	// Delete deletes a secret.
+	Delete(ctx context.Context, name string) error
+}
+
+// pluginStore is a Store served by an External Secret Store plugin.
+type pluginStore struct {
+	client essproto.ExternalSecretStorePluginServiceClient
+	config *essproto.ConfigReference
+}
+
+func (s *pluginStore) Read(ctx context.Context, name string) (map[string][]byte, error) {
+	rsp, err := s.client.GetSecret(ctx, &essproto.GetSecretRequest{Config: s.config, Secret: &essproto.Secret{ScopedName: name}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get secret %s from plugin: %w", name, err)
+	}
+	return rsp.GetSecret().GetData(), nil
+}
+
+func (s *pluginStore) Write(ctx context.Context, name string, data map[string][]byte) error {
+	_, err := s.client.ApplySecret(ctx, &essproto.ApplySecretRequest{Config: s.config, Secret: &essproto.Secret{ScopedName: name, Data: data}})
+	if err != nil {
+		return fmt.Errorf("cannot apply secret %s to plugin: %w", name, err)
+	}
+	return nil
+}
+
+func (s *pluginStore) Delete(ctx context.Context, name string) error {
+	_, err := s.client.DeleteKeys(ctx, &essproto.DeleteKeysRequest{Config: s.config, Secret: &essproto.Secret{ScopedName: name}})
+	if err != nil {
+		return fmt.Errorf("cannot delete secret %s from plugin: %w", name, err)
+	}
+	return nil
+}
+
+// plugins keeps a connection to each plugin endpoint.
+type plugins struct {
+	creds credentials.TransportCredentials
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+func newPlugins(t *tls.Config) *plugins {
+	creds := insecure.NewCredentials()
+	if t != nil {
+		creds = credentials.NewTLS(t)
+	}
+	return &plugins{creds: creds, conns: map[string]*grpc.ClientConn{}}
+}
+
+// store returns the Store of the supplied StoreConfig.
+func (p *plugins) store(sc *v1alpha1.StoreConfig) (Store, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	endpoint := sc.Spec.Plugin.Endpoint
+	conn, ok := p.conns[endpoint]
+	if !ok {
+		var err error
+		if conn, err = grpc.NewClient(endpoint, grpc.WithTransportCredentials(p.creds)); err != nil {
+			return nil, fmt.Errorf("cannot connect to plugin %s: %w", endpoint, err)
+		}
+		p.conns[endpoint] = conn
+	}
+	ref := sc.Spec.Plugin.ConfigRef
+	return &pluginStore{
+		client: essproto.NewExternalSecretStorePluginServiceClient(conn),
+		config: &essproto.ConfigReference{ApiVersion: ref.APIVersion, Kind: ref.Kind, Name: ref.Name},
+	}, nil
+}
+
+// TLSConfig returns the mutual TLS configuration of the connections to the
+// plugins, from the ca.crt, tls.crt and tls.key files in dir.
+func TLSConfig(dir string) (*tls.Config, error) {
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.crt")) //nolint:gosec // dir is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("cannot parse CA certificate")
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot load client certificate: %w", err)
+	}
+	return &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
@@ -0,0 +1,85 @@
+// Package stub is an in-memory External Secret Store plugin, for tests and
+// local development without Vault.
+package stub
+
+import (
+	"context"
+	"maps"
+	"net"
+	"sync"
+
+	essproto "github.com/crossplane/crossplane-runtime/v2/apis/proto/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+// Plugin keeps secrets in memory, by scoped name. The config reference of
+// requests is ignored.
+type Plugin struct {
+	essproto.UnimplementedExternalSecretStorePluginServiceServer
+
+	mu      sync.Mutex
+	secrets map[string]map[string][]byte
+}
+
+// New returns an empty Plugin.
+func New() *Plugin {
+	return &Plugin{secrets: map[string]map[string][]byte{}}
+}
+
+// Serve serves the plugin without TLS on the supplied listener until it is
+// closed.
+func (p *Plugin) Serve(lis net.Listener) error {
+	srv := grpc.NewServer()
+	essproto.RegisterExternalSecretStorePluginServiceServer(srv, p)
+	return srv.Serve(lis)
+}
+
+// Secret returns a copy of the data of a secret, or nil if there is none.
+func (p *Plugin) Secret(name string) map[string][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return maps.Clone(p.secrets[name])
+}
+
+// GetSecret returns a secret, which is empty if there is none.
+func (p *Plugin) GetSecret(_ context.Context, req *essproto.GetSecretRequest) (*essproto.GetSecretResponse, error) {
+	name := req.GetSecret().GetScopedName()
+	return &essproto.GetSecretResponse{Secret: &essproto.Secret{ScopedName: name, Data: p.Secret(name)}}, nil
+}
+
+// ApplySecret writes the supplied keys of a secret.
+func (p *Plugin) ApplySecret(_ context.Context, req *essproto.ApplySecretRequest) (*essproto.ApplySecretResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	name := req.GetSecret().GetScopedName()
+	s, ok := p.secrets[name]
+	if !ok {
+		s = map[string][]byte{}
+		p.secrets[name] = s
+	}
+	changed := !ok
+	for k, v := range req.GetSecret().GetData() {
+		if string(s[k]) != string(v) {
+			s[k] = v
+			changed = true
+		}
+	}
+	return &essproto.ApplySecretResponse{Changed: changed}, nil
+}
+
+// DeleteKeys deletes the supplied keys of a secret, or the whole secret if
+// none are supplied.
+func (p *Plugin) DeleteKeys(_ context.Context, req *essproto.DeleteKeysRequest) (*essproto.DeleteKeysResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	name := req.GetSecret().GetScopedName()
+	keys := req.GetSecret().GetData()
+	if len(keys) == 0 {
+		delete(p.secrets, name)
+		return &essproto.DeleteKeysResponse{}, nil
+	}
+	for k := range keys {
+		delete(p.secrets[name], k)
+	}
+	return &essproto.DeleteKeysResponse{}, nil
+}
@@ -0,0 +1,198 @@
+// Package selftest checks that the provider configuration, the controllers
+// and the API types agree with each other:
+//
+//   - every regular expression of the include list matches a resource of
+//     the Terraform provider schema
+//   - every configured resource has a managed resource controller
+//   - the kind of every managed resource controller is in the scheme
+//
+// A mismatch otherwise only shows when a resource of the kind is created.
+package selftest
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	xpcontroller "github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	tjconfig "github.com/crossplane/upjet/v2/pkg/config"
+	tjcontroller "github.com/crossplane/upjet/v2/pkg/controller"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/config"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// controllerPrefix is the prefix of the names of managed resource
+// controllers, followed by the lower case GroupVersionKind they reconcile.
+var controllerPrefix = managed.ControllerName("")
+
+// A SetupFn adds the controllers of the provider to a manager, like
+// controller.Setup.
+type SetupFn func(ctrl.Manager, tjcontroller.Options) error
+
+// A Report lists the problems found by Run. It is empty if there are none.
+type Report struct {
+	// UnmatchedIncludes are the include list entries matching no resource
+	// of the schema.
+	UnmatchedIncludes []string
+	// MissingControllers are the configured resources without a managed
+	// resource controller.
+	MissingControllers []string
+	// MissingKinds are the lower case kinds of managed resource
+	// controllers that are not in the scheme.
+	MissingKinds []string
+	// SetupError is the error returned when setting up the controllers.
+	SetupError error
+}
+
+// OK reports whether no problem was found.
+func (r *Report) OK() bool {
+	return len(r.UnmatchedIncludes) == 0 && len(r.MissingControllers) == 0 && len(r.MissingKinds) == 0 && r.SetupError == nil
+}
+
+// Write writes the report in a human readable form.
+func (r *Report) Write(w io.Writer) error {
+	if r.OK() {
+		_, err := fmt.Fprintln(w, "Self-test passed.")
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("Self-test failed:\n")
+	section := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		for _, i := range items {
+			fmt.Fprintf(&b, "  - %s\n", i)
+		}
+	}
+	section("Include list entries matching no schema resource", r.UnmatchedIncludes)
+	section("Resources without a controller", r.MissingControllers)
+	section("Controller kinds missing from the scheme", r.MissingKinds)
+	if r.SetupError != nil {
+		fmt.Fprintf(&b, "\nCannot set up controllers: %v\n", r.SetupError)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Err returns the report as an error, or nil if no problem was found.
+func (r *Report) Err() error {
+	if r.OK() {
+		return nil
+	}
+	var b strings.Builder
+	_ = r.Write(&b)
+	return fmt.Errorf("%s", strings.TrimSpace(b.String()))
+}
+
+// Run checks the supplied provider configuration and include list against
+// the controllers added by setup and the supplied scheme. The controllers
+// are added to a manager that is never started, so no API server is needed.
+func Run(pc *tjconfig.Provider, includes []string, scheme *runtime.Scheme, setup SetupFn) (*Report, error) {
+	r := &Report{UnmatchedIncludes: unmatchedIncludes(pc, includes)}
+
+	mgr, err := ctrl.NewManager(&rest.Config{Host: "https://localhost"}, ctrl.Options{
+		Scheme:  scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+		// The same controllers are set up again to run the provider.
+		Controller: ctrlconfig.Controller{SkipNameValidation: ptr.To(true)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create manager: %w", err)
+	}
+	rec := &recordingManager{Manager: mgr}
+	r.SetupError = setup(rec, tjcontroller.Options{
+		Options: xpcontroller.Options{
+			Logger:   logging.NewNopLogger(),
+			Features: &feature.Flags{},
+		},
+		Provider: pc,
+	})
+
+	names := rec.controllers()
+	for name, res := range pc.Resources {
+		gvk := schema.GroupVersionKind{Group: res.ShortGroup + "." + pc.RootGroup, Version: res.Version, Kind: res.Kind}
+		if _, ok := names[managed.ControllerName(gvk.String())]; !ok {
+			r.MissingControllers = append(r.MissingControllers, fmt.Sprintf("%s (%s)", name, gvk))
+		}
+	}
+	known := map[string]bool{}
+	for gvk := range scheme.AllKnownTypes() {
+		known[managed.ControllerName(gvk.String())] = true
+	}
+	for n := range names {
+		if !known[n] {
+			r.MissingKinds = append(r.MissingKinds, strings.TrimPrefix(n, controllerPrefix))
+		}
+	}
+	slices.Sort(r.MissingControllers)
+	slices.Sort(r.MissingKinds)
+	return r, nil
+}
+
+// unmatchedIncludes returns the entries of includes matching none of the
+// resources of pc, which are the schema resources upjet kept.
+func unmatchedIncludes(pc *tjconfig.Provider, includes []string) []string {
+	var out []string
+	for _, inc := range includes {
+		re, err := regexp.Compile(inc)
+		if err != nil {
+			out = append(out, fmt.Sprintf("%s (%v)", inc, err))
+			continue
+		}
+		matched := false
+		for name := range pc.Resources {
+			if re.MatchString(name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			out = append(out, inc)
+		}
+	}
+	return out
+}
+
+// recordingManager records the names of the event recorders requested by
+// the controllers, which managed resource controllers name after the
+// GroupVersionKind they reconcile, see managed.ControllerName.
+type recordingManager struct {
+	ctrl.Manager
+
+	mu    sync.Mutex
+	names []string
+}
+
+func (m *recordingManager) GetEventRecorderFor(name string) record.EventRecorder {
+	m.mu.Lock()
+	m.names = append(m.names, name)
+	m.mu.Unlock()
+	return m.Manager.GetEventRecorderFor(name)
+}
+
+// controllers returns the names of the managed resource controllers.
+func (m *recordingManager) controllers() map[string]struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := map[string]struct{}{}
+	for _, n := range m.names {
+		if strings.HasPrefix(n, controllerPrefix) {
+			out[n] = struct{}{}
+		}
+	}
+	return out
+}
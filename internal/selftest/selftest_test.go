@@ -0,0 +1,71 @@
+package selftest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	tjcontroller "github.com/crossplane/upjet/v2/pkg/controller"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis"
+	"github.com/millstonehq/provider-upjet-tailscale/config"
+	"github.com/millstonehq/provider-upjet-tailscale/internal/controller"
+)
+
+func TestRun(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Run(config.GetProvider(), config.IncludeList, scheme, controller.Setup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err != nil {
+		t.Errorf("Run(...): %v", err)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	pc := config.GetProvider()
+	includes := append([]string{"tailscale_dns_configuration$", "tailscale_(acl$"}, config.IncludeList...)
+	// Only set up the ACL controller, into an empty scheme.
+	setup := func(mgr ctrl.Manager, _ tjcontroller.Options) error {
+		mgr.GetEventRecorderFor(managed.ControllerName("acl.tailscale.upbound.io/v1alpha1, Kind=ACL"))
+		mgr.GetEventRecorderFor("fleet.device.tailscale.upbound.io")
+		return nil
+	}
+
+	report, err := Run(pc, includes, runtime.NewScheme(), setup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"tailscale_dns_configuration$", "tailscale_(acl$ (error parsing regexp: missing closing ): `tailscale_(acl$`)"}, report.UnmatchedIncludes); diff != "" {
+		t.Errorf("Run(...): -want unmatched includes, +got:\n%s", diff)
+	}
+	if got, want := len(report.MissingControllers), len(pc.Resources)-1; got != want {
+		t.Errorf("Run(...): got %d resources without a controller, want %d: %v", got, want, report.MissingControllers)
+	}
+	if diff := cmp.Diff([]string{"acl.tailscale.upbound.io/v1alpha1, kind=acl"}, report.MissingKinds); diff != "" {
+		t.Errorf("Run(...): -want missing kinds, +got:\n%s", diff)
+	}
+
+	var b strings.Builder
+	if err := report.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Self-test failed", "tailscale_dns_configuration$", "tailscale_webhook (webhook.tailscale.upbound.io/v1alpha1, Kind=Webhook)"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Write(...): report does not contain %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package shutdown
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// terraformCommand is the command name of the Terraform CLI processes.
+const terraformCommand = "terraform"
+
+// interruptTerraform interrupts the Terraform CLI processes started by the
+// provider, upon which Terraform stops after the resource operations in
+// progress and writes its state. Child processes are found in /proc, so
+// this only works on Linux.
+func interruptTerraform() error {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return fmt.Errorf("cannot list processes: %w", err)
+	}
+	self := os.Getpid()
+	var errs []error
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat")) //nolint:gosec // a file of /proc
+		if err != nil {
+			// The process exited.
+			continue
+		}
+		comm, ppid, ok := parseStat(string(stat))
+		if !ok || ppid != self || comm != terraformCommand {
+			continue
+		}
+		p, err := os.FindProcess(pid)
+		if err != nil {
+			continue
+		}
+		if err := p.Signal(os.Interrupt); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, fmt.Errorf("cannot interrupt process %d: %w", pid, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseStat returns the command name and parent process ID of a
+// /proc/<pid>/stat file, whose fields are "pid (comm) state ppid ...". The
+// command name may contain spaces and parentheses.
+func parseStat(stat string) (string, int, bool) {
+	open, closing := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if open < 0 || closing < open {
+		return "", 0, false
+	}
+	fields := strings.Fields(stat[closing+1:])
+	if len(fields) < 2 {
+		return "", 0, false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, false
+	}
+	return stat[open+1 : closing], ppid, true
+}
@@ -0,0 +1,363 @@
+// Package shutdown drains the provider when it stops, so that a Terraform
+// apply is not killed after it created a resource, such as an auth key, but
+// before the resource was recorded.
+//
+// When the manager stops, the controllers set up with the manager returned
+// by Drainer.Manager stop starting reconciles, but keep running those in
+// progress and their Terraform operations, including asynchronous applies
+// and destroys, for up to a timeout. Terraform operations still running then
+// are interrupted, upon which Terraform writes its state to the workspace,
+// and an event is recorded for their resources. The state files of the
+// workspaces are flushed to disk before the controllers stop, so that a
+// workspace directory on a persistent volume is picked up by the next
+// provider pod.
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/upjet/v2/pkg/terraform"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// reasonInterrupted is the reason of the events of interrupted Terraform
+	// operations.
+	reasonInterrupted event.Reason = "InterruptedTerraformOperation"
+
+	// interruptGracePeriod is how long interrupted Terraform operations are
+	// given to write their state.
+	interruptGracePeriod = 10 * time.Second
+
+	// stopGracePeriod is how long the other runnables of the manager are
+	// given to stop after draining.
+	stopGracePeriod = 30 * time.Second
+
+	// pollInterval is how often draining checks for reconciles and
+	// Terraform operations in progress.
+	pollInterval = 100 * time.Millisecond
+
+	// activeWorkers is the controller-runtime metric of the reconciles in
+	// progress.
+	activeWorkers = "controller_runtime_active_workers"
+)
+
+// ManagerTimeout returns the graceful shutdown timeout of the manager for
+// the supplied drain timeout, which leaves time to interrupt Terraform and
+// stop the other runnables.
+func ManagerTimeout(drain time.Duration) time.Duration {
+	return drain + interruptGracePeriod + stopGracePeriod
+}
+
+// A Drainer drains reconciles and Terraform operations when the manager
+// stops.
+type Drainer struct {
+	timeout time.Duration
+	dir     string
+	rec     event.Recorder
+	log     logging.Logger
+
+	// reconciling returns the number of reconciles in progress.
+	reconciling func() (int, error)
+	// interrupt interrupts the running Terraform CLI processes.
+	interrupt func() error
+
+	once     sync.Once
+	done     chan struct{}
+	draining atomic.Bool
+
+	mu  sync.Mutex
+	ops map[*operation]struct{}
+}
+
+// New returns a Drainer waiting up to the supplied timeout, that flushes
+// the state of the Terraform workspaces in dir and records events of
+// interrupted operations with the supplied recorder.
+func New(timeout time.Duration, dir string, rec event.Recorder, log logging.Logger) *Drainer {
+	return &Drainer{
+		timeout:     timeout,
+		dir:         dir,
+		rec:         rec,
+		log:         log,
+		reconciling: activeReconciles,
+		interrupt:   interruptTerraform,
+		done:        make(chan struct{}),
+		ops:         map[*operation]struct{}{},
+	}
+}
+
+// An operation is a Terraform CLI invocation for a resource.
+type operation struct {
+	obj     client.Object
+	started time.Time
+}
+
+func (d *Drainer) begin(obj client.Object) *operation {
+	op := &operation{obj: obj, started: time.Now()}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ops[op] = struct{}{}
+	return op
+}
+
+func (d *Drainer) end(op *operation) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.ops, op)
+}
+
+// running returns the Terraform operations in progress.
+func (d *Drainer) running() []*operation {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ops := make([]*operation, 0, len(d.ops))
+	for op := range d.ops {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// idle reports whether no reconcile or Terraform operation is in progress.
+func (d *Drainer) idle() bool {
+	if len(d.running()) > 0 {
+		return false
+	}
+	n, err := d.reconciling()
+	if err != nil {
+		d.log.Info("Cannot count reconciles in progress, not waiting for them", "error", err)
+		return true
+	}
+	return n == 0
+}
+
+// waitIdle waits up to the supplied timeout for reconciles and Terraform
+// operations to end, and reports whether they did.
+func (d *Drainer) waitIdle(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !d.idle() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(pollInterval)
+	}
+	return true
+}
+
+// drain waits for reconciles and Terraform operations in progress,
+// interrupts the Terraform operations still running after the timeout and
+// flushes the workspace state. It returns once draining is done.
+func (d *Drainer) drain() {
+	d.once.Do(func() {
+		defer close(d.done)
+		d.draining.Store(true)
+		d.log.Info("Draining reconciles and Terraform operations", "timeout", d.timeout, "terraform-operations", len(d.running()))
+
+		if !d.waitIdle(d.timeout) {
+			d.interruptRunning()
+		}
+		d.syncState()
+		d.log.Info("Drained reconciles and Terraform operations")
+	})
+	<-d.done
+}
+
+// interruptRunning interrupts the Terraform operations still running and
+// records an event for each of their resources.
+func (d *Drainer) interruptRunning() {
+	ops := d.running()
+	for _, op := range ops {
+		d.rec.Event(op.obj, event.Warning(reasonInterrupted, fmt.Errorf("terraform operation interrupted by provider shutdown after running for %s, its workspace state was kept and the next reconcile retries it", time.Since(op.started).Round(time.Second))))
+	}
+	d.log.Info("Interrupting Terraform operations still running", "terraform-operations", len(ops))
+	if err := d.interrupt(); err != nil {
+		d.log.Info("Cannot interrupt Terraform", "error", err)
+		return
+	}
+	if !d.waitIdle(interruptGracePeriod) {
+		d.log.Info("Terraform operations did not stop after they were interrupted", "terraform-operations", len(d.running()))
+	}
+}
+
+// syncState flushes the Terraform state files of the workspaces to disk.
+func (d *Drainer) syncState() {
+	files, err := filepath.Glob(filepath.Join(d.dir, "*", "terraform.tfstate"))
+	if err != nil {
+		d.log.Info("Cannot list Terraform state files", "error", err)
+		return
+	}
+	for _, name := range files {
+		if err := syncFile(name); err != nil {
+			d.log.Info("Cannot flush Terraform state file", "file", name, "error", err)
+		}
+	}
+}
+
+func syncFile(name string) error {
+	f, err := os.Open(name) //nolint:gosec // name is a state file in the workspace directory
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck // nothing to do about it
+	return f.Sync()
+}
+
+// activeReconciles returns the number of reconciles in progress, from the
+// controller-runtime metrics.
+func activeReconciles() (int, error) {
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		return 0, err
+	}
+	n := 0.0
+	for _, f := range families {
+		if f.GetName() != activeWorkers {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			n += m.GetGauge().GetValue()
+		}
+	}
+	return int(n), nil
+}
+
+// Manager returns a manager whose controllers drain before they stop. The
+// controllers run until draining is done, regardless of the context the
+// manager starts them with.
+func (d *Drainer) Manager(mgr ctrl.Manager) ctrl.Manager {
+	return &drainingManager{Manager: mgr, d: d}
+}
+
+type drainingManager struct {
+	ctrl.Manager
+	d *Drainer
+}
+
+func (m *drainingManager) Add(r manager.Runnable) error {
+	if c, ok := r.(controller.Controller); ok {
+		return m.Manager.Add(&drainingController{Controller: c, d: m.d})
+	}
+	return m.Manager.Add(r)
+}
+
+type drainingController struct {
+	controller.Controller
+	d *Drainer
+}
+
+// Start starts the controller with a context that is cancelled once the
+// supplied one is and draining is done.
+func (c *drainingController) Start(ctx context.Context) error {
+	cctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.d.drain()
+			cancel()
+		case <-cctx.Done():
+		}
+	}()
+	return c.Controller.Start(cctx)
+}
+
+// RateLimiter returns a rate limiter that delays every reconcile by the
+// timeout once draining started, and otherwise delegates to the supplied
+// rate limiter. The reconcilers of the controllers must use it, see
+// ratelimiter.NewReconciler.
+func (d *Drainer) RateLimiter(rl ratelimiter.RateLimiter) ratelimiter.RateLimiter {
+	return &rateLimiter{RateLimiter: rl, d: d}
+}
+
+type rateLimiter struct {
+	ratelimiter.RateLimiter
+	d *Drainer
+}
+
+func (r *rateLimiter) When(item string) time.Duration {
+	if r.d.draining.Load() {
+		return r.d.timeout
+	}
+	return r.RateLimiter.When(item)
+}
+
+// InstrumentSetup returns a terraform.SetupFn that sets a scheduler
+// tracking the Terraform CLI invocations of the resource in the setup
+// returned by fn.
+func (d *Drainer) InstrumentSetup(fn terraform.SetupFn) terraform.SetupFn {
+	return func(ctx context.Context, kube client.Client, mg resource.Managed) (terraform.Setup, error) {
+		ps, err := fn(ctx, kube, mg)
+		if err != nil {
+			return ps, err
+		}
+		inner := ps.Scheduler
+		if inner == nil {
+			inner = terraform.NewNoOpProviderScheduler()
+		}
+		ps.Scheduler = &scheduler{d: d, obj: mg, inner: inner}
+		return ps, nil
+	}
+}
+
+// scheduler is a terraform.ProviderScheduler tracking the Terraform CLI
+// invocations of one resource, and delegating to another scheduler.
+type scheduler struct {
+	d     *Drainer
+	obj   client.Object
+	inner terraform.ProviderScheduler
+}
+
+func (s *scheduler) Start(h terraform.ProviderHandle) (terraform.InUse, string, error) {
+	u, attachment, err := s.inner.Start(h)
+	if err != nil {
+		return nil, "", err
+	}
+	return &inUse{d: s.d, obj: s.obj, inner: u}, attachment, nil
+}
+
+func (s *scheduler) Stop(h terraform.ProviderHandle) error {
+	return s.inner.Stop(h)
+}
+
+// inUse tracks each invocation between Increment and Decrement. Apply and
+// destroy may run asynchronously, so invocations are ended in the order
+// they started.
+type inUse struct {
+	d     *Drainer
+	obj   client.Object
+	inner terraform.InUse
+
+	mu      sync.Mutex
+	started []*operation
+}
+
+func (u *inUse) Increment() {
+	u.inner.Increment()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.started = append(u.started, u.d.begin(u.obj))
+}
+
+func (u *inUse) Decrement() {
+	u.inner.Decrement()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.started) == 0 {
+		return
+	}
+	u.d.end(u.started[0])
+	u.started = u.started[1:]
+}
@@ -0,0 +1,242 @@
+package shutdown
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/event"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/crossplane/upjet/v2/pkg/terraform"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"github.com/millstonehq/provider-upjet-tailscale/apis/tailnetkey/v1alpha1"
+)
+
+// recorder records the reasons of the events of each object.
+type recorder struct {
+	mu     sync.Mutex
+	events map[string][]string
+}
+
+func (r *recorder) Event(obj runtime.Object, e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := obj.(metav1.Object).GetName()
+	r.events[name] = append(r.events[name], string(e.Reason))
+}
+
+func (r *recorder) WithAnnotations(_ ...string) event.Recorder { return r }
+
+func newDrainer(t *testing.T, timeout time.Duration) (*Drainer, *recorder) {
+	t.Helper()
+	rec := &recorder{events: map[string][]string{}}
+	d := New(timeout, t.TempDir(), rec, logging.NewNopLogger())
+	d.reconciling = func() (int, error) { return 0, nil }
+	return d, rec
+}
+
+// startOperation starts a Terraform operation for a Key with the supplied
+// name through the scheduler of the Drainer, and returns its InUse.
+func startOperation(t *testing.T, d *Drainer, name string) terraform.InUse {
+	t.Helper()
+	setup := d.InstrumentSetup(func(context.Context, client.Client, resource.Managed) (terraform.Setup, error) {
+		return terraform.Setup{}, nil
+	})
+	ps, err := setup(context.Background(), nil, &v1alpha1.Key{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _, err := ps.Scheduler.Start("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Increment()
+	return u
+}
+
+func TestDrain(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		// run runs Terraform operations, and returns the function
+		// interrupting them.
+		run  func(t *testing.T, d *Drainer) func() error
+		want map[string][]string
+	}{
+		"Finished": {
+			reason: "Operations that finish before the timeout should not be interrupted",
+			run: func(t *testing.T, d *Drainer) func() error {
+				u := startOperation(t, d, "ci")
+				go func() {
+					time.Sleep(2 * pollInterval)
+					u.Decrement()
+				}()
+				return func() error {
+					t.Error("interrupt(): want no interrupt")
+					return nil
+				}
+			},
+			want: map[string][]string{},
+		},
+		"Interrupted": {
+			reason: "Operations still running after the timeout should be interrupted, with an event",
+			run: func(t *testing.T, d *Drainer) func() error {
+				startOperation(t, d, "done").Decrement()
+				u := startOperation(t, d, "ci")
+				return func() error {
+					// Terraform writes its state and exits.
+					u.Decrement()
+					return nil
+				}
+			},
+			want: map[string][]string{"ci": {string(reasonInterrupted)}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d, rec := newDrainer(t, 5*pollInterval)
+			d.interrupt = tc.run(t, d)
+			d.drain()
+			if diff := cmp.Diff(tc.want, rec.events); diff != "" {
+				t.Errorf("\n%s\ndrain(): -want events, +got:\n%s", tc.reason, diff)
+			}
+			if n := len(d.running()); n != 0 {
+				t.Errorf("\n%s\ndrain(): want no operations running, got %d", tc.reason, n)
+			}
+		})
+	}
+}
+
+func TestDrainWaitsForReconciles(t *testing.T) {
+	d, _ := newDrainer(t, time.Minute)
+	var mu sync.Mutex
+	active := 1
+	d.reconciling = func() (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return active, nil
+	}
+	go func() {
+		time.Sleep(2 * pollInterval)
+		mu.Lock()
+		defer mu.Unlock()
+		active = 0
+	}()
+	start := time.Now()
+	d.drain()
+	if elapsed := time.Since(start); elapsed < 2*pollInterval || elapsed > 10*time.Second {
+		t.Errorf("drain(): want to return once the reconcile ended, returned after %s", elapsed)
+	}
+}
+
+func TestSyncState(t *testing.T) {
+	d, _ := newDrainer(t, time.Second)
+	ws := filepath.Join(d.dir, "uid")
+	if err := os.MkdirAll(ws, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "terraform.tfstate"), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d.syncState()
+	if err := syncFile(filepath.Join(ws, "missing.tfstate")); err == nil {
+		t.Error("syncFile(...): want error for a missing file")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	d, _ := newDrainer(t, time.Minute)
+	rl := d.RateLimiter(workqueue.NewTypedItemExponentialFailureRateLimiter[string](0, 0))
+	if got := rl.When("ci"); got != 0 {
+		t.Errorf("When(...): want no delay before draining, got %s", got)
+	}
+	d.draining.Store(true)
+	if got := rl.When("ci"); got != time.Minute {
+		t.Errorf("When(...): want the timeout as delay while draining, got %s", got)
+	}
+}
+
+// fakeController records whether the context it was started with was
+// cancelled.
+type fakeController struct {
+	controller.Controller
+	started chan context.Context
+}
+
+func (c *fakeController) Start(ctx context.Context) error {
+	c.started <- ctx
+	<-ctx.Done()
+	return nil
+}
+
+func TestDrainingController(t *testing.T) {
+	d, _ := newDrainer(t, time.Minute)
+	u := startOperation(t, d, "ci")
+
+	c := &drainingController{Controller: &fakeController{started: make(chan context.Context, 1)}, d: d}
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- c.Start(ctx) }()
+	cctx := <-c.Controller.(*fakeController).started
+
+	cancel()
+	time.Sleep(2 * pollInterval)
+	if cctx.Err() != nil {
+		t.Error("Start(...): want the controller to run while a Terraform operation is in progress")
+	}
+	u.Decrement()
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("Start(...): %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Start(...): want the controller to stop once drained")
+	}
+}
+
+func TestParseStat(t *testing.T) {
+	type want struct {
+		comm string
+		ppid int
+		ok   bool
+	}
+	cases := map[string]struct {
+		reason string
+		stat   string
+		want   want
+	}{
+		"Terraform": {
+			reason: "The command name and parent should be parsed",
+			stat:   "4242 (terraform) S 1 4242 1 0 -1",
+			want:   want{comm: "terraform", ppid: 1, ok: true},
+		},
+		"Parentheses": {
+			reason: "Command names with spaces and parentheses should be parsed",
+			stat:   "7 (a (b) c) R 42 7 7 0 -1",
+			want:   want{comm: "a (b) c", ppid: 42, ok: true},
+		},
+		"Truncated": {
+			reason: "Truncated files should not be parsed",
+			stat:   "7 (sh",
+			want:   want{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			comm, ppid, ok := parseStat(tc.stat)
+			if diff := cmp.Diff(tc.want, want{comm: comm, ppid: ppid, ok: ok}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nparseStat(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}